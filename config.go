@@ -1,6 +1,15 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"tmobile-stats/internal/config"
+	"tmobile-stats/internal/logger"
+	"tmobile-stats/internal/redact"
+)
 
 func validateInterval(interval int) error {
 	if interval <= 0 {
@@ -11,9 +20,176 @@ func validateInterval(interval int) error {
 
 func validateFormat(format string) error {
 	switch format {
-	case "json", "csv", "":
+	case "json", "csv", "sqlite", "tsdb", "influx", "":
+		return nil
+	default:
+		return fmt.Errorf("invalid format: %s. Must be 'json', 'csv', 'sqlite', 'tsdb' or 'influx'", format)
+	}
+}
+
+// minPingPayload is the smallest ICMP payload a probe may carry.
+const minPingPayload = 24
+
+func validatePingTargets(targets []config.PingTarget) error {
+	for _, t := range targets {
+		if t.Host == "" {
+			return fmt.Errorf("ping target host must not be empty")
+		}
+		switch t.Network {
+		case "", "ip4", "ip6":
+		default:
+			return fmt.Errorf("invalid network for ping target %s: %s. Must be 'ip4' or 'ip6'", t.Host, t.Network)
+		}
+		if t.Size != 0 && (t.Size < minPingPayload || t.Size > 65500) {
+			return fmt.Errorf("invalid payload size for ping target %s: %d. Must be between %d and 65500", t.Host, t.Size, minPingPayload)
+		}
+		if t.TTL < 0 || t.TTL > 255 {
+			return fmt.Errorf("invalid TTL for ping target %s: %d. Must be between 1 and 255, or 0 for the system default", t.Host, t.TTL)
+		}
+		if t.TOS < 0 || t.TOS > 255 {
+			return fmt.Errorf("invalid TOS for ping target %s: %d. Must be between 0 and 255", t.Host, t.TOS)
+		}
+	}
+	return nil
+}
+
+func validateAdaptive(a config.AdaptiveConfig) error {
+	if !a.Enabled {
 		return nil
+	}
+	if a.BurstInterval <= 0 {
+		return fmt.Errorf("adaptive burst_interval must be greater than 0, got %d", a.BurstInterval)
+	}
+	if a.BurstDuration <= 0 {
+		return fmt.Errorf("adaptive burst_duration must be greater than 0, got %d", a.BurstDuration)
+	}
+	return nil
+}
+
+// validateConfig runs every check on a fully merged (file + flags) config.
+func validateConfig(cfg *config.Config) error {
+	if err := validateInterval(cfg.RefreshInterval); err != nil {
+		return err
+	}
+	if err := validateFormat(cfg.Format); err != nil {
+		return err
+	}
+	if err := validatePingTargets(cfg.Targets()); err != nil {
+		return err
+	}
+	if err := validateAdaptive(cfg.Adaptive); err != nil {
+		return err
+	}
+	if err := validateSinks(cfg.Sinks); err != nil {
+		return err
+	}
+	if err := validateStdout(cfg); err != nil {
+		return err
+	}
+	if cfg.Influx.URL != "" && cfg.Influx.Bucket == "" {
+		return fmt.Errorf("influx: bucket is required")
+	}
+	if e := cfg.OTLP.Endpoint; e != "" && !strings.HasPrefix(e, "http://") && !strings.HasPrefix(e, "https://") {
+		return fmt.Errorf("otlp: endpoint must be an http(s) URL, got %q", e)
+	}
+	if cfg.MetricsPort < 0 || cfg.MetricsPort > 65535 {
+		return fmt.Errorf("invalid metrics port: %d", cfg.MetricsPort)
+	}
+	if _, err := fanoutOptions(cfg.Logging); err != nil {
+		return err
+	}
+	if cfg.Rollup.MinuteDays < 0 || cfg.Rollup.HourDays < 0 {
+		return fmt.Errorf("rollup: retention days must not be negative")
+	}
+	if _, err := redactor(cfg.Redact); err != nil {
+		return err
+	}
+	_, err := rotationPolicy(cfg.Rotation)
+	return err
+}
+
+// fanoutOptions converts the logging config section into queue options.
+func fanoutOptions(l config.LoggingConfig) (logger.FanoutOptions, error) {
+	if l.QueueSize < 0 || l.BatchSize < 0 {
+		return logger.FanoutOptions{}, fmt.Errorf("logging: queue and batch sizes must not be negative")
+	}
+	opts := logger.FanoutOptions{QueueSize: l.QueueSize, BatchSize: l.BatchSize}
+	switch l.Fsync {
+	case "", "never":
+	case "batch":
+		opts.Sync = logger.SyncBatch
+	default:
+		d, err := time.ParseDuration(l.Fsync)
+		if err != nil || d < time.Second {
+			return logger.FanoutOptions{}, fmt.Errorf("invalid fsync policy: %s. Use never, batch or a duration of at least 1s", l.Fsync)
+		}
+		opts.Sync, opts.SyncInterval = logger.SyncInterval, d
+	}
+	return opts, nil
+}
+
+// redactor builds the redaction applied to every log, or nil if disabled.
+func redactor(r config.RedactConfig) (*redact.Redactor, error) {
+	if !r.Enabled {
+		return nil, nil
+	}
+	// A random key would give every restart new pseudonyms, splitting one
+	// tower or gateway into several within the same log.
+	if (r.Mode == "" || r.Mode == "hash") && r.Key == "" {
+		return nil, fmt.Errorf("redact: hash mode needs a key to keep pseudonyms stable across restarts")
+	}
+	rd, err := redact.New(redact.Options{Mode: r.Mode, Key: r.Key})
+	if err != nil {
+		return nil, fmt.Errorf("redact: %w", err)
+	}
+	return rd, nil
+}
+
+// rotationPolicy converts the rotation config section into a logger policy.
+func rotationPolicy(r config.RotationConfig) (logger.Rotation, error) {
+	if r.MaxSizeMB < 0 || r.MaxAgeDays < 0 || r.MaxFiles < 0 {
+		return logger.Rotation{}, fmt.Errorf("rotation limits must not be negative")
+	}
+
+	var every time.Duration
+	switch r.Every {
+	case "":
+	case "hourly":
+		every = time.Hour
+	case "daily":
+		every = 24 * time.Hour
+	case "weekly":
+		every = 7 * 24 * time.Hour
 	default:
-		return fmt.Errorf("invalid format: %s. Must be 'json' or 'csv'", format)
+		d, err := time.ParseDuration(r.Every)
+		if err != nil || d < time.Minute {
+			return logger.Rotation{}, fmt.Errorf("invalid rotation period: %s. Use hourly, daily, weekly or a duration of at least 1m", r.Every)
+		}
+		every = d
+	}
+
+	return logger.Rotation{
+		MaxSize:  int64(r.MaxSizeMB) * 1024 * 1024,
+		Every:    every,
+		MaxAge:   time.Duration(r.MaxAgeDays) * 24 * time.Hour,
+		MaxFiles: r.MaxFiles,
+		Compress: r.Compress,
+	}, nil
+}
+
+// parseOTLPHeaders reads the OTEL_EXPORTER_OTLP_HEADERS format,
+// "key1=value1,key2=value2" with URL-encoded values.
+func parseOTLPHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.QueryUnescape(strings.TrimSpace(v)); err == nil {
+			v = unescaped
+		}
+		headers[strings.TrimSpace(k)] = v
 	}
-}
\ No newline at end of file
+	return headers
+}
@@ -1,11 +1,19 @@
 package main
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/config"
+	"tmobile-stats/internal/logger"
+	"tmobile-stats/internal/models"
+)
 
 func TestValidateInterval(t *testing.T) {
 	tests := []struct {
-		input    int
-		wantErr  bool
+		input   int
+		wantErr bool
 	}{
 		{5, false},   // Default/Valid
 		{1, false},   // Valid
@@ -24,11 +32,13 @@ func TestValidateInterval(t *testing.T) {
 
 func TestValidateFormat(t *testing.T) {
 	tests := []struct {
-		input    string
-		wantErr  bool
+		input   string
+		wantErr bool
 	}{
 		{"json", false},
 		{"csv", false},
+		{"sqlite", false},
+		{"tsdb", false},
 		{"", false}, // Default (disabled)
 		{"xml", true},
 		{"txt", true},
@@ -40,4 +50,173 @@ func TestValidateFormat(t *testing.T) {
 			t.Errorf("validateFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestValidatePingTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  config.PingTarget
+		wantErr bool
+	}{
+		{"Default", config.PingTarget{Host: "8.8.8.8"}, false},
+		{"IPv6 EF", config.PingTarget{Host: "2001:4860:4860::8888", PingOptions: models.PingOptions{Network: "ip6", TOS: 184}}, false},
+		{"Large payload", config.PingTarget{Host: "8.8.8.8", PingOptions: models.PingOptions{Size: 1472, DontFragment: true}}, false},
+		{"Empty host", config.PingTarget{}, true},
+		{"Bad network", config.PingTarget{Host: "8.8.8.8", PingOptions: models.PingOptions{Network: "ipx"}}, true},
+		{"Tiny payload", config.PingTarget{Host: "8.8.8.8", PingOptions: models.PingOptions{Size: 8}}, true},
+		{"TTL too high", config.PingTarget{Host: "8.8.8.8", PingOptions: models.PingOptions{TTL: 300}}, true},
+		{"Negative TTL", config.PingTarget{Host: "8.8.8.8", PingOptions: models.PingOptions{TTL: -1}}, true},
+		{"TOS too high", config.PingTarget{Host: "8.8.8.8", PingOptions: models.PingOptions{TOS: 256}}, true},
+	}
+
+	for _, tt := range tests {
+		err := validatePingTargets([]config.PingTarget{tt.target})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: validatePingTargets() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRotationPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		rot     config.RotationConfig
+		every   time.Duration
+		wantErr bool
+	}{
+		{"Disabled", config.RotationConfig{}, 0, false},
+		{"Daily", config.RotationConfig{Every: "daily", MaxAgeDays: 30}, 24 * time.Hour, false},
+		{"Duration", config.RotationConfig{Every: "6h"}, 6 * time.Hour, false},
+		{"Bad period", config.RotationConfig{Every: "fortnightly"}, 0, true},
+		{"Too short", config.RotationConfig{Every: "10s"}, 0, true},
+		{"Negative", config.RotationConfig{MaxFiles: -1}, 0, true},
+	}
+
+	for _, tt := range tests {
+		rot, err := rotationPolicy(tt.rot)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: rotationPolicy() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && rot.Every != tt.every {
+			t.Errorf("%s: expected period %v, got %v", tt.name, tt.every, rot.Every)
+		}
+	}
+}
+
+func TestFanoutOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		logging  config.LoggingConfig
+		sync     logger.SyncPolicy
+		interval time.Duration
+		wantErr  bool
+	}{
+		{"Default", config.LoggingConfig{}, logger.SyncNever, 0, false},
+		{"Batch", config.LoggingConfig{Fsync: "batch"}, logger.SyncBatch, 0, false},
+		{"Interval", config.LoggingConfig{Fsync: "1m"}, logger.SyncInterval, time.Minute, false},
+		{"Bad policy", config.LoggingConfig{Fsync: "always"}, 0, 0, true},
+		{"Negative", config.LoggingConfig{QueueSize: -1}, 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		opts, err := fanoutOptions(tt.logging)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: fanoutOptions() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (opts.Sync != tt.sync || opts.SyncInterval != tt.interval) {
+			t.Errorf("%s: expected %v/%v, got %v/%v", tt.name, tt.sync, tt.interval, opts.Sync, opts.SyncInterval)
+		}
+	}
+}
+
+func TestRedactor(t *testing.T) {
+	tests := []struct {
+		name    string
+		redact  config.RedactConfig
+		enabled bool
+		wantErr bool
+	}{
+		{"Disabled", config.RedactConfig{Mode: "bogus"}, false, false},
+		{"Hash", config.RedactConfig{Enabled: true, Key: "k"}, true, false},
+		{"Hash without key", config.RedactConfig{Enabled: true, Mode: "hash"}, false, true},
+		{"Drop", config.RedactConfig{Enabled: true, Mode: "drop"}, true, false},
+		{"Bad mode", config.RedactConfig{Enabled: true, Mode: "blur"}, false, true},
+	}
+
+	for _, tt := range tests {
+		rd, err := redactor(tt.redact)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: redactor() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if (rd != nil) != tt.enabled {
+			t.Errorf("%s: expected enabled %v, got %v", tt.name, tt.enabled, rd != nil)
+		}
+	}
+}
+
+func TestParseOTLPHeaders(t *testing.T) {
+	got := parseOTLPHeaders("api-key=secret, Authorization=Bearer%20abc,broken")
+	if len(got) != 2 || got["api-key"] != "secret" || got["Authorization"] != "Bearer abc" {
+		t.Errorf("parseOTLPHeaders = %v", got)
+	}
+	if got := parseOTLPHeaders(""); len(got) != 0 {
+		t.Errorf("Expected no headers, got %v", got)
+	}
+}
+
+func TestSinkConfigs(t *testing.T) {
+	cfg := &config.Config{
+		Format: "csv",
+		Output: "legacy.csv",
+		Sinks: []config.SinkConfig{
+			{Type: "csv", Options: []byte(`{"path": "filtered.csv"}`), Fields: []string{"ping"}},
+			{Type: "webhook", Name: "alerts"},
+		},
+		MQTT: config.MQTTConfig{Broker: "tcp://localhost:1883"},
+	}
+
+	sinks := sinkConfigs(cfg)
+	var names []string
+	for _, s := range sinks {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "csv,csv-2,alerts,mqtt" {
+		t.Fatalf("Sinks = %s", got)
+	}
+	if string(sinks[0].Options) != `{"path":"legacy.csv"}` {
+		t.Errorf("Legacy options = %s", sinks[0].Options)
+	}
+	if !strings.Contains(string(sinks[3].Options), `"broker":"tcp://localhost:1883"`) {
+		t.Errorf("MQTT options = %s", sinks[3].Options)
+	}
+
+	if err := validateSinks(cfg.Sinks); err != nil {
+		t.Errorf("validateSinks: %v", err)
+	}
+	if err := validateSinks([]config.SinkConfig{{Type: "fax"}}); err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+}
+
+func TestValidateStdout(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		wantErr bool
+	}{
+		{"Influx file", config.Config{Format: "influx", Output: "out.lp"}, false},
+		{"Influx stdout", config.Config{Format: "influx", Output: "-"}, true},
+		{"Influx stdout silent", config.Config{Format: "influx", Output: "-", Silent: true}, false},
+		{"Influx stdout live", config.Config{Format: "influx", Output: "-", Silent: true, LiveMode: true}, true},
+		{"Influx sink stdout", config.Config{Sinks: []config.SinkConfig{{Type: "influx", Options: []byte(`{"path": "-"}`)}}}, true},
+	}
+
+	for _, tt := range tests {
+		if err := validateStdout(&tt.cfg); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validateStdout() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
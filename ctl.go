@@ -0,0 +1,217 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/config"
+	"tmobile-stats/internal/control"
+	"tmobile-stats/internal/models"
+	"tmobile-stats/internal/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// runStatus prints the state of a running instance from its control socket.
+func runStatus(args []string) {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	socketPtr := fs.String("socket", control.DefaultSocketPath(), "Control socket of the running instance")
+	jsonPtr := fs.Bool("json", false, "Print the raw JSON status")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: signal-sentry status [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	client, err := control.Dial(*socketPtr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	var st control.StatusResult
+	if err := client.Call(control.MethodStatus, nil, &st); err != nil {
+		fmt.Fprintf(os.Stderr, "Status failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *jsonPtr {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(st)
+		return
+	}
+	printStatus(st)
+}
+
+func printStatus(st control.StatusResult) {
+	uptime := time.Duration(st.Uptime * float64(time.Second)).Round(time.Second)
+	fmt.Printf("Signal Sentry %s (pid %d), up %v\n", st.Version, st.PID, uptime)
+
+	interval := time.Duration(st.Interval * float64(time.Second)).String()
+	if st.Scheduler != "" {
+		interval += " [" + st.Scheduler + "]"
+	}
+	fmt.Printf("Interval:  %s\n", interval)
+	fmt.Printf("Polls:     %d (%d failed)\n", st.Collector.Polls, st.Collector.Errors)
+	if st.Collector.LastError != "" {
+		fmt.Printf("Last error: %s (%s)\n", st.Collector.LastError, st.Collector.LastErrorTime.Format("2006-01-02 15:04:05"))
+	}
+
+	if s := st.Sample; s != nil {
+		d := s.Gateway.Device
+		fmt.Printf("\nDevice:    %s | FW: %s\n", d.Model, d.SoftwareVersion)
+		fmt.Printf("Sample:    %s\n", st.SampleTime.Format("2006-01-02 15:04:05"))
+		printRadio("5G", s.Gateway.Signal.FiveG)
+		printRadio("4G", s.Gateway.Signal.FourG)
+		if p := s.Ping; p.Sent > 0 {
+			voip := analysis.CalculateVoIPScoreForPing(p)
+			fmt.Printf("Ping:      min/avg/max/std %.1f/%.1f/%.1f/%.1f ms, loss %.1f%%, MOS %.2f (%s)\n",
+				p.Min, p.Avg, p.Max, p.StdDev, p.Loss, voip.MOS, voip.Rating())
+		}
+	} else {
+		fmt.Println("\nNo sample collected yet.")
+	}
+
+	lp := st.LifetimePing
+	fmt.Printf("Lifetime:  %d sent, %d received, %.1f%% loss, avg %.1f ms\n", lp.Sent, lp.Received, lp.Loss, lp.Avg)
+
+	if len(st.Subscriptions) > 0 {
+		fmt.Println("\nConsumers:")
+		for _, sub := range st.Subscriptions {
+			fmt.Printf("  %-10s delivered %d, dropped %d, queued %d\n", sub.Name, sub.Delivered, sub.Dropped, sub.Queued)
+		}
+	}
+
+	if len(st.Logging) > 0 {
+		fmt.Println("\nLogs:")
+		for _, l := range st.Logging {
+			fmt.Printf("  %-10s written %d, dropped %d, errors %d, queued %d\n", l.Name, l.Written, l.Dropped, l.Errors, l.Queued)
+			if l.LastError != "" {
+				fmt.Printf("  %-10s last error: %s\n", "", l.LastError)
+			}
+		}
+	}
+}
+
+// runCtl sends a command to a running instance.
+func runCtl(args []string) {
+	fs := flag.NewFlagSet("ctl", flag.ExitOnError)
+	socketPtr := fs.String("socket", control.DefaultSocketPath(), "Control socket of the running instance")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: signal-sentry ctl [flags] <command> [args]\n\n")
+		fmt.Fprintf(os.Stderr, "Commands:\n")
+		fmt.Fprintf(os.Stderr, "  interval <seconds>   Change the base poll interval\n")
+		fmt.Fprintf(os.Stderr, "  annotate <text>      Attach a note to the next sample\n")
+		fmt.Fprintf(os.Stderr, "  burst [reason]       Start a burst capture now\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var method string
+	var params any
+	rest := fs.Args()[1:]
+	switch fs.Arg(0) {
+	case "interval":
+		if len(rest) != 1 {
+			fmt.Fprintln(os.Stderr, "interval takes exactly one argument (seconds)")
+			os.Exit(2)
+		}
+		seconds, err := strconv.Atoi(strings.TrimSuffix(rest[0], "s"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid interval: %s\n", rest[0])
+			os.Exit(2)
+		}
+		method, params = control.MethodSetInterval, control.IntervalParams{Seconds: seconds}
+	case "annotate":
+		if len(rest) == 0 {
+			fmt.Fprintln(os.Stderr, "annotate needs some text")
+			os.Exit(2)
+		}
+		method, params = control.MethodAnnotate, control.AnnotateParams{Text: strings.Join(rest, " ")}
+	case "burst":
+		method, params = control.MethodBurst, control.BurstParams{Reason: strings.Join(rest, " ")}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n", fs.Arg(0))
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	client, err := control.Dial(*socketPtr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	var st control.StatusResult
+	if err := client.Call(method, params, &st); err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %v\n", fs.Arg(0), err)
+		os.Exit(1)
+	}
+
+	switch method {
+	case control.MethodSetInterval:
+		fmt.Printf("Interval set to %v\n", time.Duration(st.Interval*float64(time.Second)))
+	case control.MethodAnnotate:
+		fmt.Println("Annotation queued for the next sample")
+	case control.MethodBurst:
+		fmt.Printf("Burst started: %s\n", st.Scheduler)
+	}
+}
+
+func printRadio(name string, c models.ConnectionStats) {
+	if len(c.Bands) == 0 && c.Bars == 0 {
+		return
+	}
+	tower := c.GNBID
+	if tower == 0 {
+		tower = c.PCID
+	}
+	fmt.Printf("%s:        %s | bars %.1f | RSRP %d | SINR %d | RSRQ %d | tower %d\n",
+		name, strings.Join(c.Bands, ","), c.Bars, c.RSRP, c.SINR, c.RSRQ, tower)
+}
+
+// runAttach renders the live dashboard from a running instance's sample
+// stream, without polling the gateway a second time.
+func runAttach(args []string) {
+	fs := flag.NewFlagSet("attach", flag.ExitOnError)
+	socketPtr := fs.String("socket", control.DefaultSocketPath(), "Control socket of the running instance")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: signal-sentry attach [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	src, err := control.Attach(*socketPtr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer src.Close()
+
+	p := tea.NewProgram(ui.NewModel(config.DefaultConfig(), src), tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error running UI: %v\n", err)
+		os.Exit(1)
+	}
+	if err := src.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Lost connection to instance: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"tmobile-stats/internal/config"
+	"tmobile-stats/internal/daemon"
+)
+
+// fetchBudget is the longest a single poll can take (3 attempts with a 5s
+// client timeout plus backoff); the watchdog allows for it on top of the interval.
+const fetchBudget = 20 * time.Second
+
+// runDaemon runs the monitor unattended: no terminal output besides errors,
+// loggers are flushed and closed on SIGINT/SIGTERM, and systemd is kept
+// informed via sd_notify when started as a Type=notify service.
+func runDaemon(args []string) {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	configPath := fs.String("config", "config.json", "Path to config file (JSON)")
+	intervalPtr := fs.Int("interval", 0, "Refresh interval in seconds")
+	pidfilePtr := fs.String("pidfile", "", "Write the process ID to this file")
+	webPtr := fs.Bool("web", false, "Enable background web server")
+	webPortPtr := fs.Int("web-port", 8080, "Port for background web server")
+	adaptivePtr := fs.Bool("adaptive", false, "Poll at a short burst interval after signal degradation")
+	metricsPortPtr := fs.Int("metrics-port", 0, "Serve Prometheus /metrics on this port (also on the -web server)")
+	socketPtr := fs.String("socket", "", "Control socket for status/ctl (default per-user runtime path, \"none\" disables)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: signal-sentry daemon [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "interval":
+			cfg.RefreshInterval = *intervalPtr
+		case "web":
+			cfg.WebEnabled = *webPtr
+		case "web-port":
+			cfg.WebPort = *webPortPtr
+		case "adaptive":
+			cfg.Adaptive.Enabled = *adaptivePtr
+		case "socket":
+			cfg.ControlSocket = *socketPtr
+		case "metrics-port":
+			cfg.MetricsPort = *metricsPortPtr
+		}
+	})
+	cfg.LiveMode = false
+	cfg.Silent = true
+
+	if err := validateConfig(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *pidfilePtr != "" {
+		if err := daemon.WritePIDFile(*pidfilePtr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer daemon.RemovePIDFile(*pidfilePtr)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	m, err := newMonitor(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		daemon.RemovePIDFile(*pidfilePtr)
+		os.Exit(1)
+	}
+	if cfg.WebEnabled {
+		m.startWeb(true)
+	}
+	m.startMetrics(false)
+	if err := m.startOTLP(ctx, false); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		m.Close()
+		daemon.RemovePIDFile(*pidfilePtr)
+		os.Exit(1)
+	}
+	m.startControl(ctx, false)
+
+	sub := m.coll.Subscribe("daemon", 16)
+	var lastSample atomic.Int64
+	lastSample.Store(time.Now().UnixNano())
+
+	go m.Run(ctx)
+	notify(daemon.Ready + "\n" + daemon.Status(fmt.Sprintf("Polling %s every %ds", cfg.RouterURL, cfg.RefreshInterval)))
+	fmt.Fprintf(os.Stderr, "signal-sentry %s daemon started (pid %d)\n", Version, os.Getpid())
+
+	if interval, ok := daemon.WatchdogInterval(); ok {
+		go watchdog(ctx, interval, func() bool {
+			// Only vouch for the process while the collector is still publishing
+			stale := 2*m.coll.Scheduler().Base() + fetchBudget
+			return time.Since(time.Unix(0, lastSample.Load())) < stale
+		})
+	}
+
+	failing := false
+	for s := range sub.C {
+		lastSample.Store(s.Time.UnixNano())
+		switch {
+		case s.Err != nil && !failing:
+			fmt.Fprintf(os.Stderr, "Error fetching stats: %v\n", s.Err)
+			notify(daemon.Status("Gateway unreachable: " + s.Err.Error()))
+			failing = true
+		case s.Err == nil && failing:
+			fmt.Fprintln(os.Stderr, "Gateway reachable again")
+			notify(daemon.Status(fmt.Sprintf("Polling %s every %ds", cfg.RouterURL, cfg.RefreshInterval)))
+			failing = false
+		}
+	}
+
+	// The bus closed because a signal cancelled ctx
+	notify(daemon.Stopping)
+	if err := m.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error closing logs: %v\n", err)
+		daemon.RemovePIDFile(*pidfilePtr)
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stderr, "signal-sentry daemon stopped, logs flushed")
+}
+
+// watchdog sends WATCHDOG=1 at half the systemd timeout while healthy reports true.
+func watchdog(ctx context.Context, interval time.Duration, healthy func() bool) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if healthy() {
+				notify(daemon.Watchdog)
+			}
+		}
+	}
+}
+
+func notify(state string) {
+	if _, err := daemon.Notify(state); err != nil {
+		fmt.Fprintf(os.Stderr, "sd_notify failed: %v\n", err)
+	}
+}
+
+// runInstallService writes a systemd unit that runs `signal-sentry daemon`.
+func runInstallService(args []string) {
+	fs := flag.NewFlagSet("install-service", flag.ExitOnError)
+	outputPtr := fs.String("output", daemon.DefaultUnitPath, "Where to write the unit file (- for stdout)")
+	configPtr := fs.String("config", "config.json", "Config file the service should use")
+	workdirPtr := fs.String("workdir", "", "Working directory for logs (default: current directory)")
+	userPtr := fs.String("user", "", "Run the service as this user instead of root")
+	pidfilePtr := fs.String("pidfile", "", "Pidfile the daemon should write")
+	watchdogPtr := fs.Int("watchdog", 120, "systemd watchdog timeout in seconds (0 disables)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: signal-sentry install-service [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	exe, err := os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not locate executable: %v\n", err)
+		os.Exit(1)
+	}
+
+	workdir := *workdirPtr
+	if workdir == "" {
+		workdir, _ = os.Getwd()
+	}
+	configPath, _ := filepath.Abs(*configPtr)
+
+	execStart := []string{quoteUnitArg(exe), "daemon", "-config", quoteUnitArg(configPath)}
+	pidfile := ""
+	if *pidfilePtr != "" {
+		pidfile, _ = filepath.Abs(*pidfilePtr)
+		execStart = append(execStart, "-pidfile", quoteUnitArg(pidfile))
+	}
+
+	unit := daemon.Unit{
+		ExecStart:        strings.Join(execStart, " "),
+		WorkingDirectory: workdir,
+		User:             *userPtr,
+		PIDFile:          pidfile,
+		WatchdogSec:      *watchdogPtr,
+	}
+
+	if *outputPtr == "-" {
+		if err := daemon.WriteUnit(os.Stdout, unit); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write unit: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	f, err := os.OpenFile(*outputPtr, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create unit file: %v\n", err)
+		os.Exit(1)
+	}
+	if err := daemon.WriteUnit(f, unit); err != nil {
+		f.Close()
+		fmt.Fprintf(os.Stderr, "Failed to write unit: %v\n", err)
+		os.Exit(1)
+	}
+	if err := f.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write unit: %v\n", err)
+		os.Exit(1)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(*outputPtr), ".service")
+	fmt.Printf("Wrote %s\n", *outputPtr)
+	fmt.Printf("Enable it with:\n  sudo systemctl daemon-reload\n  sudo systemctl enable --now %s\n", name)
+}
+
+// quoteUnitArg quotes a path for ExecStart= if it contains whitespace.
+func quoteUnitArg(s string) string {
+	if strings.ContainsAny(s, " \t") {
+		return `"` + s + `"`
+	}
+	return s
+}
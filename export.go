@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/export"
+	"tmobile-stats/internal/redact"
+)
+
+// runExport converts a log (any format LoadLog reads) into a file for
+// other tools, or a subset of it for sharing.
+func runExport(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	inputPtr := fs.String("input", "stats.log", "Path to the log, SQLite database or tsdb store to export")
+	outputPtr := fs.String("output", "", "Output file, or - for stdout (default signal-data.<ext>)")
+	formatPtr := fs.String("format", "parquet", "Output format: "+strings.Join(export.Formats, ", "))
+	fieldsPtr := fs.String("fields", "", "Comma-separated JSON paths to keep (e.g. gateway.signal.5g,ping.avg)")
+	everyPtr := fs.Duration("every", 0, "Keep one sample per interval (e.g. 1m)")
+	redactPtr := fs.String("redact", "", "Scrub serial, MAC, tower/cell IDs and IPs: hash (pseudonyms) or drop")
+	redactKeyPtr := fs.String("redact-key", "", "Secret behind -redact hash pseudonyms (random if empty)")
+	shiftPtr := fs.Duration("shift", 0, "Add this to every timestamp (e.g. -720h)")
+	startPtr := fs.String("start", "", "Start time (YYYY-MM-DD [HH:MM:SS])")
+	endPtr := fs.String("end", "", "End time (YYYY-MM-DD [HH:MM:SS])")
+	rangePtr := fs.Duration("range", 0, "Relative time range from now (e.g. 24h, 1h30m)")
+	rowGroupPtr := fs.Int("row-group", export.ParquetRowGroupSize, "Samples per Parquet row group")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: signal-sentry export [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if !slices.Contains(export.Formats, *formatPtr) {
+		fmt.Fprintf(os.Stderr, "Error: unsupported export format %q (use %s)\n", *formatPtr, strings.Join(export.Formats, ", "))
+		os.Exit(2)
+	}
+	if *everyPtr < 0 {
+		fmt.Fprintf(os.Stderr, "Error: -every must not be negative\n")
+		os.Exit(2)
+	}
+	output := *outputPtr
+	if output == "" {
+		output = "signal-data." + export.Ext(*formatPtr)
+	}
+	var rd *redact.Redactor
+	if *redactPtr != "" || *shiftPtr != 0 {
+		mode := *redactPtr
+		if mode == "" {
+			mode = "none"
+		}
+		var err error
+		if rd, err = redact.New(redact.Options{Mode: mode, Key: *redactKeyPtr, Shift: *shiftPtr}); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	opts := export.Options{RowGroupSize: *rowGroupPtr}
+	for _, f := range strings.Split(*fieldsPtr, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			opts.Fields = append(opts.Fields, f)
+		}
+	}
+
+	filter, err := analysis.NewTimeFilter(*startPtr, *endPtr, *rangePtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	data, err := analysis.LoadLog(*inputPtr, filter)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", *inputPtr, err)
+		os.Exit(1)
+	}
+	data = export.Downsample(data, *everyPtr)
+	if rd != nil {
+		for i := range data {
+			data[i] = *rd.Sample(&data[i])
+		}
+	}
+
+	if output == "-" {
+		if err := export.Write(os.Stdout, *formatPtr, data, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	err = export.Write(f, *formatPtr, data, opts)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(output) // Don't leave a truncated file behind
+		fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Exported %d samples to %s\n", len(data), output)
+}
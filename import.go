@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/logger"
+)
+
+// runImport loads JSON logs, including their rotated segments, into an
+// SQLite database. Samples already in the database are skipped, so
+// importing overlapping logs or re-running an import is harmless.
+func runImport(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	dbPtr := fs.String("db", "signal-data.db", "SQLite database to import into")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: signal-sentry import [flags] <log>...\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	db, err := logger.NewSQLiteLogger(*dbPtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open database: %v\n", err)
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, path := range fs.Args() {
+		data, err := analysis.LoadLog(path, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		added, err := db.Import(data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to import %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: %d samples, %d new\n", path, len(data), added)
+	}
+
+	if err := db.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to close database: %v\n", err)
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
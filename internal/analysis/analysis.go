@@ -2,45 +2,69 @@ package analysis
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
 	"text/tabwriter"
 	"time"
 
+	"tmobile-stats/internal/logger"
 	"tmobile-stats/internal/models"
+	"tmobile-stats/internal/rollup"
 )
 
 type Metric struct {
-	Min   float64
-	Max   float64
-	Sum   float64
-	Count int
+	Min    float64
+	Max    float64
+	Sum    float64
+	Count  int
+	Weight float64 // Total weight; only set via AddWeighted
 }
 
 func (m *Metric) Add(val float64) {
+	m.AddWeighted(val, 1)
+}
+
+// AddWeighted adds a value that counts for w samples in the average.
+// Burst samples use a weight below 1 so they don't dominate averages.
+func (m *Metric) AddWeighted(val, w float64) {
 	if m.Count == 0 || val < m.Min {
 		m.Min = val
 	}
 	if m.Count == 0 || val > m.Max {
 		m.Max = val
 	}
-	m.Sum += val
+	m.Sum += val * w
+	m.Weight += w
 	m.Count++
 }
 
 func (m Metric) Avg() float64 {
+	if m.Weight > 0 {
+		return m.Sum / m.Weight
+	}
 	if m.Count == 0 {
 		return 0
 	}
 	return m.Sum / float64(m.Count)
 }
 
+// Annotation is an operator note recorded with a sample.
+type Annotation struct {
+	Time time.Time
+	Text string
+}
+
 type Report struct {
 	TotalSamples int
+	BurstSamples int
+	Annotations  []Annotation
 	StartTime    time.Time
 	EndTime      time.Time
 	Filter       *TimeFilter
@@ -54,9 +78,16 @@ type Report struct {
 	TotalPingSent int
 	TotalPingLost int
 
-	Bands  map[string]int
-	Towers map[int]int
-	Bars   map[float64]int
+	// VoIP quality: per-sample MOS plus an E-model score for the whole window
+	MOS        Metric
+	CallReady  float64 // Weight of the samples in MOS that were call ready
+	WindowVoIP VoIPScore
+
+	// Time shares as summed sample weights, out of TotalWeight
+	TotalWeight float64
+	Bands       map[string]float64
+	Towers      map[int]float64
+	Bars        map[float64]float64
 	LastTowerID int
 	LastBars    float64
 
@@ -64,24 +95,74 @@ type Report struct {
 	AvgBars1h       float64
 	AvgSignalHealth float64
 	Has1hData       bool
+
+	// Per-target ping summaries, keyed by PingStats.Label().
+	// Only populated when the log contains additional targets.
+	Targets     map[string]*TargetSummary
+	TargetOrder []string
+}
+
+// TargetSummary aggregates latency and loss for one ping target and option set.
+type TargetSummary struct {
+	Latency Metric
+	StdDev  Metric
+	Sent    int
+	Lost    int
+}
+
+func (r *Report) addTarget(p models.PingStats) {
+	label := p.Label()
+	t, ok := r.Targets[label]
+	if !ok {
+		t = &TargetSummary{}
+		r.Targets[label] = t
+		r.TargetOrder = append(r.TargetOrder, label)
+	}
+	if p.Received > 0 {
+		t.Latency.Add(p.Avg)
+		t.StdDev.Add(p.StdDev)
+	}
+	t.Sent += p.Sent
+	t.Lost += p.Sent - p.Received
 }
 
-func Run(path string, filter *TimeFilter) error {
-	file, err := os.Open(path)
+func Run(path string, filter *TimeFilter, resolution string) error {
+	data, res, st, err := loadHistory(path, filter, resolution)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	return Analyze(file, os.Stdout, filter)
+	warnParse(st)
+	if res != 0 {
+		fmt.Printf("Using %s rollups\n", rollup.Name(res))
+	}
+	return AnalyzeSamples(data, os.Stdout, filter)
 }
 
 func Analyze(input io.Reader, output io.Writer, filter *TimeFilter) error {
+	// Fetch raw data using the new exported parser
+	data, st, err := ParseLog(input, filter)
+	if err != nil {
+		return err
+	}
+	warnParse(st)
+	return AnalyzeSamples(data, output, filter)
+}
+
+// warnParse tells the user about lines left out of the report.
+func warnParse(st ParseStats) {
+	if msg := st.String(); msg != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s in log\n", msg)
+	}
+}
+
+// AnalyzeSamples prints the report for samples already loaded from any backend.
+func AnalyzeSamples(data []models.CombinedStats, output io.Writer, filter *TimeFilter) error {
 	report := &Report{
-		Bands:  make(map[string]int),
-		Towers: make(map[int]int),
-		Bars:   make(map[float64]int),
-		Filter: filter,
+		Bands:   make(map[string]float64),
+		Towers:  make(map[int]float64),
+		Bars:    make(map[float64]float64),
+		Targets: make(map[string]*TargetSummary),
+		Filter:  filter,
 	}
 	// Initialize Min values to avoid 0.0 bias
 	report.Ping.Min = math.MaxFloat64
@@ -89,14 +170,7 @@ func Analyze(input io.Reader, output io.Writer, filter *TimeFilter) error {
 	report.RSRP.Min = 0
 	report.SINR.Min = 99
 
-	// Fetch raw data using the new exported parser
-	data, err := ParseLog(input, filter)
-	if err != nil {
-		return err
-	}
-
-	var sumBars float64
-	var sumHealth float64
+	var sumBars, sumHealth, sumWeight float64
 
 	for _, stats := range data {
 		report.TotalSamples++
@@ -111,12 +185,23 @@ func Analyze(input io.Reader, output io.Writer, filter *TimeFilter) error {
 			report.EndTime = sampleTime
 		}
 
-		report.RSRP.Add(float64(stats.Gateway.Signal.FiveG.RSRP))
-		report.SINR.Add(float64(stats.Gateway.Signal.FiveG.SINR))
+		// Burst samples are taken at a shorter interval, so each one stands
+		// for less time than a regular sample
+		weight := stats.SampleWeight()
+		sumWeight += weight
+		if stats.Burst {
+			report.BurstSamples++
+		}
+		for _, text := range stats.Annotations {
+			report.Annotations = append(report.Annotations, Annotation{Time: sampleTime, Text: text})
+		}
+
+		report.RSRP.AddWeighted(float64(stats.Gateway.Signal.FiveG.RSRP), weight)
+		report.SINR.AddWeighted(float64(stats.Gateway.Signal.FiveG.SINR), weight)
 
 		// Accumulate Bars & Health
-		sumBars += stats.Gateway.Signal.FiveG.Bars
-		sumHealth += CalculateSignalHealth(stats.Gateway.Signal.FiveG.RSRP, stats.Gateway.Signal.FiveG.SINR)
+		sumBars += stats.Gateway.Signal.FiveG.Bars * weight
+		sumHealth += CalculateSignalHealth(stats.Gateway.Signal.FiveG.RSRP, stats.Gateway.Signal.FiveG.SINR) * weight
 
 		if stats.Ping.Received > 0 {
 			// Ignore 0.0 pings for Min calculation as it was a bug in earlier versions
@@ -128,38 +213,53 @@ func Analyze(input io.Reader, output io.Writer, filter *TimeFilter) error {
 			if report.Ping.Count == 0 || stats.Ping.Max > report.Ping.Max {
 				report.Ping.Max = stats.Ping.Max
 			}
-			report.Ping.Sum += stats.Ping.Avg
+			report.Ping.Sum += stats.Ping.Avg * weight
+			report.Ping.Weight += weight
 			report.Ping.Count++
 
 			// Add StdDev stats
-			report.StdDev.Add(stats.Ping.StdDev)
+			report.StdDev.AddWeighted(stats.Ping.StdDev, weight)
+		}
+		report.Loss.AddWeighted(stats.Ping.Loss, weight)
+		if stats.Ping.Sent > 0 {
+			score := CalculateVoIPScoreForPing(stats.Ping)
+			report.MOS.AddWeighted(score.MOS, weight)
+			if score.CallReady() {
+				report.CallReady += weight
+			}
 		}
-		report.Loss.Add(stats.Ping.Loss)
 		report.TotalPingSent += stats.Ping.Sent
 		report.TotalPingLost += stats.Ping.Sent - stats.Ping.Received
 
 		for _, b := range stats.Gateway.Signal.FiveG.Bands {
-			report.Bands[b]++
+			report.Bands[b] += weight
 		}
 		towerID := stats.Gateway.Signal.FiveG.GNBID
 		if towerID != 0 {
-			report.Towers[towerID]++
+			report.Towers[towerID] += weight
 			report.LastTowerID = towerID
 		}
 
-		report.Bars[stats.Gateway.Signal.FiveG.Bars]++
+		report.Bars[stats.Gateway.Signal.FiveG.Bars] += weight
 		report.LastBars = stats.Gateway.Signal.FiveG.Bars
+
+		if len(stats.Targets) > 0 {
+			report.addTarget(stats.Ping)
+			for _, t := range stats.Targets {
+				report.addTarget(t)
+			}
+		}
 	}
 
 	// Finalize Averages
+	report.TotalWeight = sumWeight
 	if report.TotalSamples > 0 {
-		report.AvgBarsOverall = sumBars / float64(report.TotalSamples)
-		report.AvgSignalHealth = sumHealth / float64(report.TotalSamples)
+		report.AvgBarsOverall = sumBars / sumWeight
+		report.AvgSignalHealth = sumHealth / sumWeight
 
 		// Calculate Last 1h
 		oneHourAgo := report.EndTime.Add(-1 * time.Hour)
-		var sumBars1h float64
-		var count1h int
+		var sumBars1h, weight1h float64
 
 		// Iterate backwards from the end of data slice
 		for i := len(data) - 1; i >= 0; i-- {
@@ -167,13 +267,18 @@ func Analyze(input io.Reader, output io.Writer, filter *TimeFilter) error {
 			if sampleTime.Before(oneHourAgo) {
 				break
 			}
-			sumBars1h += data[i].Gateway.Signal.FiveG.Bars
-			count1h++
+			sumBars1h += data[i].Gateway.Signal.FiveG.Bars * data[i].SampleWeight()
+			weight1h += data[i].SampleWeight()
+		}
+
+		if report.TotalPingSent > 0 {
+			globalLoss := float64(report.TotalPingLost) / float64(report.TotalPingSent) * 100
+			report.WindowVoIP = CalculateVoIPScore(report.Ping.Avg(), report.StdDev.Avg(), globalLoss)
 		}
 
 		// Only show Last 1h if we have at least 55m of data duration
-		if report.EndTime.Sub(report.StartTime) >= 55*time.Minute && count1h > 0 {
-			report.AvgBars1h = sumBars1h / float64(count1h)
+		if report.EndTime.Sub(report.StartTime) >= 55*time.Minute && weight1h > 0 {
+			report.AvgBars1h = sumBars1h / weight1h
 			report.Has1hData = true
 		}
 	}
@@ -182,15 +287,116 @@ func Analyze(input io.Reader, output io.Writer, filter *TimeFilter) error {
 	return nil
 }
 
+// ParseStats describes the lines ParseLog could not use. Line numbers count
+// from the start of the stream, so for a rotated set they run on across
+// segments; only the first maxReportedLines of each kind are listed.
+type ParseStats struct {
+	Skipped      int // Rows that aren't samples, e.g. CSV rows before any header
+	Corrupt      int // Lines that failed to decode, e.g. cut short by a crash
+	Recovered    int // Samples salvaged from the end of corrupt lines
+	SkippedLines []int
+	CorruptLines []int
+}
+
+const maxReportedLines = 20
+
+func (s *ParseStats) reject(line int, corrupt bool) {
+	if corrupt {
+		s.Corrupt++
+		if len(s.CorruptLines) < maxReportedLines {
+			s.CorruptLines = append(s.CorruptLines, line)
+		}
+		return
+	}
+	s.Skipped++
+	if len(s.SkippedLines) < maxReportedLines {
+		s.SkippedLines = append(s.SkippedLines, line)
+	}
+}
+
+// String describes the problems, e.g. "2 corrupt lines (17, 42), 1 sample
+// recovered"; it is empty when every line was read.
+func (s ParseStats) String() string {
+	var parts []string
+	if s.Corrupt > 0 {
+		parts = append(parts, fmt.Sprintf("%d corrupt %s (%s)", s.Corrupt, plural(s.Corrupt, "line"), lineList(s.CorruptLines, s.Corrupt)))
+	}
+	if s.Skipped > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped %s (%s)", s.Skipped, plural(s.Skipped, "line"), lineList(s.SkippedLines, s.Skipped)))
+	}
+	if s.Recovered > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s recovered", s.Recovered, plural(s.Recovered, "sample")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+func lineList(lines []int, total int) string {
+	strs := make([]string, len(lines))
+	for i, l := range lines {
+		strs[i] = strconv.Itoa(l)
+	}
+	if total > len(lines) {
+		strs = append(strs, "...")
+	}
+	return strings.Join(strs, ", ")
+}
+
+// recordStart marks where a JSON sample begins, to salvage the record glued
+// onto the tail of one cut short by a crash.
+var recordStart = []byte(`{"gateway":`)
+
 // ParseLog reads the provided reader and returns a slice of CombinedStats.
-// It skips malformed lines.
-func ParseLog(r io.Reader, filter *TimeFilter) ([]models.CombinedStats, error) {
+// The log may be JSON lines or CSV as written by -format csv, detected from
+// its first line. Lines that can't be decoded are counted in the returned
+// stats rather than failing the whole log.
+func ParseLog(r io.Reader, filter *TimeFilter) ([]models.CombinedStats, ParseStats, error) {
 	var results []models.CombinedStats
-	scanner := bufio.NewScanner(r)
+	st, err := scanLog(r, filter, func(s *models.CombinedStats) error {
+		results = append(results, *s)
+		return nil
+	})
+	if err != nil {
+		return nil, st, err
+	}
+	return results, st, nil
+}
+
+// scanLog is ParseLog calling fn with each sample instead of collecting
+// them. An error from fn stops the scan.
+func scanLog(r io.Reader, filter *TimeFilter, fn func(s *models.CombinedStats) error) (ParseStats, error) {
+	br := bufio.NewReader(r)
+	if isCSVLog(br) {
+		return scanCSVLog(br, filter, fn)
+	}
+
+	var st ParseStats
+	lineNo := 0
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
 	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
 		var stats models.CombinedStats
-		if err := json.Unmarshal(scanner.Bytes(), &stats); err != nil {
-			continue // Skip malformed lines
+		if err := json.Unmarshal(line, &stats); err != nil {
+			st.reject(lineNo, true)
+			// A record cut short by a crash may have the next one glued on
+			i := bytes.LastIndex(line, recordStart)
+			stats = models.CombinedStats{}
+			if i <= 0 || json.Unmarshal(line[i:], &stats) != nil {
+				continue
+			}
+			st.Recovered++
 		}
 
 		// Filter by time
@@ -199,14 +405,40 @@ func ParseLog(r io.Reader, filter *TimeFilter) ([]models.CombinedStats, error) {
 			continue
 		}
 
-		results = append(results, stats)
+		if err := fn(&stats); err != nil {
+			return st, err
+		}
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
-	}
+	return st, scanner.Err()
+}
+
+// isCSVLog peeks at the start of r for a CSV header.
+func isCSVLog(r *bufio.Reader) bool {
+	head, _ := r.Peek(64)
+	head = bytes.TrimLeft(head, " \t\r\n")
+	return bytes.HasPrefix(head, []byte("Version,")) || bytes.HasPrefix(head, []byte("Timestamp,"))
+}
 
-	return results, nil
+func scanCSVLog(r io.Reader, filter *TimeFilter, fn func(s *models.CombinedStats) error) (ParseStats, error) {
+	var st ParseStats
+	cr := logger.NewCSVReader(r)
+	cr.OnReject = st.reject
+	for {
+		stats, err := cr.Read()
+		if err == io.EOF {
+			return st, nil
+		}
+		if err != nil {
+			return st, err
+		}
+		if filter != nil && !filter.Contains(time.Unix(stats.Gateway.Time.LocalTime, 0)) {
+			continue
+		}
+		if err := fn(stats); err != nil {
+			return st, err
+		}
+	}
 }
 
 func printReport(w io.Writer, r *Report) {
@@ -234,6 +466,9 @@ func printReport(w io.Writer, r *Report) {
 	fmt.Fprintf(w, "Data Range:    %s to %s\n", r.StartTime.Format("2006-01-02 15:04:05"), r.EndTime.Format("15:04:05"))
 	fmt.Fprintf(w, "Duration:      %v\n", duration.Round(time.Second))
 	fmt.Fprintf(w, "Total Samples: %d\n", r.TotalSamples)
+	if r.BurstSamples > 0 {
+		fmt.Fprintf(w, "Burst Samples: %d (down-weighted in averages)\n", r.BurstSamples)
+	}
 	fmt.Fprintln(w)
 
 	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
@@ -261,14 +496,49 @@ sMin := r.StdDev.Min
 		fmt.Fprintf(w, "  Packet Loss: %d / %d (%.2f%%)\n", r.TotalPingLost, r.TotalPingSent, globalLoss)
 	}
 
+	if r.MOS.Count > 0 {
+		v := r.WindowVoIP
+		verdict := "No - expect choppy or delayed audio"
+		if v.CallReady() {
+			verdict = "Yes"
+		}
+		fmt.Fprintf(w, "\nVOIP QUALITY (E-model, G.711):\n")
+		fmt.Fprintf(w, "  Window:      R %.1f / MOS %.2f (%s)\n", v.R, v.MOS, v.Rating())
+		fmt.Fprintf(w, "  Per Sample:  MOS min %.2f, avg %.2f, max %.2f\n", r.MOS.Min, r.MOS.Avg(), r.MOS.Max)
+		fmt.Fprintf(w, "  Call Ready:  %.1f%% of samples\n", r.CallReady/r.MOS.Weight*100)
+		fmt.Fprintf(w, "  Take Calls?  %s\n", verdict)
+	}
+
+	if len(r.TargetOrder) > 0 {
+		fmt.Fprintln(w, "\nPING TARGETS:")
+		twt := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(twt, "  TARGET\tAVG (ms)\tSTDDEV (ms)\tLOSS")
+		for _, label := range r.TargetOrder {
+			t := r.Targets[label]
+			loss := 0.0
+			if t.Sent > 0 {
+				loss = float64(t.Lost) / float64(t.Sent) * 100
+			}
+			fmt.Fprintf(twt, "  %s\t%.1f\t%.1f\t%d / %d (%.2f%%)\n", label, t.Latency.Avg(), t.StdDev.Avg(), t.Lost, t.Sent, loss)
+		}
+		twt.Flush()
+	}
+
+	if len(r.Annotations) > 0 {
+		fmt.Fprintln(w, "\nANNOTATIONS:")
+		for _, a := range r.Annotations {
+			fmt.Fprintf(w, "  %s  %s\n", a.Time.Format("2006-01-02 15:04:05"), a.Text)
+		}
+	}
+
 	fmt.Fprintln(w, "\nBANDS SEEN:")
-	printMap(w, r.Bands, r.TotalSamples, duration)
+	printMap(w, r.Bands, r.TotalWeight, duration)
 
 	fmt.Fprintln(w, "\nTOWERS SEEN:")
-	printTowerMap(w, r.Towers, r.TotalSamples, r.LastTowerID, duration)
+	printTowerMap(w, r.Towers, r.TotalWeight, r.LastTowerID, duration)
 
 	fmt.Fprintln(w, "\nBARS SEEN:")
-	printFloatMap(w, r.Bars, r.TotalSamples, r.LastBars, duration)
+	printFloatMap(w, r.Bars, r.TotalWeight, r.LastBars, duration)
 
 	fmt.Fprintln(w, "\nBARS AVG:")
 	tw2 := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
@@ -282,7 +552,9 @@ sMin := r.StdDev.Min
 	fmt.Fprintln(w, "================================================================================")
 }
 
-func printMap(w io.Writer, m map[string]int, total int, totalDuration time.Duration) {
+// printMap and friends print each value's share of the samples, weighted
+// so burst samples and sparse rollup buckets count for the time they cover.
+func printMap(w io.Writer, m map[string]float64, total float64, totalDuration time.Duration) {
 	keys := make([]string, 0, len(m))
 	for k := range m {
 		keys = append(keys, k)
@@ -292,14 +564,14 @@ func printMap(w io.Writer, m map[string]int, total int, totalDuration time.Durat
 	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 	for _, k := range keys {
 		count := m[k]
-		pct := float64(count) / float64(total) * 100
-		dur := time.Duration(float64(totalDuration) * (float64(count) / float64(total)))
-		fmt.Fprintf(tw, "  %s\t%d samples (%.1f%%)\t%s\n", k, count, pct, formatSmartDuration(dur))
+		pct := count / total * 100
+		dur := time.Duration(float64(totalDuration) * (count / total))
+		fmt.Fprintf(tw, "  %s\t%s samples (%.1f%%)\t%s\n", k, formatWeight(count), pct, formatSmartDuration(dur))
 	}
 	tw.Flush()
 }
 
-func printTowerMap(w io.Writer, m map[int]int, total int, liveTowerID int, totalDuration time.Duration) {
+func printTowerMap(w io.Writer, m map[int]float64, total float64, liveTowerID int, totalDuration time.Duration) {
 	keys := make([]int, 0, len(m))
 	for k := range m {
 		keys = append(keys, k)
@@ -309,18 +581,18 @@ func printTowerMap(w io.Writer, m map[int]int, total int, liveTowerID int, total
 	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 	for _, k := range keys {
 		count := m[k]
-		pct := float64(count) / float64(total) * 100
+		pct := count / total * 100
 		suffix := ""
 		if k == liveTowerID {
 			suffix = " live"
 		}
-		dur := time.Duration(float64(totalDuration) * (float64(count) / float64(total)))
-		fmt.Fprintf(tw, "  %d\t%d samples (%.1f%%)%s\t%s\n", k, count, pct, suffix, formatSmartDuration(dur))
+		dur := time.Duration(float64(totalDuration) * (count / total))
+		fmt.Fprintf(tw, "  %d\t%s samples (%.1f%%)%s\t%s\n", k, formatWeight(count), pct, suffix, formatSmartDuration(dur))
 	}
 	tw.Flush()
 }
 
-func printFloatMap(w io.Writer, m map[float64]int, total int, realTimeVal float64, totalDuration time.Duration) {
+func printFloatMap(w io.Writer, m map[float64]float64, total float64, realTimeVal float64, totalDuration time.Duration) {
 	keys := make([]float64, 0, len(m))
 	for k := range m {
 		keys = append(keys, k)
@@ -330,17 +602,23 @@ func printFloatMap(w io.Writer, m map[float64]int, total int, realTimeVal float6
 	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
 	for _, k := range keys {
 		count := m[k]
-		pct := float64(count) / float64(total) * 100
+		pct := count / total * 100
 		suffix := ""
 		if k == realTimeVal {
 			suffix = " real-time"
 		}
-		dur := time.Duration(float64(totalDuration) * (float64(count) / float64(total)))
-		fmt.Fprintf(tw, "  %g\t%d samples (%.1f%%)%s\t%s\n", k, count, pct, suffix, formatSmartDuration(dur))
+		dur := time.Duration(float64(totalDuration) * (count / total))
+		fmt.Fprintf(tw, "  %g\t%s samples (%.1f%%)%s\t%s\n", k, formatWeight(count), pct, suffix, formatSmartDuration(dur))
 	}
 	tw.Flush()
 }
 
+// formatWeight prints a summed sample weight to one decimal, without a
+// trailing ".0" for whole samples.
+func formatWeight(v float64) string {
+	return strconv.FormatFloat(math.Round(v*10)/10, 'f', -1, 64)
+}
+
 func formatSmartDuration(d time.Duration) string {
 	d = d.Round(time.Second)
 	h := d / time.Hour
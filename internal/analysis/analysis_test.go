@@ -5,6 +5,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"tmobile-stats/internal/models"
 )
 
 func TestAnalyzeEndToEnd(t *testing.T) {
@@ -37,6 +39,7 @@ func TestAnalyzeEndToEnd(t *testing.T) {
 		"BARS AVG:",
 		"Overall", "3.5",
 		"SgnlHealth", "3.9",
+		"VOIP QUALITY", "Take Calls?  Yes",
 	}
 
 	for _, check := range checks {
@@ -178,4 +181,148 @@ func TestAnalyzeRealTimeBars(t *testing.T) {
 	if strings.Contains(result, unexpectedRealTime) {
 		t.Errorf("Expected Bars 3 NOT to be marked real-time, but it was.\nOutput:\n%s", result)
 	}
-}
\ No newline at end of file
+}
+
+func TestAnalyzePingTargets(t *testing.T) {
+	jsonInput := `
+{"gateway":{"time":{"localTime":1767651600},"signal":{"5g":{"bands":["n41"],"bars":3.0,"rsrp":-100,"sinr":5,"gNBID":100}}},"ping":{"avg":20,"sent":10,"received":10,"target":"8.8.8.8"},"targets":[{"avg":30,"sent":10,"received":9,"target":"2001:4860:4860::8888","options":{"network":"ip6"}}]}
+{"gateway":{"time":{"localTime":1767651660},"signal":{"5g":{"bands":["n41"],"bars":3.0,"rsrp":-100,"sinr":5,"gNBID":100}}},"ping":{"avg":22,"sent":10,"received":10,"target":"8.8.8.8"},"targets":[{"avg":34,"sent":10,"received":10,"target":"2001:4860:4860::8888","options":{"network":"ip6"}}]}
+`
+	var output bytes.Buffer
+	if err := Analyze(strings.NewReader(strings.TrimSpace(jsonInput)), &output, nil); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	result := output.String()
+	checks := []string{
+		"PING TARGETS:",
+		"8.8.8.8",
+		"2001:4860:4860::8888 ip6",
+		"32.0",
+		"1 / 20 (5.00%)",
+	}
+	for _, check := range checks {
+		if !strings.Contains(result, check) {
+			t.Errorf("Expected output to contain %q.\nOutput:\n%s", check, result)
+		}
+	}
+}
+
+func TestAnalyzeBurstWeighting(t *testing.T) {
+	// One regular sample at -90 and five 1s burst samples at -110 (weight 0.2 each).
+	// Weighted RSRP average: (-90*1 + -110*1) / 2 = -100
+	jsonInput := `
+{"gateway":{"time":{"localTime":1767651600},"signal":{"5g":{"bands":["n41"],"bars":4.0,"rsrp":-90,"sinr":10,"gNBID":100}}},"ping":{}}
+{"gateway":{"time":{"localTime":1767651605},"signal":{"5g":{"bands":["n41"],"bars":2.0,"rsrp":-110,"sinr":10,"gNBID":100}}},"ping":{},"burst":true,"weight":0.2,"annotations":["moved gateway"]}
+{"gateway":{"time":{"localTime":1767651606},"signal":{"5g":{"bands":["n41"],"bars":2.0,"rsrp":-110,"sinr":10,"gNBID":100}}},"ping":{},"burst":true,"weight":0.2}
+{"gateway":{"time":{"localTime":1767651607},"signal":{"5g":{"bands":["n41"],"bars":2.0,"rsrp":-110,"sinr":10,"gNBID":100}}},"ping":{},"burst":true,"weight":0.2}
+{"gateway":{"time":{"localTime":1767651608},"signal":{"5g":{"bands":["n41"],"bars":2.0,"rsrp":-110,"sinr":10,"gNBID":100}}},"ping":{},"burst":true,"weight":0.2}
+{"gateway":{"time":{"localTime":1767651609},"signal":{"5g":{"bands":["n41"],"bars":2.0,"rsrp":-110,"sinr":10,"gNBID":100}}},"ping":{},"burst":true,"weight":0.2}
+`
+	var output bytes.Buffer
+	if err := Analyze(strings.NewReader(strings.TrimSpace(jsonInput)), &output, nil); err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+
+	result := output.String()
+	checks := []string{
+		"Total Samples: 6",
+		"Burst Samples: 5",
+		"-100.0",
+		"Overall     3.0",
+		"ANNOTATIONS:",
+		"moved gateway",
+	}
+	for _, check := range checks {
+		if !strings.Contains(result, check) {
+			t.Errorf("Expected output to contain %q.\nOutput:\n%s", check, result)
+		}
+	}
+}
+
+func TestAnalyzeBurstKeepsTimeShares(t *testing.T) {
+	// Ten seconds on tower 100, then ten on tower 200, where a burst
+	// replaced the second 5s sample with five 1s samples (weight 0.2 each).
+	// The burst covers the same time, so each tower keeps half.
+	sample := func(at int64, tower int, burst bool) models.CombinedStats {
+		var s models.CombinedStats
+		s.Gateway.Time.LocalTime = 1767651600 + at
+		s.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{"n41"}, Bars: 4, GNBID: tower}
+		if burst {
+			s.Burst, s.Weight = true, 0.2
+		}
+		return s
+	}
+	data := []models.CombinedStats{sample(0, 100, false), sample(5, 100, false), sample(10, 200, false)}
+	for i := int64(0); i < 5; i++ {
+		data = append(data, sample(15+i, 200, true))
+	}
+
+	var output bytes.Buffer
+	if err := AnalyzeSamples(data, &output, nil); err != nil {
+		t.Fatalf("AnalyzeSamples failed: %v", err)
+	}
+	result := output.String()
+	for _, check := range []string{"100  2 samples (50.0%)", "200  2 samples (50.0%) live", "n41  4 samples (100.0%)"} {
+		if !strings.Contains(result, check) {
+			t.Errorf("Expected output to contain %q.\nOutput:\n%s", check, result)
+		}
+	}
+}
+
+func TestAnalyzeBurstWeightsPing(t *testing.T) {
+	// One good regular sample and five bad burst samples (weight 0.2 each)
+	// count the same: latency (20 + 220) / 2 = 120 ms, half call ready.
+	var data []models.CombinedStats
+	for i := 0; i < 6; i++ {
+		var s models.CombinedStats
+		s.Gateway.Time.LocalTime = 1767651600 + int64(i)
+		s.Ping = models.PingStats{Min: 20, Avg: 20, Max: 20, Sent: 10, Received: 10}
+		if i > 0 {
+			s.Burst, s.Weight = true, 0.2
+			s.Ping = models.PingStats{Min: 220, Avg: 220, Max: 220, StdDev: 50, Loss: 50, Sent: 10, Received: 5}
+		}
+		data = append(data, s)
+	}
+
+	var output bytes.Buffer
+	if err := AnalyzeSamples(data, &output, nil); err != nil {
+		t.Fatalf("AnalyzeSamples failed: %v", err)
+	}
+	result := output.String()
+	for _, check := range []string{"Ping (ms)    20.0  120.0  220.0", "StdDev (ms)  0.0   25.0   50.0", "Call Ready:  50.0% of samples"} {
+		if !strings.Contains(result, check) {
+			t.Errorf("Expected output to contain %q.\nOutput:\n%s", check, result)
+		}
+	}
+}
+
+func TestParseLogReportsCorruptLines(t *testing.T) {
+	input := `{"gateway":{"time":{"localTime":1767651600}}}
+not json
+{"gateway":{"time":{"localTi{"gateway":{"time":{"localTime":1767651660}}}
+{"gateway":{"time":{"localTime":1767651720}}}
+`
+	data, st, err := ParseLog(strings.NewReader(input), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 3 || data[1].Gateway.Time.LocalTime != 1767651660 {
+		t.Errorf("Expected 3 samples with the glued one recovered, got %+v", data)
+	}
+	if st.Corrupt != 2 || len(st.CorruptLines) != 2 || st.CorruptLines[0] != 2 || st.CorruptLines[1] != 3 || st.Recovered != 1 {
+		t.Errorf("Stats = %+v", st)
+	}
+	if got := st.String(); got != "2 corrupt lines (2, 3), 1 sample recovered" {
+		t.Errorf("String() = %q", got)
+	}
+
+	csvInput := "Version,Timestamp\n2,2026-01-05T12:00:00Z\n2,2026-01-05T12:00:05Z,extra\n2,\"unterminated\n"
+	data, st, err = ParseLog(strings.NewReader(csvInput), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 1 || st.Corrupt != 2 || st.CorruptLines[0] != 3 || st.CorruptLines[1] != 4 {
+		t.Errorf("CSV: %d samples, stats %+v", len(data), st)
+	}
+}
@@ -0,0 +1,100 @@
+package analysis
+
+import (
+	"time"
+
+	"tmobile-stats/internal/models"
+	"tmobile-stats/internal/rollup"
+)
+
+// minPoints is the fewest buckets an automatically chosen resolution must
+// give over the requested range; around the width of a chart in points.
+const minPoints = 300
+
+// LoadHistory is LoadLog reading rollups kept next to the log when they
+// are coarse enough for the range. resolution is "raw", "1m", "1h", or
+// "auto" to pick the coarsest one giving enough points; auto falls back to
+// finer data when the chosen resolution has nothing stored. The
+// resolution actually read is returned with the samples.
+func LoadHistory(path string, filter *TimeFilter, resolution string) ([]models.CombinedStats, time.Duration, error) {
+	data, res, _, err := loadHistory(path, filter, resolution)
+	return data, res, err
+}
+
+// loadHistory is LoadHistory that also reports the lines of a raw text log
+// it could not read.
+func loadHistory(path string, filter *TimeFilter, resolution string) ([]models.CombinedStats, time.Duration, ParseStats, error) {
+	if resolution != "auto" {
+		res, err := rollup.Parse(resolution)
+		if err != nil {
+			return nil, 0, ParseStats{}, err
+		}
+		if res == 0 {
+			data, st, err := loadLog(path, filter)
+			return data, 0, st, err
+		}
+		data, err := loadRollups(path, filter, res)
+		return data, res, ParseStats{}, err
+	}
+
+	store := &rollup.Store{Dir: rollup.DirFor(path)}
+	var start, end time.Time
+	if filter != nil {
+		start, end = filter.Start, filter.End
+	}
+	if start.IsZero() {
+		first, err := store.First(time.Minute)
+		if err != nil {
+			return nil, 0, ParseStats{}, err
+		}
+		start = first
+	}
+	if end.IsZero() {
+		end = time.Now()
+	}
+
+	if !start.IsZero() {
+		for res := rollup.Choose(start, end, minPoints); res != 0; res = finer(res) {
+			data, err := loadRollups(path, filter, res)
+			if err != nil {
+				return nil, 0, ParseStats{}, err
+			}
+			if len(data) > 0 {
+				return data, res, ParseStats{}, nil
+			}
+		}
+	}
+	data, st, err := loadLog(path, filter)
+	return data, 0, st, err
+}
+
+// finer returns the next finer resolution, or raw (0).
+func finer(res time.Duration) time.Duration {
+	for i := len(rollup.Resolutions) - 1; i > 0; i-- {
+		if rollup.Resolutions[i] == res {
+			return rollup.Resolutions[i-1]
+		}
+	}
+	return 0
+}
+
+// loadRollups returns the buckets of res overlapping filter as samples.
+func loadRollups(path string, filter *TimeFilter, res time.Duration) ([]models.CombinedStats, error) {
+	var start, end time.Time
+	if filter != nil {
+		start = filter.Start.Truncate(res)
+		if !filter.End.IsZero() {
+			end = filter.End.Add(time.Nanosecond)
+		}
+	}
+	store := &rollup.Store{Dir: rollup.DirFor(path)}
+	buckets, err := store.Query(res, start, end)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]models.CombinedStats, len(buckets))
+	for i := range buckets {
+		data[i] = buckets[i].Sample()
+	}
+	return data, nil
+}
@@ -0,0 +1,67 @@
+package analysis
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/rollup"
+)
+
+func TestLoadHistory(t *testing.T) {
+	// Thirteen days of minute samples, just enough for hour buckets, rolled up next to the log
+	const days = 13
+	path := filepath.Join(t.TempDir(), "stats.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := rollup.Open(rollup.DirFor(path), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := json.NewEncoder(f)
+	for i := 0; i < days*24*60; i++ {
+		s := benchSample(i)
+		if err := enc.Encode(s); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Log(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Close()
+
+	end := monthStart.Add(days * 24 * time.Hour)
+	tests := []struct {
+		name       string
+		filter     *TimeFilter
+		resolution string
+		want       time.Duration
+		samples    int
+	}{
+		{"whole log", nil, "auto", time.Hour, days*24 - 1},
+		{"one day", &TimeFilter{Start: end.Add(-48 * time.Hour), End: end.Add(-24*time.Hour - time.Second)}, "auto", time.Minute, 24 * 60},
+		{"one hour", &TimeFilter{Start: monthStart, End: monthStart.Add(time.Hour - time.Second)}, "auto", 0, 60},
+		{"forced", &TimeFilter{Start: monthStart, End: monthStart.Add(time.Hour - time.Second)}, "1m", time.Minute, 60},
+		// The last minute is still in progress, so only in the raw log
+		{"not rolled up yet", &TimeFilter{Start: end.Add(-time.Minute)}, "1m", time.Minute, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, res, err := LoadHistory(path, tt.filter, tt.resolution)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res != tt.want || len(data) != tt.samples {
+				t.Errorf("read %d samples at %s, want %d at %s", len(data), rollup.Name(res), tt.samples, rollup.Name(tt.want))
+			}
+		})
+	}
+
+	if _, _, err := LoadHistory(path, nil, "5m"); err == nil {
+		t.Error("LoadHistory accepted an unknown resolution")
+	}
+}
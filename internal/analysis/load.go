@@ -0,0 +1,103 @@
+package analysis
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tmobile-stats/internal/logger"
+	"tmobile-stats/internal/models"
+	"tmobile-stats/internal/tsdb"
+)
+
+// sqliteMagic is the header every SQLite 3 database file starts with.
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+// IsSQLite reports whether path is an SQLite database, judged by its
+// header or, for files that don't exist yet, a .db/.sqlite extension.
+func IsSQLite(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".db", ".sqlite", ".sqlite3":
+			return true
+		}
+		return false
+	}
+	defer f.Close()
+
+	head := make([]byte, len(sqliteMagic))
+	if _, err := io.ReadFull(f, head); err != nil {
+		return false
+	}
+	return bytes.Equal(head, sqliteMagic)
+}
+
+// LoadLog returns the samples inside filter from the log at path, which may
+// be a binary store directory, an SQLite database or a JSON log with
+// rotated segments.
+func LoadLog(path string, filter *TimeFilter) ([]models.CombinedStats, error) {
+	data, _, err := loadLog(path, filter)
+	return data, err
+}
+
+// loadLog is LoadLog that also reports the lines of a text log it could
+// not read.
+func loadLog(path string, filter *TimeFilter) ([]models.CombinedStats, ParseStats, error) {
+	var data []models.CombinedStats
+	st, err := ScanLog(path, filter, func(s *models.CombinedStats) error {
+		data = append(data, *s)
+		return nil
+	})
+	if err != nil {
+		return nil, st, err
+	}
+	return data, st, nil
+}
+
+// ScanLog calls fn with each sample inside filter, oldest first, without
+// holding a text log in memory. Stores and databases are queried whole.
+func ScanLog(path string, filter *TimeFilter, fn func(s *models.CombinedStats) error) (ParseStats, error) {
+	var start, end time.Time
+	if filter != nil {
+		start, end = filter.Start, filter.End
+	}
+
+	var data []models.CombinedStats
+	switch {
+	case tsdb.IsStore(path):
+		var err error
+		if data, err = tsdb.Query(path, start, end); err != nil {
+			return ParseStats{}, err
+		}
+	case IsSQLite(path):
+		if _, err := os.Stat(path); err != nil {
+			return ParseStats{}, err
+		}
+		db, err := logger.NewSQLiteLogger(path)
+		if err != nil {
+			return ParseStats{}, err
+		}
+		defer db.Close()
+		if data, err = db.Query(start, end); err != nil {
+			return ParseStats{}, err
+		}
+	default:
+		f, err := OpenLogSet(path, filter)
+		if err != nil {
+			return ParseStats{}, err
+		}
+		defer f.Close()
+		return scanLog(f, filter, fn)
+	}
+
+	for i := range data {
+		if err := fn(&data[i]); err != nil {
+			return ParseStats{}, err
+		}
+	}
+	return ParseStats{}, nil
+}
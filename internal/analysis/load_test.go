@@ -0,0 +1,136 @@
+package analysis
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+	"tmobile-stats/internal/tsdb"
+)
+
+// monthSamples is a month of samples at a one-minute interval.
+const monthSamples = 30 * 24 * 60
+
+var monthStart = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func benchSample(i int) *models.CombinedStats {
+	s := &models.CombinedStats{
+		Ping: models.PingStats{Min: 18, Avg: 24.5 + float64(i%7), Max: 60, StdDev: 4.2, Loss: float64(i % 3), Sent: 60, Received: 60 - i%3, Target: "8.8.8.8"},
+	}
+	s.Gateway.Device = models.DeviceInfo{Model: "G4AR", Manufacturer: "Arcadyan", SoftwareVersion: "1.00.20", Serial: "ABC123", MacID: "00:11:22:33:44:55"}
+	s.Gateway.Signal.FiveG = models.ConnectionStats{AntennaUsed: "Internal_directional", Bands: []string{"n41"}, Bars: 4, CID: 311, GNBID: 1234567, PCID: 512, RSRP: -90 - i%10, RSRQ: -11, RSSI: -80, SINR: 10 + i%8}
+	s.Gateway.Signal.FourG = models.ConnectionStats{AntennaUsed: "Internal_directional", Bands: []string{"b66"}, Bars: 3, EID: 99, PCID: 120, RSRP: -101, RSRQ: -12, RSSI: -75, SINR: 6}
+	s.Gateway.Signal.Generic = models.GenericInfo{APN: "fbb.home", HasIPv6: true, Registration: "registered"}
+	s.Gateway.Time = models.TimeInfo{LocalTime: monthStart.Add(time.Duration(i) * time.Minute).Unix(), LocalTimeZone: "UTC", UpTime: i * 60}
+	return s
+}
+
+// writeMonth writes the same month of samples as a JSON log and a binary store.
+func writeMonth(b *testing.B) (jsonPath, storePath string) {
+	b.Helper()
+	dir := b.TempDir()
+	jsonPath = filepath.Join(dir, "stats.log")
+	storePath = filepath.Join(dir, "stats"+tsdb.Ext)
+
+	f, err := os.Create(jsonPath)
+	if err != nil {
+		b.Fatal(err)
+	}
+	bw := bufio.NewWriter(f)
+	enc := json.NewEncoder(bw)
+	store, err := tsdb.Open(storePath, tsdb.Options{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < monthSamples; i++ {
+		s := benchSample(i)
+		if err := enc.Encode(s); err != nil {
+			b.Fatal(err)
+		}
+		if err := store.Log(s); err != nil {
+			b.Fatal(err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		b.Fatal(err)
+	}
+	f.Close()
+	store.Close()
+	return jsonPath, storePath
+}
+
+func dirSize(path string) int64 {
+	var total int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			total += info.Size()
+		}
+		return err
+	})
+	return total
+}
+
+func benchLoad(b *testing.B, path string, filter *TimeFilter, want int) {
+	b.Helper()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		data, err := LoadLog(path, filter)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(data) != want {
+			b.Fatalf("Loaded %d samples, want %d", len(data), want)
+		}
+	}
+	b.ReportMetric(float64(dirSize(path))/monthSamples, "disk-B/sample")
+}
+
+// lastDay selects the final day of the month, which ParseLog can only reach
+// by parsing everything before it.
+var lastDay = &TimeFilter{Start: monthStart.Add(29 * 24 * time.Hour), End: monthStart.Add(30*24*time.Hour - time.Second)}
+
+func BenchmarkParseLogMonth(b *testing.B) {
+	jsonPath, _ := writeMonth(b)
+	benchLoad(b, jsonPath, nil, monthSamples)
+}
+
+func BenchmarkTSDBMonth(b *testing.B) {
+	_, storePath := writeMonth(b)
+	benchLoad(b, storePath, nil, monthSamples)
+}
+
+func BenchmarkParseLogLastDay(b *testing.B) {
+	jsonPath, _ := writeMonth(b)
+	benchLoad(b, jsonPath, lastDay, 24*60)
+}
+
+func BenchmarkTSDBLastDay(b *testing.B) {
+	_, storePath := writeMonth(b)
+	benchLoad(b, storePath, lastDay, 24*60)
+}
+
+func TestLoadLogTSDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal"+tsdb.Ext)
+	store, err := tsdb.Open(path, tsdb.Options{})
+	if err != nil {
+		t.Fatalf("Failed to open store: %v", err)
+	}
+	for i := 0; i < 3*60; i++ {
+		if err := store.Log(benchSample(i)); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+	store.Close()
+
+	data, err := LoadLog(path, &TimeFilter{Start: monthStart.Add(time.Hour), End: monthStart.Add(2 * time.Hour)})
+	if err != nil {
+		t.Fatalf("LoadLog failed: %v", err)
+	}
+	if len(data) != 61 {
+		t.Errorf("Expected 61 samples in the hour, got %d", len(data))
+	}
+}
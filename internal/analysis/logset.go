@@ -0,0 +1,134 @@
+package analysis
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"tmobile-stats/internal/logger"
+)
+
+// logSlack widens the bounds of rotated segments. Rotation uses the local
+// clock while samples carry the gateway's time, so the two can disagree.
+const logSlack = 10 * time.Minute
+
+// LogFiles returns the files making up the log at path that may hold
+// samples inside filter: rotated segments (plain or .gz) oldest first,
+// followed by the active file.
+func LogFiles(path string, filter *TimeFilter) ([]string, error) {
+	rotated, err := logger.RotatedFiles(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	var start, end time.Time
+	if filter != nil {
+		start, end = filter.Start, filter.End
+	}
+
+	var files []string
+	var prevEnd time.Time
+	for _, f := range rotated {
+		// Segment f holds samples written in (prevEnd, f.End]
+		after := start.IsZero() || !f.End.Before(start.Add(-logSlack))
+		before := end.IsZero() || prevEnd.IsZero() || !prevEnd.After(end.Add(logSlack))
+		if after && before {
+			files = append(files, f.Path)
+		}
+		prevEnd = f.End
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		if end.IsZero() || prevEnd.IsZero() || !prevEnd.After(end.Add(logSlack)) {
+			files = append(files, path)
+		}
+	} else if len(rotated) == 0 {
+		return nil, err
+	}
+	return files, nil
+}
+
+// OpenLogSet opens every file LogFiles selects as one stream, decompressing
+// rotated .gz segments on the fly.
+func OpenLogSet(path string, filter *TimeFilter) (io.ReadCloser, error) {
+	files, err := LogFiles(path, filter)
+	if err != nil {
+		return nil, err
+	}
+	return &logSetReader{files: files}, nil
+}
+
+// logSetReader concatenates files, opening one at a time. A newline is
+// inserted between files so a truncated last line never merges with the
+// first line of the next file.
+type logSetReader struct {
+	files []string
+	file  *os.File
+	cur   io.Reader
+	sep   bool
+}
+
+func (r *logSetReader) Read(p []byte) (int, error) {
+	for {
+		if r.sep {
+			r.sep = false
+			p[0] = '\n'
+			return 1, nil
+		}
+		if r.cur == nil {
+			if len(r.files) == 0 {
+				return 0, io.EOF
+			}
+			if err := r.next(); err != nil {
+				return 0, err
+			}
+		}
+
+		n, err := r.cur.Read(p)
+		if err == io.EOF {
+			r.closeCurrent()
+			r.sep = true
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *logSetReader) next() error {
+	path := r.files[0]
+	r.files = r.files[1:]
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	r.file = f
+	r.cur = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		r.cur = zr
+	}
+	return nil
+}
+
+func (r *logSetReader) closeCurrent() {
+	if r.file != nil {
+		r.file.Close()
+	}
+	r.file, r.cur = nil, nil
+}
+
+func (r *logSetReader) Close() error {
+	r.closeCurrent()
+	r.files = nil
+	return nil
+}
@@ -0,0 +1,148 @@
+package analysis
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/logger"
+	"tmobile-stats/internal/models"
+)
+
+func writeSegment(t *testing.T, path string, compress bool, times ...time.Time) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if compress {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+	for _, ts := range times {
+		fmt.Fprintf(w, `{"gateway":{"time":{"localTime":%d},"signal":{"5g":{"rsrp":-90}}},"ping":{}}`+"\n", ts.Unix())
+	}
+	if zw != nil {
+		zw.Close()
+	}
+}
+
+func TestLogSet(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "stats.log")
+	day := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	// Two daily segments and the active file
+	writeSegment(t, filepath.Join(dir, "stats-20260105T000000.log.gz"), true, day.Add(-20*time.Hour), day.Add(-2*time.Hour))
+	writeSegment(t, filepath.Join(dir, "stats-20260106T000000.log.gz"), true, day.Add(4*time.Hour), day.Add(20*time.Hour))
+	writeSegment(t, path, false, day.Add(28*time.Hour))
+
+	tests := []struct {
+		name    string
+		filter  *TimeFilter
+		files   int
+		samples int
+	}{
+		{"All", nil, 3, 5},
+		{"FirstDay", &TimeFilter{Start: day.Add(-24 * time.Hour), End: day.Add(-time.Hour)}, 1, 2},
+		{"SecondDay", &TimeFilter{Start: day.Add(3 * time.Hour), End: day.Add(21 * time.Hour)}, 1, 2},
+		{"Today", &TimeFilter{Start: day.Add(25 * time.Hour)}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := LogFiles(path, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != tt.files {
+				t.Errorf("Expected %d files, got %v", tt.files, files)
+			}
+
+			r, err := OpenLogSet(path, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			data, _, err := ParseLog(r, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(data) != tt.samples {
+				t.Errorf("Expected %d samples, got %d", tt.samples, len(data))
+			}
+		})
+	}
+}
+
+func TestLogSetMissing(t *testing.T) {
+	if _, err := OpenLogSet(filepath.Join(t.TempDir(), "stats.log"), nil); !os.IsNotExist(err) {
+		t.Errorf("Expected not-exist error, got %v", err)
+	}
+}
+
+func TestLoadLogSQLite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal.db")
+	db, err := logger.NewSQLiteLogger(path)
+	if err != nil {
+		t.Fatalf("Failed to create database: %v", err)
+	}
+	base := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		s := &models.CombinedStats{}
+		s.Gateway.Time.LocalTime = base.Add(time.Duration(i) * time.Hour).Unix()
+		if err := db.Log(s); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+	db.Close()
+
+	if !IsSQLite(path) {
+		t.Fatal("IsSQLite did not recognise the database")
+	}
+	data, err := LoadLog(path, &TimeFilter{Start: base.Add(30 * time.Minute)})
+	if err != nil {
+		t.Fatalf("LoadLog failed: %v", err)
+	}
+	if len(data) != 2 {
+		t.Errorf("Expected 2 samples after start, got %d", len(data))
+	}
+}
+
+func TestLoadLogCSV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal-data.csv")
+	// A small size limit rotates the CSV, so the set spans several files
+	l, err := logger.NewRotatingCSVLogger(path, logger.Rotation{MaxSize: 800})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		s := &models.CombinedStats{}
+		s.Gateway.Time.LocalTime = base.Add(time.Duration(i) * time.Hour).Unix()
+		s.Gateway.Signal.FiveG.RSRP = -90 - i
+		if err := l.Log(s); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+	l.Close()
+	if files, _ := logger.RotatedFiles(path); len(files) == 0 {
+		t.Fatal("Expected the CSV log to rotate")
+	}
+
+	data, err := LoadLog(path, &TimeFilter{Start: base.Add(90 * time.Minute)})
+	if err != nil {
+		t.Fatalf("LoadLog failed: %v", err)
+	}
+	if len(data) != 4 || data[0].Gateway.Signal.FiveG.RSRP != -92 || data[3].Gateway.Signal.FiveG.RSRP != -95 {
+		t.Errorf("Expected samples 2-5 from the rotated CSV set, got %d: %+v", len(data), data)
+	}
+}
@@ -0,0 +1,176 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// Span is a stretch of time; for overlaps, Inputs lists the logs (by index)
+// that all hold samples in it.
+type Span struct {
+	Start, End time.Time
+	Inputs     []int
+}
+
+// MergeStats describes what MergeLogs did with its inputs.
+type MergeStats struct {
+	Read       []int // Samples read from each input
+	Written    int
+	Duplicates int // Dropped: same gateway time and identical payload as a kept sample
+	Conflicts  int // Kept: same gateway time as another sample but a different payload
+	Overlaps   []Span
+	Gaps       []Span // Stretches longer than the gap threshold with no samples at all
+}
+
+// timedSample is a sample with where it came from, for a stable merge.
+type timedSample struct {
+	t     int64
+	input int
+	s     *models.CombinedStats
+}
+
+// MergeLogs interleaves the samples of several logs by gateway time and
+// drops exact duplicates. A log is taken to cover the time between two of
+// its samples unless they are more than gap apart; where two logs cover the
+// same time it is reported as an overlap, and where the merged log has
+// nothing for more than gap it is reported as a gap.
+func MergeLogs(inputs [][]models.CombinedStats, gap time.Duration) ([]models.CombinedStats, MergeStats, error) {
+	st := MergeStats{Read: make([]int, len(inputs))}
+	var all []timedSample
+	for i, data := range inputs {
+		st.Read[i] = len(data)
+		for j := range data {
+			all = append(all, timedSample{t: data[j].Gateway.Time.LocalTime, input: i, s: &data[j]})
+		}
+	}
+	// Stable, so samples sharing a time keep their input order
+	sort.SliceStable(all, func(i, j int) bool { return all[i].t < all[j].t })
+
+	var out []models.CombinedStats
+	var seen [][]byte // Payloads kept at the current time
+	for i, ts := range all {
+		if i == 0 || ts.t != all[i-1].t {
+			seen = seen[:0]
+		}
+		payload, err := json.Marshal(ts.s)
+		if err != nil {
+			return nil, st, fmt.Errorf("could not marshal JSON: %w", err)
+		}
+		dup := false
+		for _, p := range seen {
+			if bytes.Equal(p, payload) {
+				dup = true
+				break
+			}
+		}
+		if dup {
+			st.Duplicates++
+			continue
+		}
+		if len(seen) > 0 {
+			st.Conflicts++
+		}
+		seen = append(seen, payload)
+		out = append(out, *ts.s)
+	}
+	st.Written = len(out)
+
+	st.Overlaps = overlaps(inputs, gap)
+	for i := 1; i < len(out); i++ {
+		prev, next := sampleTime(&out[i-1]), sampleTime(&out[i])
+		if next.Sub(prev) > gap {
+			st.Gaps = append(st.Gaps, Span{Start: prev, End: next})
+		}
+	}
+	return out, st, nil
+}
+
+func sampleTime(s *models.CombinedStats) time.Time {
+	return time.Unix(s.Gateway.Time.LocalTime, 0)
+}
+
+// overlaps finds the spans covered by more than one input.
+func overlaps(inputs [][]models.CombinedStats, gap time.Duration) []Span {
+	type event struct {
+		t     time.Time
+		input int
+		delta int
+	}
+	var events []event
+	for i, data := range inputs {
+		times := make([]time.Time, len(data))
+		for j := range data {
+			times[j] = sampleTime(&data[j])
+		}
+		sort.Slice(times, func(a, b int) bool { return times[a].Before(times[b]) })
+
+		// Each run of samples no more than gap apart covers its span
+		for j := 0; j < len(times); {
+			k := j
+			for k+1 < len(times) && times[k+1].Sub(times[k]) <= gap {
+				k++
+			}
+			events = append(events, event{times[j], i, 1}, event{times[k], i, -1})
+			j = k + 1
+		}
+	}
+	sort.SliceStable(events, func(a, b int) bool {
+		if !events[a].t.Equal(events[b].t) {
+			return events[a].t.Before(events[b].t)
+		}
+		return events[a].delta > events[b].delta // Starts first, so touching runs overlap by nothing
+	})
+
+	var spans []Span
+	active := make([]int, len(inputs))
+	count := 0
+	var prev time.Time
+	for _, e := range events {
+		if count >= 2 && e.t.After(prev) {
+			var in []int
+			for i, n := range active {
+				if n > 0 {
+					in = append(in, i)
+				}
+			}
+			if last := len(spans) - 1; last >= 0 && spans[last].End.Equal(prev) {
+				spans[last].End = e.t
+				spans[last].Inputs = union(spans[last].Inputs, in)
+			} else {
+				spans = append(spans, Span{Start: prev, End: e.t, Inputs: in})
+			}
+		}
+		// An input's runs never overlap each other, so count is the
+		// number of inputs covering the time from here on
+		active[e.input] += e.delta
+		count += e.delta
+		prev = e.t
+	}
+	return spans
+}
+
+// union merges two sorted index lists.
+func union(a, b []int) []int {
+	var out []int
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || i < len(a) && a[i] < b[j]:
+			out = append(out, a[i])
+			i++
+		case i == len(a) || b[j] < a[i]:
+			out = append(out, b[j])
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
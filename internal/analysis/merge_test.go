@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// mergeLog returns samples every 5s at the given offsets from monthStart.
+func mergeLog(from, to time.Duration) []models.CombinedStats {
+	var data []models.CombinedStats
+	for d := from; d <= to; d += 5 * time.Second {
+		var s models.CombinedStats
+		s.Gateway.Time.LocalTime = monthStart.Add(d).Unix()
+		s.Gateway.Signal.FiveG.RSRP = -90
+		data = append(data, s)
+	}
+	return data
+}
+
+func TestMergeLogs(t *testing.T) {
+	// a covers 0-10m and 20-30m; b covers 5-15m, overlapping a with the
+	// same samples from 5m to 10m. Nothing covers 15-20m.
+	a := append(mergeLog(0, 10*time.Minute), mergeLog(20*time.Minute, 30*time.Minute)...)
+	b := mergeLog(5*time.Minute, 15*time.Minute)
+	// A different reading at a time a already has
+	b[0].Gateway.Signal.FiveG.RSRP = -100
+
+	merged, st, err := MergeLogs([][]models.CombinedStats{a, b}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	overlap := 5*60/5 + 1 // Samples from 5m to 10m inclusive
+	if st.Duplicates != overlap-1 || st.Conflicts != 1 {
+		t.Errorf("Duplicates = %d, conflicts = %d; want %d and 1", st.Duplicates, st.Conflicts, overlap-1)
+	}
+	if want := len(a) + len(b) - (overlap - 1); len(merged) != want || st.Written != want {
+		t.Errorf("Wrote %d samples (%d reported), want %d", len(merged), st.Written, want)
+	}
+	for i := 1; i < len(merged); i++ {
+		if merged[i].Gateway.Time.LocalTime < merged[i-1].Gateway.Time.LocalTime {
+			t.Fatalf("Sample %d is out of order", i)
+		}
+	}
+
+	if len(st.Overlaps) != 1 {
+		t.Fatalf("Overlaps = %+v, want one", st.Overlaps)
+	}
+	o := st.Overlaps[0]
+	if !o.Start.Equal(sampleTime(&b[0])) || !o.End.Equal(sampleTime(&a[len(mergeLog(0, 10*time.Minute))-1])) || len(o.Inputs) != 2 {
+		t.Errorf("Overlap = %s to %s of %v, want 5m to 10m of both", o.Start, o.End, o.Inputs)
+	}
+
+	if len(st.Gaps) != 1 || st.Gaps[0].End.Sub(st.Gaps[0].Start) != 5*time.Minute {
+		t.Errorf("Gaps = %+v, want the 5 minutes from 15m to 20m", st.Gaps)
+	}
+}
@@ -0,0 +1,293 @@
+package analysis
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// LossBurst is a run of consecutive lost probes.
+type LossBurst struct {
+	Start    time.Time
+	End      time.Time
+	Count    int
+	Interval time.Duration // Time between probes in or around the burst; zero if unknown
+}
+
+// Duration returns the time span covered by the burst.
+// A single lost probe is counted as one probe interval long.
+func (b LossBurst) Duration() time.Duration {
+	return b.End.Sub(b.Start) + b.Interval
+}
+
+// RawReport summarises the per-packet results of one ping target.
+type RawReport struct {
+	Target    string // Label of the target and its packet options
+	Filter    *TimeFilter
+	StartTime time.Time
+	EndTime   time.Time
+
+	Sent   int
+	Lost   int
+	RTT    Metric
+	P50    float64
+	P95    float64
+	P99    float64
+	Jitter float64 // Mean absolute difference between consecutive RTTs
+
+	Bursts []LossBurst
+}
+
+func RunRaw(path string, filter *TimeFilter) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return AnalyzeRaw(file, os.Stdout, filter)
+}
+
+// AnalyzeRaw prints latency percentiles and exact loss bursts from a raw
+// ping log, separately for each target in it.
+func AnalyzeRaw(input io.Reader, output io.Writer, filter *TimeFilter) error {
+	results, err := ParseRawLog(input, filter)
+	if err != nil {
+		return err
+	}
+
+	reports := BuildRawReports(results, filter)
+	if len(reports) == 0 {
+		printRawReport(output, &RawReport{Filter: filter})
+	}
+	for _, r := range reports {
+		printRawReport(output, r)
+	}
+	return nil
+}
+
+// RawTarget is the per-packet results of one ping target.
+type RawTarget struct {
+	Label   string
+	Results []models.PingResult
+}
+
+// SplitByTarget groups results by target and packet options, in the order
+// each target first appears. Every target of a pinger group logs to the
+// same raw log, but jitter, percentiles and loss bursts only mean
+// something within one target.
+func SplitByTarget(results []models.PingResult) []RawTarget {
+	var targets []RawTarget
+	index := map[string]int{}
+	for _, r := range results {
+		label := models.PingStats{Target: r.Target, Options: r.Options}.Label()
+		i, ok := index[label]
+		if !ok {
+			i = len(targets)
+			index[label] = i
+			targets = append(targets, RawTarget{Label: label})
+		}
+		targets[i].Results = append(targets[i].Results, r)
+	}
+	return targets
+}
+
+// BuildRawReports computes one raw report per target.
+func BuildRawReports(results []models.PingResult, filter *TimeFilter) []*RawReport {
+	var reports []*RawReport
+	for _, t := range SplitByTarget(results) {
+		r := BuildRawReport(t.Results, filter)
+		r.Target = t.Label
+		reports = append(reports, r)
+	}
+	return reports
+}
+
+// BuildRawReport computes the raw report for already parsed results of a
+// single target.
+func BuildRawReport(results []models.PingResult, filter *TimeFilter) *RawReport {
+	report := &RawReport{Filter: filter}
+
+	var rtts []float64
+	var jitterSum float64
+	var jitterCount int
+	prevRTT := -1.0
+
+	for _, r := range results {
+		if report.StartTime.IsZero() || r.Time.Before(report.StartTime) {
+			report.StartTime = r.Time
+		}
+		if r.Time.After(report.EndTime) {
+			report.EndTime = r.Time
+		}
+
+		report.Sent++
+		if r.Lost {
+			report.Lost++
+			continue
+		}
+
+		report.RTT.Add(r.RTT)
+		rtts = append(rtts, r.RTT)
+		if prevRTT >= 0 {
+			jitterSum += math.Abs(r.RTT - prevRTT)
+			jitterCount++
+		}
+		prevRTT = r.RTT
+	}
+
+	if jitterCount > 0 {
+		report.Jitter = jitterSum / float64(jitterCount)
+	}
+
+	sort.Float64s(rtts)
+	report.P50 = percentile(rtts, 50)
+	report.P95 = percentile(rtts, 95)
+	report.P99 = percentile(rtts, 99)
+
+	report.Bursts = FindLossBursts(results)
+	return report
+}
+
+// FindLossBursts groups consecutive lost probes into bursts.
+// Results are expected in the order they were logged.
+//
+// The raw log doesn't record the probe interval, so each burst takes it
+// from the spacing of its own probes, or for a single lost probe from the
+// probe before it (or after it, at the start of the log).
+func FindLossBursts(results []models.PingResult) []LossBurst {
+	var bursts []LossBurst
+	var current *LossBurst
+
+	for i, r := range results {
+		if !r.Lost {
+			if current != nil && current.Interval <= 0 {
+				current.Interval = r.Time.Sub(current.End)
+			}
+			current = nil
+			continue
+		}
+		if current == nil {
+			bursts = append(bursts, LossBurst{Start: r.Time, End: r.Time})
+			current = &bursts[len(bursts)-1]
+			if i > 0 {
+				current.Interval = r.Time.Sub(results[i-1].Time)
+			}
+		}
+		current.End = r.Time
+		current.Count++
+	}
+
+	for i := range bursts {
+		b := &bursts[i]
+		if b.Count > 1 {
+			b.Interval = b.End.Sub(b.Start) / time.Duration(b.Count-1)
+		}
+		b.Interval = max(b.Interval, 0)
+	}
+	return bursts
+}
+
+// ParseRawLog reads a per-packet ping log produced by logger.RawLogger.
+// It skips malformed lines.
+func ParseRawLog(r io.Reader, filter *TimeFilter) ([]models.PingResult, error) {
+	var results []models.PingResult
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var res models.PingResult
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			continue // Skip malformed lines
+		}
+
+		if filter != nil && !filter.Contains(res.Time) {
+			continue
+		}
+
+		results = append(results, res)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// percentile returns the p-th percentile of sorted values using nearest-rank.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func printRawReport(w io.Writer, r *RawReport) {
+	fmt.Fprintln(w, "================================================================================")
+	fmt.Fprintln(w, " PER-PACKET PING ANALYSIS")
+	fmt.Fprintln(w, "================================================================================")
+
+	if r.Sent == 0 {
+		fmt.Fprintln(w, "No ping results found.")
+		return
+	}
+
+	if r.Target != "" {
+		fmt.Fprintf(w, "Target:        %s\n", r.Target)
+	}
+	fmt.Fprintf(w, "Data Range:    %s to %s\n", r.StartTime.Format("2006-01-02 15:04:05"), r.EndTime.Format("15:04:05"))
+	fmt.Fprintf(w, "Probes:        %d sent, %d lost (%.2f%%)\n", r.Sent, r.Lost, float64(r.Lost)/float64(r.Sent)*100)
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RTT (ms)\tMIN\tP50\tAVG\tP95\tP99\tMAX\tJITTER")
+	fmt.Fprintf(tw, "\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\n",
+		r.RTT.Min, r.P50, r.RTT.Avg(), r.P95, r.P99, r.RTT.Max, r.Jitter)
+	tw.Flush()
+
+	fmt.Fprintln(w, "\nLOSS BURSTS:")
+	if len(r.Bursts) == 0 {
+		fmt.Fprintln(w, "  None")
+	} else {
+		longest := r.Bursts[0]
+		single := 0
+		for _, b := range r.Bursts {
+			if b.Count > longest.Count {
+				longest = b
+			}
+			if b.Count == 1 {
+				single++
+			}
+		}
+		fmt.Fprintf(w, "  Bursts:   %d (%d single-packet)\n", len(r.Bursts), single)
+		fmt.Fprintf(w, "  Longest:  %d packets at %s (%s)\n", longest.Count, longest.Start.Format("2006-01-02 15:04:05"), formatSmartDuration(longest.Duration()))
+
+		// List the worst bursts, longest first
+		sorted := make([]LossBurst, len(r.Bursts))
+		copy(sorted, r.Bursts)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Count > sorted[j].Count })
+		if len(sorted) > 10 {
+			sorted = sorted[:10]
+		}
+
+		tw2 := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw2, "  START\tPACKETS\tDURATION")
+		for _, b := range sorted {
+			fmt.Fprintf(tw2, "  %s\t%d\t%s\n", b.Start.Format("2006-01-02 15:04:05"), b.Count, formatSmartDuration(b.Duration()))
+		}
+		tw2.Flush()
+	}
+
+	fmt.Fprintln(w, "================================================================================")
+}
@@ -0,0 +1,141 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+func TestFindLossBursts(t *testing.T) {
+	base := time.Unix(1767651600, 0)
+	lost := []bool{false, true, true, false, true, false, true, true, true}
+
+	var results []models.PingResult
+	for i, l := range lost {
+		results = append(results, models.PingResult{
+			Time: base.Add(time.Duration(i) * time.Second),
+			Seq:  i,
+			RTT:  20,
+			Lost: l,
+		})
+	}
+
+	bursts := FindLossBursts(results)
+	if len(bursts) != 3 {
+		t.Fatalf("Expected 3 bursts, got %d: %+v", len(bursts), bursts)
+	}
+
+	expected := []int{2, 1, 3}
+	for i, b := range bursts {
+		if b.Count != expected[i] {
+			t.Errorf("Burst %d: expected %d packets, got %d", i, expected[i], b.Count)
+		}
+	}
+	if d := bursts[2].Duration(); d != 3*time.Second {
+		t.Errorf("Expected last burst to last 3s, got %v", d)
+	}
+
+	// Probes every 200ms, e.g. during an adaptive burst
+	for i := range results {
+		results[i].Time = base.Add(time.Duration(i) * 200 * time.Millisecond)
+	}
+	bursts = FindLossBursts(results)
+	if d := bursts[1].Duration(); d != 200*time.Millisecond {
+		t.Errorf("Expected a single lost probe to last 200ms, got %v", d)
+	}
+	if d := bursts[2].Duration(); d != 600*time.Millisecond {
+		t.Errorf("Expected last burst to last 600ms, got %v", d)
+	}
+}
+
+func TestAnalyzeRaw(t *testing.T) {
+	input := `
+{"time":"2026-01-05T22:20:00Z","target":"8.8.8.8","seq":0,"rtt":10}
+{"time":"2026-01-05T22:20:01Z","target":"8.8.8.8","seq":1,"rtt":20}
+{"time":"2026-01-05T22:20:02Z","target":"8.8.8.8","seq":2,"lost":true}
+not json
+{"time":"2026-01-05T22:20:03Z","target":"8.8.8.8","seq":3,"rtt":30}
+`
+	var output bytes.Buffer
+	if err := AnalyzeRaw(strings.NewReader(strings.TrimSpace(input)), &output, nil); err != nil {
+		t.Fatalf("AnalyzeRaw failed: %v", err)
+	}
+
+	result := output.String()
+	checks := []string{
+		"PER-PACKET PING ANALYSIS",
+		"4 sent, 1 lost (25.00%)",
+		"Bursts:   1 (1 single-packet)",
+	}
+	for _, check := range checks {
+		if !strings.Contains(result, check) {
+			t.Errorf("Expected output to contain %q.\nOutput:\n%s", check, result)
+		}
+	}
+}
+
+func TestBuildRawReportPercentiles(t *testing.T) {
+	var results []models.PingResult
+	for i := 1; i <= 100; i++ {
+		results = append(results, models.PingResult{Time: time.Unix(int64(i), 0), RTT: float64(i)})
+	}
+
+	r := BuildRawReport(results, nil)
+	if r.P50 != 50 || r.P95 != 95 || r.P99 != 99 {
+		t.Errorf("Unexpected percentiles: p50=%v p95=%v p99=%v", r.P50, r.P95, r.P99)
+	}
+	if r.Jitter != 1 {
+		t.Errorf("Expected jitter 1, got %v", r.Jitter)
+	}
+}
+
+func TestBuildRawReportsPerTarget(t *testing.T) {
+	// 8.8.8.8 over IPv4 answers in 10ms and never loses a probe; the same
+	// host over IPv6 answers in 100ms and loses every other probe. Logged
+	// interleaved, as a pinger group writes them.
+	base := time.Unix(1767651600, 0)
+	v6 := &models.PingOptions{Network: "ip6"}
+	var results []models.PingResult
+	for i := 0; i < 10; i++ {
+		at := base.Add(time.Duration(i) * time.Second)
+		results = append(results,
+			models.PingResult{Time: at, Target: "8.8.8.8", Seq: i, RTT: 10},
+			models.PingResult{Time: at.Add(10 * time.Millisecond), Target: "8.8.8.8", Seq: i, RTT: 100, Lost: i%2 == 1, Options: v6},
+		)
+	}
+
+	reports := BuildRawReports(results, nil)
+	if len(reports) != 2 || reports[0].Target != "8.8.8.8" || reports[1].Target != "8.8.8.8 ip6" {
+		t.Fatalf("Expected a report per target, got %d", len(reports))
+	}
+	v4r, v6r := reports[0], reports[1]
+	if v4r.Sent != 10 || v4r.Lost != 0 || v4r.Jitter != 0 || v4r.P95 != 10 || len(v4r.Bursts) != 0 {
+		t.Errorf("IPv4 report mixed in the other target: %+v", v4r)
+	}
+	if v6r.Sent != 10 || v6r.Lost != 5 || v6r.Jitter != 0 || v6r.P50 != 100 {
+		t.Errorf("Unexpected IPv6 report: %+v", v6r)
+	}
+	// Probes lost every other second are separate one-probe bursts a second long
+	if len(v6r.Bursts) != 5 || v6r.Bursts[0].Count != 1 || v6r.Bursts[0].Duration() != time.Second {
+		t.Errorf("Unexpected IPv6 bursts: %+v", v6r.Bursts)
+	}
+
+	var output bytes.Buffer
+	var log strings.Builder
+	for _, r := range results {
+		b, _ := json.Marshal(r)
+		log.Write(append(b, '\n'))
+	}
+	if err := AnalyzeRaw(strings.NewReader(log.String()), &output, nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, check := range []string{"Target:        8.8.8.8\n", "Target:        8.8.8.8 ip6\n", "10 sent, 5 lost (50.00%)"} {
+		if !strings.Contains(output.String(), check) {
+			t.Errorf("Expected output to contain %q.\nOutput:\n%s", check, output.String())
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"math"
+
+	"tmobile-stats/internal/models"
+)
+
+// E-model constants for a G.711 call with packet loss concealment.
+const (
+	codecDelay = 10.0  // Packetization + codec lookahead (ms)
+	defaultR   = 93.2  // R0 - Is - A with G.107 default parameters
+	g711Ie     = 0.0   // Equipment impairment of G.711
+	g711Bpl    = 25.1  // Packet-loss robustness of G.711 with PLC
+	idKnee     = 177.3 // One-way delay (ms) beyond which delay impairment grows quickly
+)
+
+// VoIPScore is an ITU-T G.107 E-model estimate of call quality.
+type VoIPScore struct {
+	R   float64 // Transmission rating factor (0 - 100)
+	MOS float64 // Estimated mean opinion score (1.0 - 4.5)
+}
+
+// CalculateVoIPScore estimates call quality from round-trip latency (ms),
+// jitter (ms) and packet loss (%).
+// One-way delay is taken as half the RTT plus a jitter buffer sized at twice
+// the jitter plus codec delay. Loss is treated as random (BurstR = 1).
+func CalculateVoIPScore(latency, jitter, loss float64) VoIPScore {
+	d := latency/2 + 2*jitter + codecDelay
+
+	// Delay impairment (Cole & Rosenbluth approximation of Idd)
+	id := 0.024 * d
+	if d > idKnee {
+		id += 0.11 * (d - idKnee)
+	}
+
+	// Effective equipment impairment under loss
+	loss = math.Max(0, math.Min(loss, 100))
+	ie := g711Ie + (95-g711Ie)*loss/(loss+g711Bpl)
+
+	r := defaultR - id - ie
+	return VoIPScore{R: math.Round(r*10) / 10, MOS: rToMOS(r)}
+}
+
+// CalculateVoIPScoreForPing scores a single ping window, using its standard
+// deviation as the jitter estimate.
+func CalculateVoIPScoreForPing(p models.PingStats) VoIPScore {
+	return CalculateVoIPScore(p.Avg, p.StdDev, p.Loss)
+}
+
+// rToMOS converts an R-factor to MOS as defined in G.107 Annex B.
+func rToMOS(r float64) float64 {
+	var mos float64
+	switch {
+	case r <= 0:
+		mos = 1.0
+	case r >= 100:
+		mos = 4.5
+	default:
+		mos = 1 + 0.035*r + r*(r-60)*(100-r)*7e-6
+	}
+	return math.Round(mos*100) / 100
+}
+
+// Rating maps the R-factor to the user satisfaction bands of G.109.
+func (v VoIPScore) Rating() string {
+	switch {
+	case v.R >= 90:
+		return "Excellent"
+	case v.R >= 80:
+		return "Good"
+	case v.R >= 70:
+		return "Fair"
+	case v.R >= 60:
+		return "Poor"
+	default:
+		return "Bad"
+	}
+}
+
+// CallReady reports whether most users would be satisfied with a call.
+func (v VoIPScore) CallReady() bool {
+	return v.R >= 70
+}
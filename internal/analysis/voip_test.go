@@ -0,0 +1,43 @@
+package analysis
+
+import "testing"
+
+func TestCalculateVoIPScore(t *testing.T) {
+	tests := []struct {
+		name    string
+		latency float64
+		jitter  float64
+		loss    float64
+		r       float64
+		mos     float64
+		rating  string
+	}{
+		// d = 20/2 + 2*2 + 10 = 24ms, Id = 0.58, no loss
+		{"Clean", 20, 2, 0, 92.6, 4.40, "Excellent"},
+
+		// 1% loss costs about 3.6 R with G.711 PLC
+		{"LightLoss", 50, 10, 1, 88.2, 4.29, "Good"},
+
+		// d = 150 + 100 + 10 = 260ms crosses the 177.3ms knee
+		{"HighDelay", 300, 50, 0, 77.9, 3.94, "Fair"},
+
+		// 10% loss is the dominant impairment
+		{"HeavyLoss", 40, 5, 10, 65.2, 3.36, "Poor"},
+
+		// R below zero clamps MOS to 1.0
+		{"Unusable", 2000, 500, 50, -219.9, 1.0, "Bad"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CalculateVoIPScore(tt.latency, tt.jitter, tt.loss)
+			if got.R != tt.r || got.MOS != tt.mos {
+				t.Errorf("CalculateVoIPScore(%v, %v, %v) = R %v / MOS %v; want R %v / MOS %v",
+					tt.latency, tt.jitter, tt.loss, got.R, got.MOS, tt.r, tt.mos)
+			}
+			if got.Rating() != tt.rating {
+				t.Errorf("Rating() = %s; want %s", got.Rating(), tt.rating)
+			}
+		})
+	}
+}
@@ -133,6 +133,7 @@ func GenerateToWriter(data []models.CombinedStats, w io.Writer) error {
 	lossXYs := make(plotter.XYs, len(data))
 	barsXYs := make(plotter.XYs, len(data))
 	healthXYs := make(plotter.XYs, len(data))
+	mosXYs := make(plotter.XYs, 0, len(data))
 	bandXYs := make(plotter.XYs, len(data))
 	towerXYs := make(plotter.XYs, len(data))
 
@@ -223,6 +224,11 @@ func GenerateToWriter(data []models.CombinedStats, w io.Writer) error {
 		healthXYs[i].X = t
 		healthXYs[i].Y = analysis.CalculateSignalHealth(d.Gateway.Signal.FiveG.RSRP, d.Gateway.Signal.FiveG.SINR)
 
+		// VoIP MOS shares the 1-5 scale with bars; skip samples without pings
+		if d.Ping.Sent > 0 {
+			mosXYs = append(mosXYs, plotter.XY{X: t, Y: analysis.CalculateVoIPScoreForPing(d.Ping).MOS})
+		}
+
 		bandXYs[i].X = t
 		towerXYs[i].X = t
 
@@ -261,6 +267,7 @@ func GenerateToWriter(data []models.CombinedStats, w io.Writer) error {
 			shouldSmoothBars = true
 			barsXYs = downsample(barsXYs, 300)
 			healthXYs = downsample(healthXYs, 300)
+			mosXYs = downsample(mosXYs, 300)
 		}
 
 		// 24 hours = 86400 seconds
@@ -426,7 +433,7 @@ func GenerateToWriter(data []models.CombinedStats, w io.Writer) error {
 
 	// --- 4. Signal Bars Plot ---
 	pBars := plot.New()
-	pBars.Title.Text = "Signal Bars"
+	pBars.Title.Text = "Signal Bars & VoIP MOS"
 	pBars.Y.Label.Text = "Bars"
 	pBars.X.Tick.Marker = timeTicks
 	pBars.X.Min = minX
@@ -466,6 +473,16 @@ func GenerateToWriter(data []models.CombinedStats, w io.Writer) error {
 	pBars.Add(polyHealth, lineHealth, lineBars)
 	pBars.Legend.Add("Reported Bars", lineBars)
 	pBars.Legend.Add("Signal Health", lineHealth)
+
+	if len(mosXYs) > 0 {
+		lineMOS, _ := plotter.NewLine(mosXYs)
+		lineMOS.Color = color.RGBA{R: 0, G: 128, B: 128, A: 255} // Teal
+		lineMOS.Width = vg.Points(1)
+		pBars.Add(lineMOS)
+		pBars.Legend.Add("VoIP MOS", lineMOS)
+		addLastPointLabel(pBars, mosXYs, "MOS %.2f", lineMOS.Color)
+	}
+
 	pBars.Add(plotter.NewGrid())
 	addLastPointLabel(pBars, barsXYs, "%.1f", lineBars.Color)
 
@@ -0,0 +1,181 @@
+package charting
+
+import (
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"time"
+
+	"gonum.org/v1/plot"
+	"gonum.org/v1/plot/plotter"
+	"gonum.org/v1/plot/vg"
+	"gonum.org/v1/plot/vg/draw"
+	"gonum.org/v1/plot/vg/vgimg"
+
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/models"
+)
+
+// GenerateLatency creates a per-packet latency PNG chart from a raw ping log and saves it to outputFile.
+func GenerateLatency(results []models.PingResult, outputFile string) error {
+	f, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return GenerateLatencyToWriter(results, f)
+}
+
+// GenerateLatencyToWriter charts every individual RTT and marks each lost
+// packet, in one panel per target stacked over a shared time axis.
+func GenerateLatencyToWriter(results []models.PingResult, w io.Writer) error {
+	if len(results) == 0 {
+		return fmt.Errorf("no data to chart")
+	}
+
+	minTime, maxTime := results[0].Time, results[0].Time
+	for _, r := range results {
+		if r.Time.Before(minTime) {
+			minTime = r.Time
+		}
+		if r.Time.After(maxTime) {
+			maxTime = r.Time
+		}
+	}
+	minX := float64(minTime.Unix())
+	maxT := float64(maxTime.Unix())
+	duration := maxT - minX
+	if duration <= 0 {
+		duration = 60
+	}
+	maxX := maxT + (duration * 0.12)
+
+	format := "15:04"
+	if duration <= 3600 {
+		format = "15:04:05"
+	}
+
+	targets := analysis.SplitByTarget(results)
+	var plots []*plot.Plot
+	for _, t := range targets {
+		title := ""
+		if len(targets) > 1 {
+			title = t.Label
+		}
+		p, err := latencyPlot(t.Results, title, format, minX, maxX, maxT)
+		if err != nil {
+			return err
+		}
+		plots = append(plots, p)
+	}
+
+	const width = 20 * vg.Inch
+	const panelHeight = 6 * vg.Inch
+	height := panelHeight * vg.Length(len(plots))
+
+	c := vgimg.NewWith(vgimg.UseWH(width, height), vgimg.UseBackgroundColor(color.White))
+	dc := draw.New(c)
+	for i, p := range plots {
+		top := height - panelHeight*vg.Length(i)
+		p.Draw(draw.Canvas{
+			Canvas: dc,
+			Rectangle: vg.Rectangle{
+				Min: vg.Point{X: 0, Y: top - panelHeight},
+				Max: vg.Point{X: width, Y: top},
+			},
+		})
+	}
+
+	return png.Encode(w, c.Image())
+}
+
+// latencyPlot charts the results of one target. The title names the
+// target when there is more than one.
+func latencyPlot(results []models.PingResult, target, format string, minX, maxX, maxT float64) (*plot.Plot, error) {
+	report := analysis.BuildRawReport(results, nil)
+
+	var rttXYs, lossXYs plotter.XYs
+	for _, r := range results {
+		t := float64(r.Time.Unix()) + float64(r.Time.Nanosecond())/1e9
+		if r.Lost {
+			lossXYs = append(lossXYs, plotter.XY{X: t})
+			continue
+		}
+		rtt := r.RTT
+		if rtt <= 0 {
+			rtt = 0.1 // Sanitize for log scale
+		}
+		rttXYs = append(rttXYs, plotter.XY{X: t, Y: rtt})
+	}
+
+	// Loss markers sit just above the highest RTT so they never hide the trace
+	lossY := report.RTT.Max * 1.5
+	if lossY <= 0 {
+		lossY = 100
+	}
+	for i := range lossXYs {
+		lossXYs[i].Y = lossY
+	}
+
+	// Keep individual spikes visible; only thin out very long logs
+	if len(rttXYs) > 5000 {
+		rttXYs = downsample(rttXYs, 5000)
+	}
+
+	p := plot.New()
+	p.Title.Text = fmt.Sprintf("Per-Packet Latency (%d probes, %d lost)", report.Sent, report.Lost)
+	if target != "" {
+		p.Title.Text = fmt.Sprintf("Per-Packet Latency: %s (%d probes, %d lost)", target, report.Sent, report.Lost)
+	}
+	p.Y.Label.Text = "RTT (ms)"
+	p.X.Tick.Marker = plot.TimeTicks{
+		Format: format,
+		Time: func(t float64) time.Time {
+			return time.Unix(int64(t), 0).Local()
+		},
+	}
+	p.X.Min = minX
+	p.X.Max = maxX
+	p.Y.Scale = plot.LogScale{}
+	p.Y.Tick.Marker = logTicks{}
+
+	if len(rttXYs) > 0 {
+		lineRTT, err := plotter.NewLine(rttXYs)
+		if err != nil {
+			return nil, err
+		}
+		lineRTT.Color = color.RGBA{R: 0, G: 0, B: 255, A: 255} // Blue
+		lineRTT.Width = vg.Points(0.5)
+		p.Add(lineRTT)
+		p.Legend.Add("RTT", lineRTT)
+
+		// P95 reference line across the full range
+		p95XYs := plotter.XYs{{X: minX, Y: report.P95}, {X: maxT, Y: report.P95}}
+		lineP95, err := plotter.NewLine(p95XYs)
+		if err != nil {
+			return nil, err
+		}
+		lineP95.Color = color.RGBA{R: 255, G: 140, B: 0, A: 255} // Dark Orange
+		lineP95.Dashes = []vg.Length{vg.Points(4), vg.Points(2)}
+		p.Add(lineP95)
+		p.Legend.Add("P95", lineP95)
+		addCustomLabel(p, p95XYs, fmt.Sprintf("P95: %.1fms", report.P95), lineP95.Color)
+	}
+
+	if len(lossXYs) > 0 {
+		scatterLoss, err := plotter.NewScatter(lossXYs)
+		if err != nil {
+			return nil, err
+		}
+		scatterLoss.GlyphStyle.Shape = draw.CrossGlyph{}
+		scatterLoss.GlyphStyle.Color = color.RGBA{R: 255, G: 0, B: 0, A: 255} // Red
+		scatterLoss.GlyphStyle.Radius = vg.Points(2.5)
+		p.Add(scatterLoss)
+		p.Legend.Add("Lost", scatterLoss)
+	}
+
+	p.Add(plotter.NewGrid())
+	return p, nil
+}
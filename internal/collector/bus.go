@@ -0,0 +1,219 @@
+package collector
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultMaxWait is how long Publish waits for a full subscriber to catch up
+// before dropping that subscriber's oldest queued sample.
+const DefaultMaxWait = 100 * time.Millisecond
+
+// Subscription is one consumer's bounded queue on the Bus.
+type Subscription struct {
+	Name string
+	C    <-chan Sample
+
+	ch        chan Sample
+	maxWait   time.Duration
+	mu        sync.Mutex
+	delivered uint64
+	dropped   uint64
+
+	// sendMu keeps the channel from being closed mid-send, now that
+	// delivery happens outside the bus lock
+	sendMu sync.Mutex
+	closed bool
+}
+
+// SubscriptionStats reports delivery counters for a subscription.
+// Delivered counts samples queued for the consumer; Dropped counts samples
+// discarded unread because the consumer fell behind.
+type SubscriptionStats struct {
+	Name      string `json:"name"`
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"`
+	Queued    int    `json:"queued"`
+}
+
+// Stats returns the subscription's delivery counters.
+func (s *Subscription) Stats() SubscriptionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return SubscriptionStats{
+		Name:      s.Name,
+		Delivered: s.delivered,
+		Dropped:   s.dropped,
+		Queued:    len(s.ch),
+	}
+}
+
+// Bus fans published samples out to every subscriber.
+// Each subscriber has its own bounded queue, so a slow consumer only ever
+// loses its own oldest samples and never blocks the others for long.
+type Bus struct {
+	mu     sync.Mutex
+	subs   []*Subscription
+	closed bool
+}
+
+// NewBus creates an empty bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers a consumer with a queue of size samples.
+func (b *Bus) Subscribe(name string, size int) *Subscription {
+	if size < 1 {
+		size = 1
+	}
+	ch := make(chan Sample, size)
+	sub := &Subscription{Name: name, C: ch, ch: ch, maxWait: DefaultMaxWait}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		sub.close()
+		return sub
+	}
+	b.subs = append(b.subs, sub)
+	return sub
+}
+
+// Unsubscribe removes a consumer and closes its channel.
+func (b *Bus) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, s := range b.subs {
+		if s == sub {
+			b.subs = append(b.subs[:i], b.subs[i+1:]...)
+			s.close()
+			return
+		}
+	}
+}
+
+// Publish delivers s to every subscriber.
+// A full queue gets up to maxWait to drain (backpressure); after that the
+// subscriber's oldest sample is dropped to make room and counted.
+func (b *Bus) Publish(s Sample) {
+	b.mu.Lock()
+	subs := slices.Clone(b.subs)
+	b.mu.Unlock()
+
+	// Queues with room get the sample straight away. Full ones wait side by
+	// side, so a stalled subscriber holds up neither the others nor
+	// Subscribe, Unsubscribe and Stats.
+	var wg sync.WaitGroup
+	for _, sub := range subs {
+		if !sub.tryDeliver(s) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sub.deliver(s)
+			}()
+		}
+	}
+	wg.Wait()
+}
+
+// Stats returns the delivery counters of every subscriber.
+func (b *Bus) Stats() []SubscriptionStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	stats := make([]SubscriptionStats, 0, len(b.subs))
+	for _, sub := range b.subs {
+		stats = append(stats, sub.Stats())
+	}
+	return stats
+}
+
+// Close closes every subscriber channel. Later subscriptions are closed immediately.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, sub := range b.subs {
+		sub.close()
+	}
+	b.subs = nil
+}
+
+// close closes the channel once no delivery is in progress.
+func (sub *Subscription) close() {
+	sub.sendMu.Lock()
+	defer sub.sendMu.Unlock()
+	if !sub.closed {
+		sub.closed = true
+		close(sub.ch)
+	}
+}
+
+// tryDeliver queues s if there is room, reporting whether it is done with
+// s. Samples for a closed subscription are discarded.
+func (sub *Subscription) tryDeliver(s Sample) bool {
+	sub.sendMu.Lock()
+	defer sub.sendMu.Unlock()
+	if sub.closed {
+		return true
+	}
+	select {
+	case sub.ch <- s:
+		sub.count(false)
+		return true
+	default:
+		return false
+	}
+}
+
+func (sub *Subscription) deliver(s Sample) {
+	sub.sendMu.Lock()
+	defer sub.sendMu.Unlock()
+	if sub.closed {
+		return
+	}
+	select {
+	case sub.ch <- s:
+		sub.count(false)
+		return
+	default:
+	}
+
+	timer := time.NewTimer(sub.maxWait)
+	defer timer.Stop()
+	select {
+	case sub.ch <- s:
+		sub.count(false)
+		return
+	case <-timer.C:
+	}
+
+	// Still full: make room by dropping the oldest queued sample
+	select {
+	case <-sub.ch:
+		sub.count(true)
+	default:
+	}
+	select {
+	case sub.ch <- s:
+		sub.count(false)
+	default:
+		sub.count(true)
+	}
+}
+
+func (sub *Subscription) count(dropped bool) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	if dropped {
+		sub.dropped++
+	} else {
+		sub.delivered++
+	}
+}
@@ -0,0 +1,100 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBusFanOut(t *testing.T) {
+	b := NewBus()
+	a := b.Subscribe("a", 4)
+	c := b.Subscribe("c", 4)
+
+	b.Publish(Sample{Time: time.Unix(1, 0)})
+	b.Publish(Sample{Time: time.Unix(2, 0)})
+	b.Close()
+
+	for _, sub := range []*Subscription{a, c} {
+		var got []int64
+		for s := range sub.C {
+			got = append(got, s.Time.Unix())
+		}
+		if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+			t.Errorf("%s: expected samples [1 2], got %v", sub.Name, got)
+		}
+		if st := sub.Stats(); st.Delivered != 2 || st.Dropped != 0 {
+			t.Errorf("%s: unexpected stats %+v", sub.Name, st)
+		}
+	}
+}
+
+func TestBusDropsOldestForSlowConsumer(t *testing.T) {
+	b := NewBus()
+	slow := b.Subscribe("slow", 2)
+	slow.maxWait = time.Millisecond
+	fast := b.Subscribe("fast", 8)
+
+	for i := 1; i <= 5; i++ {
+		b.Publish(Sample{Time: time.Unix(int64(i), 0)})
+	}
+
+	st := slow.Stats()
+	if st.Dropped != 3 || st.Queued != 2 {
+		t.Errorf("Expected 3 dropped and 2 queued, got %+v", st)
+	}
+	// The slow consumer keeps the newest samples
+	if s := <-slow.C; s.Time.Unix() != 4 {
+		t.Errorf("Expected oldest surviving sample 4, got %d", s.Time.Unix())
+	}
+	if st := fast.Stats(); st.Dropped != 0 || st.Delivered != 5 {
+		t.Errorf("Fast consumer should not be affected, got %+v", st)
+	}
+}
+
+func TestBusStalledSubscriberBlocksNobody(t *testing.T) {
+	b := NewBus()
+	stalled := b.Subscribe("stalled", 1)
+	stalled.maxWait = time.Second
+	fast := b.Subscribe("fast", 8)
+
+	b.Publish(Sample{Time: time.Unix(1, 0)}) // Fills the stalled queue
+	done := make(chan struct{})
+	go func() {
+		b.Publish(Sample{Time: time.Unix(2, 0)}) // Waits on the stalled queue
+		close(done)
+	}()
+
+	for i := int64(1); i <= 2; i++ {
+		select {
+		case s := <-fast.C:
+			if s.Time.Unix() != i {
+				t.Errorf("Expected sample %d, got %d", i, s.Time.Unix())
+			}
+		case <-time.After(500 * time.Millisecond):
+			t.Fatal("Fast subscriber waited on the stalled one")
+		}
+	}
+	start := time.Now()
+	b.Stats()
+	late := b.Subscribe("late", 1)
+	b.Unsubscribe(late)
+	if d := time.Since(start); d > 500*time.Millisecond {
+		t.Errorf("Stats, Subscribe and Unsubscribe took %s behind a stalled delivery", d)
+	}
+
+	// Closing while a delivery waits is safe
+	b.Close()
+	<-done
+	for range stalled.C {
+	}
+}
+
+func TestBusSubscribeAfterClose(t *testing.T) {
+	b := NewBus()
+	b.Close()
+	sub := b.Subscribe("late", 1)
+	if _, ok := <-sub.C; ok {
+		t.Error("Expected closed channel for subscription after Close")
+	}
+	b.Publish(Sample{}) // must not panic
+}
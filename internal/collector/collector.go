@@ -0,0 +1,223 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/gateway"
+	"tmobile-stats/internal/logger"
+	"tmobile-stats/internal/models"
+	"tmobile-stats/internal/pinger"
+	"tmobile-stats/internal/scheduler"
+)
+
+// Sample is one poll result published on the bus.
+// Stats is nil when the gateway fetch failed and Err is set.
+type Sample struct {
+	Time         time.Time
+	Stats        *models.CombinedStats
+	LifetimePing models.PingStats
+	Err          error
+}
+
+// Collector is the single owner of gateway polling and ping snapshots.
+// It schedules fetches, builds CombinedStats and publishes them on its Bus
+// for the TUI, loggers, the legacy printer, the web server and exporters.
+type Collector struct {
+	routerURL string
+	client    *http.Client
+	pinger    *pinger.Group
+	cursor    *pinger.GroupCursor
+	sched     *scheduler.Adaptive
+	bus       *Bus
+	wake      chan struct{}
+
+	mu      sync.RWMutex
+	latest  Sample
+	status  Status
+	pending []string
+}
+
+// Status summarises the collector's health since Run started.
+type Status struct {
+	Started       time.Time `json:"started"`
+	Polls         uint64    `json:"polls"`
+	Errors        uint64    `json:"errors"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time,omitempty"`
+}
+
+// New creates a collector. The pinger group must be running separately.
+func New(routerURL string, client *http.Client, pg *pinger.Group, sched *scheduler.Adaptive) *Collector {
+	return &Collector{
+		routerURL: routerURL,
+		client:    client,
+		pinger:    pg,
+		cursor:    pg.NewCursor(),
+		sched:     sched,
+		bus:       NewBus(),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Subscribe registers a consumer on the collector's bus.
+func (c *Collector) Subscribe(name string, size int) *Subscription {
+	return c.bus.Subscribe(name, size)
+}
+
+// Unsubscribe removes a consumer from the collector's bus.
+func (c *Collector) Unsubscribe(sub *Subscription) {
+	c.bus.Unsubscribe(sub)
+}
+
+// Bus returns the collector's subscription bus.
+func (c *Collector) Bus() *Bus {
+	return c.bus
+}
+
+// Scheduler returns the adaptive scheduler driving the poll interval.
+func (c *Collector) Scheduler() *scheduler.Adaptive {
+	return c.sched
+}
+
+// Pinger returns the ping group the collector snapshots.
+func (c *Collector) Pinger() *pinger.Group {
+	return c.pinger
+}
+
+// SetInterval changes the base poll interval and re-plans the next poll.
+func (c *Collector) SetInterval(d time.Duration) {
+	c.sched.SetBase(d)
+	c.Wake()
+}
+
+// Wake makes the collector re-evaluate its delay immediately, e.g. after a
+// burst was triggered manually.
+func (c *Collector) Wake() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Latest returns the most recently published sample.
+func (c *Collector) Latest() Sample {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.latest
+}
+
+// Status returns poll and error counters.
+func (c *Collector) Status() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.status
+}
+
+// Annotate queues an operator note for the next successful sample.
+func (c *Collector) Annotate(text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = append(c.pending, text)
+}
+
+// TriggerBurst starts a burst capture now, whatever the samples look like.
+func (c *Collector) TriggerBurst(reason string) {
+	c.sched.Trigger(reason, time.Now())
+	c.Wake()
+}
+
+// Run polls until ctx is cancelled, then closes the bus so consumers drain and exit.
+func (c *Collector) Run(ctx context.Context) {
+	defer c.bus.Close()
+
+	c.mu.Lock()
+	c.status.Started = time.Now()
+	c.mu.Unlock()
+
+	lastPoll := time.Time{}
+	for {
+		now := time.Now()
+		if lastPoll.IsZero() || !now.Before(lastPoll.Add(c.sched.Delay(now))) {
+			lastPoll = now
+			c.Poll()
+		}
+
+		timer := time.NewTimer(time.Until(lastPoll.Add(c.sched.Delay(time.Now()))))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-c.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Poll performs one gateway fetch and ping snapshot and publishes the result.
+func (c *Collector) Poll() Sample {
+	sample := c.collect()
+
+	c.mu.Lock()
+	c.latest = sample
+	c.status.Polls++
+	if sample.Err != nil {
+		c.status.Errors++
+		c.status.LastError = sample.Err.Error()
+		c.status.LastErrorTime = sample.Time
+	} else if len(c.pending) > 0 {
+		sample.Stats.Annotations = c.pending
+		c.pending = nil
+	}
+	c.mu.Unlock()
+
+	c.bus.Publish(sample)
+	return sample
+}
+
+func (c *Collector) collect() Sample {
+	now := time.Now()
+	lifetime := c.pinger.Primary().LifetimeStats()
+
+	gatewayData, err := gateway.FetchStats(c.client, c.routerURL)
+	if err != nil {
+		return Sample{Time: now, LifetimePing: lifetime, Err: err}
+	}
+
+	pingData, targets := c.cursor.Next()
+	stats := &models.CombinedStats{
+		Gateway: *gatewayData,
+		Ping:    pingData,
+		Targets: targets,
+	}
+	c.sched.Flag(stats, now)
+	c.sched.Observe(stats, now)
+
+	return Sample{Time: now, Stats: stats, LifetimePing: lifetime}
+}
+
+// LogTo consumes sub and writes every successful sample to the loggers;
+// failed polls go to loggers implementing logger.GatewayObserver.
+// It returns when the subscription is closed; onErr receives logging errors.
+func LogTo(sub *Subscription, loggers []logger.Logger, onErr func(error)) {
+	for s := range sub.C {
+		if s.Stats == nil {
+			for _, l := range loggers {
+				if o, ok := l.(logger.GatewayObserver); ok {
+					if err := o.GatewayError(s.Err); err != nil && onErr != nil {
+						onErr(err)
+					}
+				}
+			}
+			continue
+		}
+		for _, l := range loggers {
+			if err := l.Log(s.Stats); err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+	}
+}
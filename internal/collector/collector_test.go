@@ -0,0 +1,148 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/config"
+	"tmobile-stats/internal/logger"
+	"tmobile-stats/internal/models"
+	"tmobile-stats/internal/pinger"
+	"tmobile-stats/internal/scheduler"
+)
+
+type memLogger struct {
+	mu   sync.Mutex
+	logs []*models.CombinedStats
+}
+
+func (l *memLogger) Log(data *models.CombinedStats) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.logs = append(l.logs, data)
+	return nil
+}
+
+func (l *memLogger) Close() error { return nil }
+
+func newTestCollector(t *testing.T, handler http.HandlerFunc) *Collector {
+	t.Helper()
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	pg := pinger.NewGroup(pinger.NewPinger("127.0.0.1", time.Second))
+	sched := scheduler.New(config.AdaptiveConfig{}, 20*time.Millisecond)
+	return New(ts.URL, &http.Client{Timeout: time.Second}, pg, sched)
+}
+
+func TestCollectorPublishesToAllConsumers(t *testing.T) {
+	c := newTestCollector(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"device": {"model": "TEST-MODEL"}}`)
+	})
+
+	ui := c.Subscribe("tui", 8)
+	logs := c.Subscribe("loggers", 8)
+	l := &memLogger{}
+	done := make(chan struct{})
+	go func() {
+		LogTo(logs, []logger.Logger{l}, nil)
+		close(done)
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go c.Run(ctx)
+
+	for i := 0; i < 3; i++ {
+		s := <-ui.C
+		if s.Err != nil || s.Stats.Gateway.Device.Model != "TEST-MODEL" {
+			t.Fatalf("Unexpected sample %+v", s)
+		}
+	}
+	cancel()
+	<-done
+
+	if len(l.logs) < 3 {
+		t.Errorf("Expected the logger to see at least 3 samples, got %d", len(l.logs))
+	}
+	if st := c.Status(); st.Polls < 3 || st.Errors != 0 {
+		t.Errorf("Expected >=3 polls and no errors, got %+v", st)
+	}
+}
+
+func TestCollectorPublishesFetchErrors(t *testing.T) {
+	c := newTestCollector(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	s := c.Poll()
+	if s.Err == nil || s.Stats != nil {
+		t.Fatalf("Expected fetch error sample, got %+v", s)
+	}
+	if st := c.Status(); st.Errors != 1 || st.LastError == "" {
+		t.Errorf("Expected 1 error with message, got %+v", st)
+	}
+}
+
+func TestCollectorAnnotatesNextSample(t *testing.T) {
+	fail := true
+	c := newTestCollector(t, func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"device": {"model": "TEST-MODEL"}}`)
+	})
+
+	c.Annotate("moved gateway")
+	if s := c.Poll(); s.Err == nil {
+		t.Fatal("Expected fetch error")
+	}
+
+	// A failed poll keeps the note for the next successful sample
+	fail = false
+	s := c.Poll()
+	if len(s.Stats.Annotations) != 1 || s.Stats.Annotations[0] != "moved gateway" {
+		t.Errorf("Expected annotation on sample, got %v", s.Stats.Annotations)
+	}
+	if s := c.Poll(); len(s.Stats.Annotations) != 0 {
+		t.Errorf("Annotation should only be attached once, got %v", s.Stats.Annotations)
+	}
+}
+
+type watchLogger struct {
+	memLogger
+	errs []error
+}
+
+func (l *watchLogger) GatewayError(err error) error {
+	l.errs = append(l.errs, err)
+	return nil
+}
+
+func TestLogToReportsFetchErrors(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe("loggers", 8)
+	plain, watcher := &memLogger{}, &watchLogger{}
+	done := make(chan struct{})
+	go func() {
+		LogTo(sub, []logger.Logger{plain, watcher}, nil)
+		close(done)
+	}()
+
+	bus.Publish(Sample{Err: fmt.Errorf("gateway unreachable")})
+	bus.Publish(Sample{Stats: &models.CombinedStats{}})
+	bus.Close()
+	<-done
+
+	if len(plain.logs) != 1 || len(watcher.logs) != 1 {
+		t.Errorf("Expected one logged sample each, got %d and %d", len(plain.logs), len(watcher.logs))
+	}
+	if len(watcher.errs) != 1 || watcher.errs[0].Error() != "gateway unreachable" {
+		t.Errorf("Expected the fetch error to be reported, got %v", watcher.errs)
+	}
+}
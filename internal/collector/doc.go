@@ -0,0 +1,3 @@
+// Package collector owns gateway polling and ping snapshots and publishes
+// each sample to in-process subscribers (TUI, loggers, printer, web, exporters).
+package collector
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+
+	"tmobile-stats/internal/models"
 )
 
 // Config holds all application configuration settings.
@@ -18,6 +20,136 @@ type Config struct {
 	WebEnabled      bool   `json:"web_enabled"`      // Unified Run Mode
 	WebPort         int    `json:"web_port"`         // Unified Run Mode
 	Silent          bool   `json:"silent"`           // Suppress CLI output
+	RawPingLog      string `json:"raw_ping_log"`     // Per-packet ping log (empty disables)
+	ControlSocket   string `json:"control_socket"`   // Unix socket for status/ctl ("" default path, "none" disables)
+	MetricsPort     int    `json:"metrics_port"`     // Standalone Prometheus /metrics listener (0 disables)
+
+	// PingOptions applies to PingTarget. PingTargets, if set, replaces both
+	// and lists every target to probe; the first entry is the primary one.
+	PingOptions models.PingOptions `json:"ping_options"`
+	PingTargets []PingTarget       `json:"ping_targets"`
+
+	// Sinks lists the logs to write. Format/Output, Influx and MQTT are
+	// still honoured and add a sink each.
+	Sinks []SinkConfig `json:"sinks"`
+
+	Adaptive AdaptiveConfig `json:"adaptive"`
+	Rotation RotationConfig `json:"rotation"`
+	Logging  LoggingConfig  `json:"logging"`
+	Rollup   RollupConfig   `json:"rollup"`
+	Redact   RedactConfig   `json:"redact"`
+	Influx   InfluxConfig   `json:"influx"`
+	MQTT     MQTTConfig     `json:"mqtt"`
+	OTLP     OTLPConfig     `json:"otlp"`
+}
+
+// OTLPConfig points the OpenTelemetry metrics exporter at an OTLP/HTTP
+// collector. It is enabled when Endpoint is set; Headers falls back to
+// $OTEL_EXPORTER_OTLP_HEADERS.
+type OTLPConfig struct {
+	Endpoint        string            `json:"endpoint"` // e.g. http://otel-collector:4318
+	Headers         map[string]string `json:"headers"`
+	IntervalSeconds int               `json:"interval_seconds"` // Push interval (default 60)
+	Site            string            `json:"site"`             // Reported as the "site" resource attribute
+}
+
+// MQTTConfig points the MQTT publisher at a broker. It is enabled when
+// Broker is set; Password falls back to $MQTT_PASSWORD.
+type MQTTConfig struct {
+	Broker           string `json:"broker"` // e.g. tcp://homeassistant.local:1883
+	ClientID         string `json:"client_id"`
+	Username         string `json:"username"`
+	Password         string `json:"password"`
+	TopicPrefix      string `json:"topic_prefix"`      // Default "signal-sentry"
+	DiscoveryPrefix  string `json:"discovery_prefix"`  // Default "homeassistant"
+	DisableDiscovery bool   `json:"disable_discovery"` // Don't announce Home Assistant sensors
+}
+
+// InfluxConfig points the InfluxDB v2 writer at a server. It is enabled
+// when URL is set; Token falls back to $INFLUX_TOKEN.
+type InfluxConfig struct {
+	URL          string `json:"url"`
+	Org          string `json:"org"`
+	Bucket       string `json:"bucket"`
+	Token        string `json:"token"`
+	BatchSize    int    `json:"batch_size"`    // Samples per write (default 10)
+	FlushSeconds int    `json:"flush_seconds"` // Send a partial batch after this long (default 10)
+	MaxBuffer    int    `json:"max_buffer"`    // Samples kept while the server is down (default 10000)
+}
+
+// SinkConfig is one entry of the sinks list. Options depend on the type,
+// e.g. {"path": "signal.csv"} for csv or the influx section's keys for
+// influxdb.
+type SinkConfig struct {
+	Type        string          `json:"type"` // json, csv, sqlite, tsdb, influx, influxdb, mqtt or webhook
+	Name        string          `json:"name"` // Shown in status (default: the type)
+	Options     json.RawMessage `json:"options"`
+	Fields      []string        `json:"fields"`       // Only write these dotted JSON paths, e.g. "gateway.signal.5g.rsrp"
+	SampleEvery int             `json:"sample_every"` // Write one sample in N (0 or 1 writes all)
+
+	DisableRedact bool `json:"disable_redact"` // Write real identifiers even when the redact section is enabled
+}
+
+// LoggingConfig tunes the queue in front of every log sink, which keeps a
+// slow disk or network sink from holding up the others.
+type LoggingConfig struct {
+	QueueSize int    `json:"queue_size"` // Samples kept per sink before the oldest are dropped (default 256)
+	BatchSize int    `json:"batch_size"` // Most samples written at once (default 32)
+	Fsync     string `json:"fsync"`      // "never" (default), "batch" or an interval like "30s"
+}
+
+// RollupConfig controls the 1-minute and 1-hour summaries kept next to the
+// raw log for long-term history. Raw samples are still kept as long as
+// Rotation allows.
+type RollupConfig struct {
+	Enabled    bool `json:"enabled"`
+	MinuteDays int  `json:"minute_days"` // Delete 1-minute rollups older than this (0 = keep)
+	HourDays   int  `json:"hour_days"`   // Delete 1-hour rollups older than this (0 = keep)
+}
+
+// RedactConfig scrubs the gateway serial, MAC address, tower and cell IDs
+// and IP addresses from every log before it is written, for logs meant to
+// be shared. Timestamps are left alone so history reads back consistently;
+// `export -shift` moves them when a log is exported.
+type RedactConfig struct {
+	Enabled bool   `json:"enabled"`
+	Mode    string `json:"mode"` // "hash" (default) keeps IDs consistent; "drop" clears them
+	Key     string `json:"key"`  // Secret behind the hashes; required in hash mode
+}
+
+// RotationConfig controls rotation of stats.log and the -format log.
+// Rotated files are named <name>-<UTC time>.<ext>[.gz] next to the log.
+type RotationConfig struct {
+	MaxSizeMB  int    `json:"max_size_mb"`  // Rotate at this size (0 = no size limit)
+	Every      string `json:"every"`        // "hourly", "daily", "weekly" or a duration like "12h"
+	MaxAgeDays int    `json:"max_age_days"` // Delete rotated files older than this (0 = keep)
+	MaxFiles   int    `json:"max_files"`    // Keep at most this many rotated files (0 = keep all)
+	Compress   bool   `json:"compress"`     // Gzip rotated files
+}
+
+// AdaptiveConfig controls burst capture: when health drops, loss appears or
+// the tower changes, the gateway is polled every BurstInterval seconds for
+// BurstDuration seconds before backing off to RefreshInterval.
+type AdaptiveConfig struct {
+	Enabled       bool    `json:"enabled"`
+	BurstInterval int     `json:"burst_interval"` // Seconds
+	BurstDuration int     `json:"burst_duration"` // Seconds
+	HealthDrop    float64 `json:"health_drop"`    // Signal health drop that triggers a burst
+	LossThreshold float64 `json:"loss_threshold"` // Loss percentage above which a burst starts
+}
+
+// PingTarget is one host to probe with its own ICMP packet options.
+type PingTarget struct {
+	Host string `json:"host"`
+	models.PingOptions
+}
+
+// Targets returns the resolved list of ping targets, primary first.
+func (c *Config) Targets() []PingTarget {
+	if len(c.PingTargets) > 0 {
+		return c.PingTargets
+	}
+	return []PingTarget{{Host: c.PingTarget, PingOptions: c.PingOptions}}
 }
 
 // DefaultConfig returns a configuration with sensible defaults.
@@ -27,6 +159,17 @@ func DefaultConfig() *Config {
 		PingTarget:      "8.8.8.8",
 		RefreshInterval: 5,
 		WebPort:         8080,
+		Adaptive: AdaptiveConfig{
+			BurstInterval: 1,
+			BurstDuration: 60,
+			HealthDrop:    0.5,
+		},
+		Rotation: RotationConfig{
+			Compress: true,
+		},
+		Rollup: RollupConfig{
+			MinuteDays: 90,
+		},
 	}
 }
 
@@ -0,0 +1,101 @@
+package control
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Client talks to a running instance's control socket.
+type Client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int
+}
+
+// Dial connects to the control socket at path.
+func Dial(path string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", path, 2*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("no running instance at %s: %w", path, err)
+	}
+	return &Client{conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// Call invokes method with params and decodes the result into result (if non-nil).
+func (c *Client) Call(method string, params, result any) error {
+	c.nextID++
+	req := Request{JSONRPC: "2.0", ID: json.RawMessage(strconv.Itoa(c.nextID)), Method: method}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		req.Params = raw
+	}
+
+	c.conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		return err
+	}
+
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result != nil {
+		return json.Unmarshal(resp.Result, result)
+	}
+	return nil
+}
+
+// Subscribe turns the connection into a sample stream. The returned status
+// describes the instance at the time of subscribing. The client cannot be
+// used for other calls afterwards; open a second one for commands.
+func (c *Client) Subscribe() (StatusResult, error) {
+	var st StatusResult
+	if err := c.Call(MethodSubscribe, nil, &st); err != nil {
+		return st, err
+	}
+	// Samples may be minutes apart, so the stream has no deadline
+	c.conn.SetDeadline(time.Time{})
+	return st, nil
+}
+
+// NextSample blocks until the next sample notification on a subscribed
+// connection. It returns io.EOF once the instance stops.
+func (c *Client) NextSample() (SampleEvent, error) {
+	for {
+		line, err := c.reader.ReadBytes('\n')
+		if err != nil {
+			return SampleEvent{}, err
+		}
+		var note Request
+		if err := json.Unmarshal(line, &note); err != nil {
+			return SampleEvent{}, err
+		}
+		if note.Method != MethodSample {
+			continue
+		}
+		var event SampleEvent
+		if err := json.Unmarshal(note.Params, &event); err != nil {
+			return SampleEvent{}, err
+		}
+		return event, nil
+	}
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
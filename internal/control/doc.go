@@ -0,0 +1,3 @@
+// Package control exposes a running instance over a Unix domain socket
+// speaking newline-delimited JSON-RPC 2.0, and provides the matching client.
+package control
@@ -0,0 +1,122 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"tmobile-stats/internal/collector"
+	"tmobile-stats/internal/logger"
+	"tmobile-stats/internal/models"
+)
+
+// Methods served on the control socket.
+const (
+	MethodStatus      = "status"
+	MethodSetInterval = "set_interval"
+	MethodAnnotate    = "annotate"
+	MethodBurst       = "burst"
+
+	// MethodSubscribe turns the connection into a stream of MethodSample
+	// notifications, one per collected sample.
+	MethodSubscribe = "subscribe"
+	MethodSample    = "sample"
+)
+
+// JSON-RPC 2.0 error codes.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeServerError    = -32000
+)
+
+// Request is a JSON-RPC 2.0 request; one per line on the socket.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response is a JSON-RPC 2.0 response; one per line on the socket.
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// Error is a JSON-RPC error object.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("rpc error %d: %s", e.Code, e.Message)
+}
+
+// StatusResult is returned by the status method.
+type StatusResult struct {
+	Version       string                        `json:"version"`
+	PID           int                           `json:"pid"`
+	Uptime        float64                       `json:"uptime_seconds"`
+	Interval      float64                       `json:"interval_seconds"`
+	Scheduler     string                        `json:"scheduler,omitempty"`
+	Collector     collector.Status              `json:"collector"`
+	SampleTime    time.Time                     `json:"sample_time,omitempty"`
+	Sample        *models.CombinedStats         `json:"sample,omitempty"`
+	LifetimePing  models.PingStats              `json:"lifetime_ping"`
+	Subscriptions []collector.SubscriptionStats `json:"subscriptions"`
+	Logging       []logger.SinkStats            `json:"logging,omitempty"`
+}
+
+// SampleEvent is the params of a sample notification on a subscribed connection.
+type SampleEvent struct {
+	Time         time.Time             `json:"time"`
+	Stats        *models.CombinedStats `json:"stats,omitempty"`
+	LifetimePing models.PingStats      `json:"lifetime_ping"`
+	Error        string                `json:"error,omitempty"`
+	Interval     float64               `json:"interval_seconds"`
+	Scheduler    string                `json:"scheduler,omitempty"`
+	Logging      []logger.SinkStats    `json:"logging,omitempty"`
+}
+
+// Sample converts the event back into a collector sample.
+func (e *SampleEvent) Sample() collector.Sample {
+	s := collector.Sample{Time: e.Time, Stats: e.Stats, LifetimePing: e.LifetimePing}
+	if e.Error != "" {
+		s.Err = errors.New(e.Error)
+	}
+	return s
+}
+
+// IntervalParams are the parameters of set_interval.
+type IntervalParams struct {
+	Seconds int `json:"seconds"`
+}
+
+// AnnotateParams are the parameters of annotate.
+type AnnotateParams struct {
+	Text string `json:"text"`
+}
+
+// BurstParams are the parameters of burst.
+type BurstParams struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// DefaultSocketPath is where instances listen unless configured otherwise:
+// $XDG_RUNTIME_DIR/signal-sentry.sock, or a per-user file in the temp dir.
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "signal-sentry.sock")
+	}
+	return filepath.Join(os.TempDir(), "signal-sentry-"+strconv.Itoa(os.Getuid())+".sock")
+}
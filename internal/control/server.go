@@ -0,0 +1,248 @@
+package control
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"tmobile-stats/internal/collector"
+	"tmobile-stats/internal/logger"
+)
+
+// MaxInterval is the longest poll interval set_interval accepts.
+const MaxInterval = 3600
+
+// Server answers JSON-RPC requests about a running collector on a Unix socket.
+type Server struct {
+	coll     *collector.Collector
+	version  string
+	logs     *logger.Fanout
+	ln       net.Listener
+	attached atomic.Int64
+}
+
+// NewServer creates a control server for coll; version is reported by status.
+func NewServer(coll *collector.Collector, version string) *Server {
+	return &Server{coll: coll, version: version}
+}
+
+// SetLogs reports the counters of logs in status and sample events.
+func (s *Server) SetLogs(logs *logger.Fanout) {
+	s.logs = logs
+}
+
+// logStats returns the log sink counters, or nil without logs.
+func (s *Server) logStats() []logger.SinkStats {
+	if s.logs == nil {
+		return nil
+	}
+	return s.logs.Stats()
+}
+
+// Listen binds the Unix socket at path. A stale socket left by a crashed
+// instance is replaced; one that still answers is an error.
+func (s *Server) Listen(path string) error {
+	if _, err := os.Stat(path); err == nil {
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return fmt.Errorf("control socket %s is in use by another instance", path)
+		}
+		os.Remove(path)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	// Only the owner may change the interval or trigger bursts
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return err
+	}
+	s.ln = ln
+	return nil
+}
+
+// Serve accepts connections until ctx is cancelled, then closes the listener.
+// Removing the socket file is left to the caller.
+func (s *Server) Serve(ctx context.Context) error {
+	go func() {
+		<-ctx.Done()
+		s.ln.Close()
+	}()
+
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			enc.Encode(errorResponse(nil, CodeParseError, err.Error()))
+			continue
+		}
+		if req.Method == MethodSubscribe {
+			s.stream(conn, enc, req)
+			return
+		}
+		if err := enc.Encode(s.handle(req)); err != nil {
+			return
+		}
+	}
+}
+
+// stream acknowledges a subscribe request and then writes one sample
+// notification per collected sample until either side goes away.
+func (s *Server) stream(conn net.Conn, enc *json.Encoder, req Request) {
+	sub := s.coll.Subscribe(fmt.Sprintf("attach-%d", s.attached.Add(1)), 16)
+	defer s.coll.Unsubscribe(sub)
+
+	raw, _ := json.Marshal(s.status())
+	if err := enc.Encode(Response{JSONRPC: "2.0", ID: req.ID, Result: raw}); err != nil {
+		return
+	}
+
+	sched := s.coll.Scheduler()
+	for sample := range sub.C {
+		event := SampleEvent{
+			Time:         sample.Time,
+			Stats:        sample.Stats,
+			LifetimePing: sample.LifetimePing,
+			Interval:     sched.Base().Seconds(),
+			Scheduler:    sched.Status(time.Now()),
+			Logging:      s.logStats(),
+		}
+		if sample.Err != nil {
+			event.Error = sample.Err.Error()
+		}
+		params, _ := json.Marshal(event)
+		if err := enc.Encode(Request{JSONRPC: "2.0", Method: MethodSample, Params: params}); err != nil {
+			return
+		}
+	}
+}
+
+// handle dispatches one decoded request.
+func (s *Server) handle(req Request) Response {
+	if req.Method == "" {
+		return errorResponse(req.ID, CodeInvalidRequest, "missing method")
+	}
+
+	result, err := s.call(req.Method, req.Params)
+	if err != nil {
+		var rpcErr *Error
+		if errors.As(err, &rpcErr) {
+			return errorResponse(req.ID, rpcErr.Code, rpcErr.Message)
+		}
+		return errorResponse(req.ID, CodeServerError, err.Error())
+	}
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(req.ID, CodeServerError, err.Error())
+	}
+	return Response{JSONRPC: "2.0", ID: req.ID, Result: raw}
+}
+
+func (s *Server) call(method string, params json.RawMessage) (any, error) {
+	switch method {
+	case MethodStatus:
+		return s.status(), nil
+
+	case MethodSetInterval:
+		var p IntervalParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Seconds < 1 || p.Seconds > MaxInterval {
+			return nil, &Error{CodeInvalidParams, fmt.Sprintf("interval must be between 1 and %d seconds", MaxInterval)}
+		}
+		s.coll.SetInterval(time.Duration(p.Seconds) * time.Second)
+		return s.status(), nil
+
+	case MethodAnnotate:
+		var p AnnotateParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(p.Text) == "" {
+			return nil, &Error{CodeInvalidParams, "annotation text must not be empty"}
+		}
+		s.coll.Annotate(p.Text)
+		return s.status(), nil
+
+	case MethodBurst:
+		var p BurstParams
+		if err := decodeParams(params, &p); err != nil {
+			return nil, err
+		}
+		if p.Reason == "" {
+			p.Reason = "manual"
+		}
+		s.coll.TriggerBurst(p.Reason)
+		return s.status(), nil
+	}
+	return nil, &Error{CodeMethodNotFound, "unknown method " + method}
+}
+
+func (s *Server) status() StatusResult {
+	now := time.Now()
+	st := s.coll.Status()
+	latest := s.coll.Latest()
+	sched := s.coll.Scheduler()
+
+	res := StatusResult{
+		Version:       s.version,
+		PID:           os.Getpid(),
+		Interval:      sched.Base().Seconds(),
+		Scheduler:     sched.Status(now),
+		Collector:     st,
+		SampleTime:    latest.Time,
+		Sample:        latest.Stats,
+		LifetimePing:  s.coll.Pinger().Primary().LifetimeStats(),
+		Subscriptions: s.coll.Bus().Stats(),
+		Logging:       s.logStats(),
+	}
+	if !st.Started.IsZero() {
+		res.Uptime = now.Sub(st.Started).Seconds()
+	}
+	return res
+}
+
+func decodeParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{CodeInvalidParams, err.Error()}
+	}
+	return nil
+}
+
+func errorResponse(id json.RawMessage, code int, msg string) Response {
+	return Response{JSONRPC: "2.0", ID: id, Error: &Error{Code: code, Message: msg}}
+}
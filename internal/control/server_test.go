@@ -0,0 +1,153 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/collector"
+	"tmobile-stats/internal/config"
+	"tmobile-stats/internal/pinger"
+	"tmobile-stats/internal/scheduler"
+)
+
+func startServer(t *testing.T) (*collector.Collector, *Client, string) {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"device": {"model": "TEST-MODEL"}}`)
+	}))
+	t.Cleanup(ts.Close)
+
+	pg := pinger.NewGroup(pinger.NewPinger("127.0.0.1", time.Second))
+	sched := scheduler.New(config.AdaptiveConfig{BurstInterval: 1, BurstDuration: 60}, 5*time.Second)
+	coll := collector.New(ts.URL, &http.Client{Timeout: time.Second}, pg, sched)
+	coll.Poll()
+
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+	srv := NewServer(coll, "test")
+	if err := srv.Listen(path); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go srv.Serve(ctx)
+
+	client, err := Dial(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	// A second instance must not take over a live socket
+	if err := NewServer(coll, "test").Listen(path); err == nil {
+		t.Error("Expected error when socket is in use")
+	}
+	return coll, client, path
+}
+
+func TestStatus(t *testing.T) {
+	_, client, _ := startServer(t)
+
+	var st StatusResult
+	if err := client.Call(MethodStatus, nil, &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Version != "test" || st.Interval != 5 || st.Collector.Polls != 1 {
+		t.Errorf("Unexpected status %+v", st)
+	}
+	if st.Sample == nil || st.Sample.Gateway.Device.Model != "TEST-MODEL" {
+		t.Errorf("Expected current sample in status, got %+v", st.Sample)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	coll, client, _ := startServer(t)
+
+	var st StatusResult
+	if err := client.Call(MethodSetInterval, IntervalParams{Seconds: 10}, &st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Interval != 10 || coll.Scheduler().Base() != 10*time.Second {
+		t.Errorf("Expected interval 10s, got %v", st.Interval)
+	}
+
+	if err := client.Call(MethodBurst, BurstParams{Reason: "test"}, &st); err != nil {
+		t.Fatal(err)
+	}
+	if burst, reason := coll.Scheduler().Burst(time.Now()); !burst || reason != "test" {
+		t.Errorf("Expected burst, got %v %q", burst, reason)
+	}
+
+	if err := client.Call(MethodAnnotate, AnnotateParams{Text: "moved gateway"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if s := coll.Poll(); len(s.Stats.Annotations) != 1 {
+		t.Errorf("Expected annotation on next sample, got %v", s.Stats.Annotations)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	_, client, _ := startServer(t)
+
+	tests := []struct {
+		method string
+		params any
+		code   int
+	}{
+		{"nope", nil, CodeMethodNotFound},
+		{MethodSetInterval, IntervalParams{Seconds: 0}, CodeInvalidParams},
+		{MethodSetInterval, "ten", CodeInvalidParams},
+		{MethodAnnotate, AnnotateParams{}, CodeInvalidParams},
+	}
+	for _, tt := range tests {
+		err := client.Call(tt.method, tt.params, nil)
+		var rpcErr *Error
+		if !errors.As(err, &rpcErr) || rpcErr.Code != tt.code {
+			t.Errorf("%s(%v): expected code %d, got %v", tt.method, tt.params, tt.code, err)
+		}
+	}
+}
+
+func TestAttachStream(t *testing.T) {
+	coll, _, path := startServer(t)
+	src, err := Attach(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	if src.Interval() != 5*time.Second {
+		t.Errorf("Expected interval from status, got %v", src.Interval())
+	}
+
+	// Wait until the server has registered the stream subscriber
+	for i := 0; i < 100 && len(coll.Bus().Stats()) == 0; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	coll.Poll()
+
+	s, ok := src.Next()
+	if !ok || s.Stats == nil || s.Stats.Gateway.Device.Model != "TEST-MODEL" {
+		t.Fatalf("Expected streamed sample, got %+v ok=%v", s, ok)
+	}
+
+	if err := src.SetInterval(7 * time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if src.Interval() != 7*time.Second || coll.Scheduler().Base() != 7*time.Second {
+		t.Errorf("Expected remote interval change to 7s, got %v", coll.Scheduler().Base())
+	}
+
+	// Stopping the collector ends the stream cleanly
+	coll.Bus().Close()
+	if _, ok := src.Next(); ok {
+		t.Error("Expected stream to end when the instance stops")
+	}
+	if src.Err() != nil {
+		t.Errorf("Expected clean end of stream, got %v", src.Err())
+	}
+}
@@ -0,0 +1,115 @@
+package control
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/collector"
+	"tmobile-stats/internal/logger"
+)
+
+// RemoteSource streams samples from a running instance's control socket.
+// It satisfies ui.Source, so the dashboard can attach to a daemon instead
+// of starting a second collector.
+type RemoteSource struct {
+	path   string
+	stream *Client
+
+	mu        sync.Mutex
+	interval  time.Duration
+	scheduler string
+	logs      []logger.SinkStats
+	err       error
+}
+
+// Attach subscribes to the instance listening on path.
+func Attach(path string) (*RemoteSource, error) {
+	stream, err := Dial(path)
+	if err != nil {
+		return nil, err
+	}
+	st, err := stream.Subscribe()
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+	return &RemoteSource{
+		path:      path,
+		stream:    stream,
+		interval:  time.Duration(st.Interval * float64(time.Second)),
+		scheduler: st.Scheduler,
+		logs:      st.Logging,
+	}, nil
+}
+
+func (r *RemoteSource) Next() (collector.Sample, bool) {
+	event, err := r.stream.NextSample()
+	if err != nil {
+		r.mu.Lock()
+		if !errors.Is(err, io.EOF) {
+			r.err = err
+		}
+		r.mu.Unlock()
+		return collector.Sample{}, false
+	}
+
+	r.mu.Lock()
+	r.interval = time.Duration(event.Interval * float64(time.Second))
+	r.scheduler = event.Scheduler
+	r.logs = event.Logging
+	r.mu.Unlock()
+	return event.Sample(), true
+}
+
+func (r *RemoteSource) Interval() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.interval
+}
+
+// SetInterval changes the interval of the remote collector over a separate
+// connection, since the stream connection only carries samples.
+func (r *RemoteSource) SetInterval(d time.Duration) error {
+	client, err := Dial(r.path)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	var st StatusResult
+	if err := client.Call(MethodSetInterval, IntervalParams{Seconds: int(d / time.Second)}, &st); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.interval = time.Duration(st.Interval * float64(time.Second))
+	r.scheduler = st.Scheduler
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *RemoteSource) SchedulerStatus() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.scheduler
+}
+
+func (r *RemoteSource) LogStatus() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return logger.SummarizeSinks(r.logs)
+}
+
+// Err reports why the stream ended, or nil if the instance shut down cleanly.
+func (r *RemoteSource) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Close ends the stream.
+func (r *RemoteSource) Close() error {
+	return r.stream.Close()
+}
@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	sent, err := Notify(Ready)
+	if err != nil || !sent {
+		t.Fatalf("Notify failed: sent=%v err=%v", sent, err)
+	}
+
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(buf[:n]); got != Ready {
+		t.Errorf("Expected %q, got %q", Ready, got)
+	}
+}
+
+func TestNotifyWithoutSystemd(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := Notify(Ready); sent || err != nil {
+		t.Errorf("Expected no-op outside systemd, got sent=%v err=%v", sent, err)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if d, ok := WatchdogInterval(); !ok || d != 30*time.Second {
+		t.Errorf("Expected 30s watchdog, got %v %v", d, ok)
+	}
+
+	t.Setenv("WATCHDOG_PID", "1")
+	if _, ok := WatchdogInterval(); ok {
+		t.Error("Watchdog meant for another pid should be ignored")
+	}
+}
+
+func TestPIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal-sentry.pid")
+	if err := WritePIDFile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.TrimSpace(string(data)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("Unexpected pidfile contents %q", data)
+	}
+	if err := RemovePIDFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected pidfile to be removed")
+	}
+
+	// A pidfile owned by a live process is not overwritten, even one of
+	// another user's (pid 1 always exists and is root's)
+	os.WriteFile(path, []byte("1\n"), 0644)
+	if err := WritePIDFile(path); err == nil && os.Getpid() != 1 {
+		t.Error("Expected error for pidfile held by another process")
+	}
+}
+
+func TestWriteUnit(t *testing.T) {
+	var sb strings.Builder
+	err := WriteUnit(&sb, Unit{
+		ExecStart:        "/usr/local/bin/signal-sentry daemon -config /etc/signal-sentry.json",
+		WorkingDirectory: "/var/lib/signal-sentry",
+		User:             "sentry",
+		WatchdogSec:      60,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unit := sb.String()
+	for _, want := range []string{
+		"Type=notify",
+		"ExecStart=/usr/local/bin/signal-sentry daemon -config /etc/signal-sentry.json",
+		"WorkingDirectory=/var/lib/signal-sentry",
+		"User=sentry",
+		"AmbientCapabilities=CAP_NET_RAW",
+		"WatchdogSec=60",
+		"WantedBy=multi-user.target",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("Unit missing %q:\n%s", want, unit)
+		}
+	}
+	if strings.Contains(unit, "PIDFile=") {
+		t.Error("PIDFile should be omitted when not set")
+	}
+}
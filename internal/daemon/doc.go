@@ -0,0 +1,3 @@
+// Package daemon provides the pieces needed to run unattended under systemd:
+// sd_notify messages, pidfiles and unit file generation.
+package daemon
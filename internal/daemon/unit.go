@@ -0,0 +1,59 @@
+package daemon
+
+import (
+	"io"
+	"text/template"
+)
+
+// DefaultUnitPath is where install-service writes the unit by default.
+const DefaultUnitPath = "/etc/systemd/system/signal-sentry.service"
+
+// Unit describes a systemd service running `signal-sentry daemon`.
+type Unit struct {
+	Description      string
+	ExecStart        string
+	WorkingDirectory string
+	User             string
+	PIDFile          string
+	WatchdogSec      int
+}
+
+const unitTemplate = `[Unit]
+Description={{.Description}}
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=notify
+NotifyAccess=main
+ExecStart={{.ExecStart}}
+{{- if .WorkingDirectory}}
+WorkingDirectory={{.WorkingDirectory}}
+{{- end}}
+{{- if .User}}
+User={{.User}}
+# Unprivileged ICMP needs CAP_NET_RAW
+AmbientCapabilities=CAP_NET_RAW
+{{- end}}
+{{- if .PIDFile}}
+PIDFile={{.PIDFile}}
+{{- end}}
+{{- if .WatchdogSec}}
+WatchdogSec={{.WatchdogSec}}
+{{- end}}
+Restart=on-failure
+RestartSec=10
+KillSignal=SIGTERM
+TimeoutStopSec=30
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// WriteUnit renders the unit file to w.
+func WriteUnit(w io.Writer, u Unit) error {
+	if u.Description == "" {
+		u.Description = "Signal Sentry - T-Mobile Gateway Signal Monitor"
+	}
+	return template.Must(template.New("unit").Parse(unitTemplate)).Execute(w, u)
+}
@@ -0,0 +1,3 @@
+// Package export converts logged samples into formats for other tools,
+// such as Parquet for pandas and DuckDB.
+package export
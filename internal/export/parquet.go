@@ -0,0 +1,195 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/zstd"
+
+	"tmobile-stats/internal/models"
+)
+
+// ParquetRowGroupSize is the default number of samples per row group:
+// about three days at the 5s interval, which keeps a month-long export
+// readable in chunks without making each group tiny.
+const ParquetRowGroupSize = 50000
+
+// parquetRow is one sample flattened into typed columns. Radio columns are
+// null while that radio is not connected, so they don't drag averages
+// towards zero.
+type parquetRow struct {
+	Time            time.Time `parquet:"time,timestamp(millisecond)"`
+	TimeZone        string    `parquet:"timezone,dict"`
+	UptimeSeconds   int64     `parquet:"uptime_seconds"`
+	Manufacturer    string    `parquet:"manufacturer,dict"`
+	Model           string    `parquet:"model,dict"`
+	Serial          string    `parquet:"serial,dict"`
+	MacID           string    `parquet:"mac_id,dict"`
+	HardwareVersion string    `parquet:"hardware_version,dict"`
+	SoftwareVersion string    `parquet:"software_version,dict"`
+	Role            string    `parquet:"role,dict"`
+	APN             string    `parquet:"apn,dict"`
+	HasIPv6         bool      `parquet:"has_ipv6"`
+	Registration    string    `parquet:"registration,dict"`
+
+	NRBand    *string  `parquet:"nr_band,optional,dict"`  // Primary band
+	NRBands   *string  `parquet:"nr_bands,optional,dict"` // All bands, comma-separated
+	NRBars    *float64 `parquet:"nr_bars,optional"`
+	NRRSRP    *int32   `parquet:"nr_rsrp_dbm,optional"`
+	NRRSRQ    *int32   `parquet:"nr_rsrq_db,optional"`
+	NRRSSI    *int32   `parquet:"nr_rssi_dbm,optional"`
+	NRSINR    *int32   `parquet:"nr_sinr_db,optional"`
+	NRCID     *int64   `parquet:"nr_cid,optional"`
+	NRGNBID   *int64   `parquet:"nr_gnb_id,optional"`
+	NRPCI     *int32   `parquet:"nr_pci,optional"`
+	NRAntenna *string  `parquet:"nr_antenna,optional,dict"`
+
+	LTEBand    *string  `parquet:"lte_band,optional,dict"`
+	LTEBands   *string  `parquet:"lte_bands,optional,dict"`
+	LTEBars    *float64 `parquet:"lte_bars,optional"`
+	LTERSRP    *int32   `parquet:"lte_rsrp_dbm,optional"`
+	LTERSRQ    *int32   `parquet:"lte_rsrq_db,optional"`
+	LTERSSI    *int32   `parquet:"lte_rssi_dbm,optional"`
+	LTESINR    *int32   `parquet:"lte_sinr_db,optional"`
+	LTECID     *int64   `parquet:"lte_cid,optional"`
+	LTEENBID   *int64   `parquet:"lte_enb_id,optional"`
+	LTEPCI     *int32   `parquet:"lte_pci,optional"`
+	LTEAntenna *string  `parquet:"lte_antenna,optional,dict"`
+
+	PingTarget    string  `parquet:"ping_target,dict"`
+	PingMinMS     float64 `parquet:"ping_min_ms"`
+	PingAvgMS     float64 `parquet:"ping_avg_ms"`
+	PingMaxMS     float64 `parquet:"ping_max_ms"`
+	PingStdDevMS  float64 `parquet:"ping_stddev_ms"`
+	PingLastRTTMS float64 `parquet:"ping_last_rtt_ms"`
+	PingLossPct   float64 `parquet:"ping_loss_pct"`
+	PingSent      int32   `parquet:"ping_sent"`
+	PingReceived  int32   `parquet:"ping_received"`
+
+	Targets     []parquetTarget `parquet:"targets,list"`
+	Burst       bool            `parquet:"burst"`
+	BurstReason string          `parquet:"burst_reason,dict"`
+	Weight      float64         `parquet:"weight"`
+	Annotations []string        `parquet:"annotations,list"`
+}
+
+// parquetTarget is one additional ping target.
+type parquetTarget struct {
+	Target   string  `parquet:"target,dict"`
+	MinMS    float64 `parquet:"min_ms"`
+	AvgMS    float64 `parquet:"avg_ms"`
+	MaxMS    float64 `parquet:"max_ms"`
+	StdDevMS float64 `parquet:"stddev_ms"`
+	LossPct  float64 `parquet:"loss_pct"`
+	Sent     int32   `parquet:"sent"`
+	Received int32   `parquet:"received"`
+}
+
+func newParquetRow(s *models.CombinedStats) parquetRow {
+	g := &s.Gateway
+	row := parquetRow{
+		Time:            time.Unix(g.Time.LocalTime, 0).UTC(),
+		TimeZone:        g.Time.LocalTimeZone,
+		UptimeSeconds:   int64(g.Time.UpTime),
+		Manufacturer:    g.Device.Manufacturer,
+		Model:           g.Device.Model,
+		Serial:          g.Device.Serial,
+		MacID:           g.Device.MacID,
+		HardwareVersion: g.Device.HardwareVersion,
+		SoftwareVersion: g.Device.SoftwareVersion,
+		Role:            g.Device.Role,
+		APN:             g.Signal.Generic.APN,
+		HasIPv6:         g.Signal.Generic.HasIPv6,
+		Registration:    g.Signal.Generic.Registration,
+
+		PingTarget:    s.Ping.Label(),
+		PingMinMS:     s.Ping.Min,
+		PingAvgMS:     s.Ping.Avg,
+		PingMaxMS:     s.Ping.Max,
+		PingStdDevMS:  s.Ping.StdDev,
+		PingLastRTTMS: s.Ping.LastRTT,
+		PingLossPct:   s.Ping.Loss,
+		PingSent:      int32(s.Ping.Sent),
+		PingReceived:  int32(s.Ping.Received),
+
+		Burst:       s.Burst,
+		BurstReason: s.BurstReason,
+		Weight:      s.SampleWeight(),
+		Annotations: s.Annotations,
+	}
+
+	if c := &g.Signal.FiveG; connected(c) {
+		row.NRBand, row.NRBands = ptr(primaryBand(c)), ptr(strings.Join(c.Bands, ","))
+		row.NRBars = ptr(c.Bars)
+		row.NRRSRP, row.NRRSRQ, row.NRRSSI, row.NRSINR = ptr(int32(c.RSRP)), ptr(int32(c.RSRQ)), ptr(int32(c.RSSI)), ptr(int32(c.SINR))
+		row.NRCID, row.NRGNBID, row.NRPCI = ptr(int64(c.CID)), ptr(int64(c.GNBID)), ptr(int32(c.PCID))
+		row.NRAntenna = ptr(c.AntennaUsed)
+	}
+	if c := &g.Signal.FourG; connected(c) {
+		row.LTEBand, row.LTEBands = ptr(primaryBand(c)), ptr(strings.Join(c.Bands, ","))
+		row.LTEBars = ptr(c.Bars)
+		row.LTERSRP, row.LTERSRQ, row.LTERSSI, row.LTESINR = ptr(int32(c.RSRP)), ptr(int32(c.RSRQ)), ptr(int32(c.RSSI)), ptr(int32(c.SINR))
+		row.LTECID, row.LTEENBID, row.LTEPCI = ptr(int64(c.CID)), ptr(int64(c.EID)), ptr(int32(c.PCID))
+		row.LTEAntenna = ptr(c.AntennaUsed)
+	}
+
+	for _, t := range s.Targets {
+		row.Targets = append(row.Targets, parquetTarget{
+			Target:   t.Label(),
+			MinMS:    t.Min,
+			AvgMS:    t.Avg,
+			MaxMS:    t.Max,
+			StdDevMS: t.StdDev,
+			LossPct:  t.Loss,
+			Sent:     int32(t.Sent),
+			Received: int32(t.Received),
+		})
+	}
+	return row
+}
+
+func connected(c *models.ConnectionStats) bool {
+	return len(c.Bands) > 0 || c.Bars > 0
+}
+
+func primaryBand(c *models.ConnectionStats) string {
+	if len(c.Bands) == 0 {
+		return ""
+	}
+	return c.Bands[0]
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// WriteParquet writes samples to w as a zstd-compressed Parquet file with
+// at most rowGroupSize rows per row group (ParquetRowGroupSize if <= 0).
+func WriteParquet(w io.Writer, samples []models.CombinedStats, rowGroupSize int) error {
+	if rowGroupSize <= 0 {
+		rowGroupSize = ParquetRowGroupSize
+	}
+	pw := parquet.NewGenericWriter[parquetRow](w,
+		parquet.Compression(&zstd.Codec{}),
+		parquet.MaxRowsPerRowGroup(int64(rowGroupSize)),
+	)
+
+	// Convert in batches so a long history isn't held twice in memory
+	batch := make([]parquetRow, 0, min(len(samples), 4096))
+	for i := range samples {
+		batch = append(batch, newParquetRow(&samples[i]))
+		if len(batch) == cap(batch) || i == len(samples)-1 {
+			if _, err := pw.Write(batch); err != nil {
+				return fmt.Errorf("could not write Parquet rows: %w", err)
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := pw.Close(); err != nil {
+		return fmt.Errorf("could not write Parquet file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"tmobile-stats/internal/models"
+)
+
+func TestWriteParquet(t *testing.T) {
+	base := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	samples := make([]models.CombinedStats, 25)
+	for i := range samples {
+		s := &samples[i]
+		s.Gateway.Time.LocalTime = base.Add(time.Duration(i) * 5 * time.Second).Unix()
+		s.Gateway.Device = models.DeviceInfo{Manufacturer: "Arcadyan", Model: "G4AR", MacID: "00:11:22:33:44:55", Role: "gateway"}
+		s.Gateway.Signal.Generic.HasIPv6 = true
+		s.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{"n41", "n71"}, Bars: 4, GNBID: 1234567, PCID: 512, RSRP: -90 - i, SINR: 14}
+		s.Ping = models.PingStats{Avg: 25, Sent: 10, Received: 10, Target: "8.8.8.8"}
+	}
+	samples[3].Targets = []models.PingStats{{Avg: 30, Loss: 10, Sent: 10, Received: 9, Target: "1.1.1.1", Options: &models.PingOptions{Network: "ip6"}}}
+	samples[4].Annotations = []string{"moved gateway"}
+	samples[5].Gateway.Signal.FourG = models.ConnectionStats{Bands: []string{"b2"}, Bars: 3, EID: 98765, RSRP: -101}
+
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, samples, 10); err != nil {
+		t.Fatalf("WriteParquet failed: %v", err)
+	}
+
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Not a readable Parquet file: %v", err)
+	}
+	if n := len(f.RowGroups()); n != 3 {
+		t.Errorf("Expected 3 row groups of at most 10 rows, got %d", n)
+	}
+	for _, col := range []string{"time", "nr_band", "nr_rsrp_dbm", "nr_gnb_id", "lte_enb_id", "ping_avg_ms", "weight", "manufacturer", "mac_id", "hardware_version", "role", "has_ipv6"} {
+		if _, ok := f.Schema().Lookup(col); !ok {
+			t.Errorf("Missing column %q", col)
+		}
+	}
+
+	rows, err := parquet.Read[parquetRow](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(rows) != len(samples) {
+		t.Fatalf("Expected %d rows, got %d", len(samples), len(rows))
+	}
+	r := rows[5]
+	if !r.Time.Equal(base.Add(25*time.Second)) || *r.NRBand != "n41" || *r.NRBands != "n41,n71" || *r.NRRSRP != -95 ||
+		*r.NRGNBID != 1234567 || *r.LTEENBID != 98765 || r.PingAvgMS != 25 || r.Weight != 1 ||
+		r.Manufacturer != "Arcadyan" || r.MacID != "00:11:22:33:44:55" || r.Role != "gateway" || !r.HasIPv6 {
+		t.Errorf("Unexpected row %+v", r)
+	}
+	if rows[0].LTERSRP != nil {
+		t.Errorf("LTE columns should be null while LTE is not connected, got %d", *rows[0].LTERSRP)
+	}
+	if len(rows[3].Targets) != 1 || rows[3].Targets[0].Target != "1.1.1.1 ip6" || rows[3].Targets[0].LossPct != 10 {
+		t.Errorf("Unexpected targets %+v", rows[3].Targets)
+	}
+	if len(rows[4].Annotations) != 1 || rows[4].Annotations[0] != "moved gateway" {
+		t.Errorf("Unexpected annotations %v", rows[4].Annotations)
+	}
+}
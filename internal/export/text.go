@@ -0,0 +1,140 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"tmobile-stats/internal/logger"
+	"tmobile-stats/internal/models"
+)
+
+// Formats lists what Write produces: Parquet, JSON lines, CSV, Influx line
+// protocol and an indented JSON array for reading by eye.
+var Formats = []string{"parquet", "jsonl", "csv", "influx", "json"}
+
+// Ext returns the file extension for format.
+func Ext(format string) string {
+	if format == "influx" {
+		return "lp"
+	}
+	return format
+}
+
+// Options choose what Write includes.
+type Options struct {
+	// Fields are dotted JSON paths to keep, such as "gateway.signal.5g" or
+	// "ping.avg"; gateway.time is always kept. JSON formats hold nothing
+	// else; CSV, Influx and Parquet keep their columns with the rest zero.
+	Fields []string
+	// RowGroupSize is the Parquet row group size; see WriteParquet.
+	RowGroupSize int
+}
+
+// Write writes samples to w in format.
+func Write(w io.Writer, format string, samples []models.CombinedStats, opts Options) error {
+	switch format {
+	case "jsonl", "json":
+		return writeJSON(w, samples, opts.Fields, format == "json")
+	case "parquet", "csv", "influx":
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+
+	if len(opts.Fields) > 0 {
+		projected := make([]models.CombinedStats, len(samples))
+		for i := range samples {
+			p, err := logger.ProjectFields(&samples[i], opts.Fields)
+			if err != nil {
+				return err
+			}
+			projected[i] = *p
+		}
+		samples = projected
+	}
+
+	switch format {
+	case "parquet":
+		return WriteParquet(w, samples, opts.RowGroupSize)
+	case "csv":
+		return logger.WriteCSV(w, samples)
+	default:
+		bw := bufio.NewWriter(w)
+		var buf []byte
+		for i := range samples {
+			buf = logger.AppendInfluxLines(buf[:0], &samples[i])
+			if _, err := bw.Write(buf); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+}
+
+// Downsample keeps the first sample of each interval of every, aligned to
+// UTC, or all of them when every is zero. samples must be in time order.
+func Downsample(samples []models.CombinedStats, every time.Duration) []models.CombinedStats {
+	if every <= 0 {
+		return samples
+	}
+	var out []models.CombinedStats
+	var last time.Time
+	for i := range samples {
+		bucket := time.Unix(samples[i].Gateway.Time.LocalTime, 0).UTC().Truncate(every)
+		if len(out) == 0 || bucket.After(last) {
+			out = append(out, samples[i])
+			last = bucket
+		}
+	}
+	return out
+}
+
+// writeJSON writes one object per line, or an indented array when pretty.
+func writeJSON(w io.Writer, samples []models.CombinedStats, fields []string, pretty bool) error {
+	bw := bufio.NewWriter(w)
+	if pretty {
+		bw.WriteString("[")
+	}
+	for i := range samples {
+		var v any = &samples[i]
+		if len(fields) > 0 {
+			sel, err := logger.SelectFields(&samples[i], fields)
+			if err != nil {
+				return err
+			}
+			v = sel
+		}
+
+		var line []byte
+		var err error
+		if pretty {
+			line, err = json.MarshalIndent(v, "  ", "  ")
+		} else {
+			line, err = json.Marshal(v)
+		}
+		if err != nil {
+			return fmt.Errorf("could not marshal JSON: %w", err)
+		}
+
+		switch {
+		case !pretty:
+		case i == 0:
+			bw.WriteString("\n  ")
+		default:
+			bw.WriteString(",\n  ")
+		}
+		bw.Write(line)
+		if !pretty {
+			bw.WriteString("\n")
+		}
+	}
+	if pretty {
+		if len(samples) > 0 {
+			bw.WriteString("\n")
+		}
+		bw.WriteString("]\n")
+	}
+	return bw.Flush()
+}
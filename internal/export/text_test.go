@@ -0,0 +1,97 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// textSamples are a minute of samples every 5s.
+func textSamples() []models.CombinedStats {
+	base := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	samples := make([]models.CombinedStats, 12)
+	for i := range samples {
+		s := &samples[i]
+		s.Gateway.Time.LocalTime = base.Add(time.Duration(i) * 5 * time.Second).Unix()
+		s.Gateway.Device.Serial = "ABC123"
+		s.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{"n41"}, Bars: 4, GNBID: 1234567, RSRP: -90 - i, SINR: 14}
+		s.Ping = models.PingStats{Avg: 25, Sent: 10, Received: 10, Target: "8.8.8.8"}
+	}
+	return samples
+}
+
+func TestWriteJSON(t *testing.T) {
+	samples := textSamples()
+	var buf bytes.Buffer
+	if err := Write(&buf, "jsonl", samples, Options{Fields: []string{"gateway.signal.5g.rsrp", "ping.avg"}}); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(samples) {
+		t.Fatalf("Expected %d lines, got %d", len(samples), len(lines))
+	}
+	want := `{"gateway":{"signal":{"5g":{"rsrp":-90}},"time":{"localTime":1767614400,"localTimeZone":"","upTime":0}},"ping":{"avg":25}}`
+	if lines[0] != want {
+		t.Errorf("First line = %s\nwant %s", lines[0], want)
+	}
+
+	buf.Reset()
+	if err := Write(&buf, "json", samples, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []models.CombinedStats
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Pretty output is not a JSON array: %v", err)
+	}
+	if len(decoded) != len(samples) || decoded[3].Gateway.Signal.FiveG.RSRP != -93 {
+		t.Errorf("Pretty output decoded to %d samples, want %d", len(decoded), len(samples))
+	}
+
+	buf.Reset()
+	if err := Write(&buf, "json", nil, Options{}); err != nil || buf.String() != "[]\n" {
+		t.Errorf("Empty pretty output = %q, %v; want []", buf.String(), err)
+	}
+}
+
+func TestWriteCSVAndInflux(t *testing.T) {
+	samples := textSamples()
+	var buf bytes.Buffer
+	if err := Write(&buf, "csv", samples, Options{Fields: []string{"gateway.signal.5g"}}); err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(rows) != len(samples)+1 || !strings.HasPrefix(rows[0], "Version,Timestamp,") {
+		t.Fatalf("Expected a header and %d rows, got %d lines starting %q", len(samples), len(rows), rows[0])
+	}
+	if strings.Contains(buf.String(), "ABC123") {
+		t.Error("CSV kept the serial number, which was not selected")
+	}
+
+	buf.Reset()
+	if err := Write(&buf, "influx", samples, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "rsrp=-90i") {
+		t.Errorf("Influx output lacks the first RSRP:\n%s", buf.String())
+	}
+
+	if err := Write(&buf, "xml", samples, Options{}); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	samples := textSamples()
+	// The minute starts at the first sample, so 30s keeps samples 0 and 6
+	got := Downsample(samples, 30*time.Second)
+	if len(got) != 2 || got[1].Gateway.Time.LocalTime != samples[6].Gateway.Time.LocalTime {
+		t.Errorf("Downsample(30s) kept %d samples, want 2 starting each half minute", len(got))
+	}
+	if got := Downsample(samples, 0); len(got) != len(samples) {
+		t.Errorf("Downsample(0) kept %d of %d samples", len(got), len(samples))
+	}
+}
@@ -1,75 +1,329 @@
 package logger
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 	"tmobile-stats/internal/models"
 )
 
+// CSVVersion is the schema version written in the Version column. Version 1
+// logs have no Version column and only the band, RSRP, SINR, bars and ping
+// summary columns; their Timestamp is the logger's clock rather than the
+// gateway's.
+const CSVVersion = 2
+
 type CSVLogger struct {
-	file   *os.File
+	file   *rotatingFile
+	buf    bytes.Buffer // Rows are encoded here, then written in one go
 	writer *csv.Writer
 }
 
 func NewCSVLogger(filename string) (*CSVLogger, error) {
-	fileInfo, err := os.Stat(filename)
-	isNew := os.IsNotExist(err) || (err == nil && fileInfo.Size() == 0)
+	return NewRotatingCSVLogger(filename, Rotation{})
+}
 
-	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+// NewRotatingCSVLogger opens filename for appending and rotates it according
+// to rot. Every new file, including each rotated one, starts with the header.
+// Appending to a log written with another schema first repeats the header,
+// which CSVReader picks up mid-file.
+func NewRotatingCSVLogger(filename string, rot Rotation) (*CSVLogger, error) {
+	f, err := openRotatingFile(filename, rot, writeCSVHeader)
 	if err != nil {
-		return nil, fmt.Errorf("could not open log file: %w", err)
+		return nil, err
 	}
+	// Checked once open has repaired a torn last line
+	if csvHeaderStale(filename) {
+		if err := writeCSVHeader(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	l := &CSVLogger{file: f}
+	l.writer = csv.NewWriter(&l.buf)
+	return l, nil
+}
 
-	writer := csv.NewWriter(f)
-	l := &CSVLogger{file: f, writer: writer}
+// csvHeaderStale reports whether filename exists and its rows are being
+// written under a header other than the current one.
+func csvHeaderStale(filename string) bool {
+	f, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
 
-	if isNew {
-		header := []string{
-			"Timestamp",
-			"5G_Band", "5G_RSRP", "5G_SINR", "5G_Bars",
-			"4G_Band", "4G_RSRP", "4G_SINR", "4G_Bars",
-			"Ping_Min", "Ping_Avg", "Ping_Max", "Ping_StdDev", "Ping_Loss",
-		}
-		if err := writer.Write(header); err != nil {
-			return nil, fmt.Errorf("could not write CSV header: %w", err)
+	// The last header in the file applies to the rows appended after it
+	last := ""
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Version,") || strings.HasPrefix(line, "Timestamp,") {
+			last = line
 		}
-		writer.Flush()
 	}
+	return last != "" && last != strings.Join(csvHeader(), ",")
+}
 
-	return l, nil
+func writeCSVHeader(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader()); err != nil {
+		return fmt.Errorf("could not write CSV header: %w", err)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvHeader() []string {
+	header := make([]string, len(csvColumns))
+	for i, c := range csvColumns {
+		header[i] = c.name
+	}
+	return header
 }
 
 func (l *CSVLogger) Log(data *models.CombinedStats) error {
-	row := []string{
-		time.Now().Format(time.RFC3339),
-		strings.Join(data.Gateway.Signal.FiveG.Bands, ","),
-		strconv.Itoa(data.Gateway.Signal.FiveG.RSRP),
-		strconv.Itoa(data.Gateway.Signal.FiveG.SINR),
-		strconv.FormatFloat(data.Gateway.Signal.FiveG.Bars, 'f', 1, 64),
-		strings.Join(data.Gateway.Signal.FourG.Bands, ","),
-		strconv.Itoa(data.Gateway.Signal.FourG.RSRP),
-		strconv.Itoa(data.Gateway.Signal.FourG.SINR),
-		strconv.FormatFloat(data.Gateway.Signal.FourG.Bars, 'f', 1, 64),
-		strconv.FormatFloat(data.Ping.Min, 'f', 2, 64),
-		strconv.FormatFloat(data.Ping.Avg, 'f', 2, 64),
-		strconv.FormatFloat(data.Ping.Max, 'f', 2, 64),
-		strconv.FormatFloat(data.Ping.StdDev, 'f', 2, 64),
-		strconv.FormatFloat(data.Ping.Loss, 'f', 1, 64),
+	row := make([]string, len(csvColumns))
+	for i, c := range csvColumns {
+		row[i] = c.get(data)
 	}
 
 	if err := l.writer.Write(row); err != nil {
+		l.writer.Flush()
+		l.buf.Reset()
 		return fmt.Errorf("could not write CSV row: %w", err)
 	}
+	return l.flush()
+}
+
+// flush writes the encoded rows to the file with a single write.
+func (l *CSVLogger) flush() error {
 	l.writer.Flush()
+	defer l.buf.Reset()
+	if err := l.writer.Error(); err != nil {
+		return fmt.Errorf("could not write CSV row: %w", err)
+	}
+	if _, err := l.file.Write(l.buf.Bytes()); err != nil {
+		return fmt.Errorf("could not write CSV row: %w", err)
+	}
 	return nil
 }
 
+// LogBatch writes the rows and flushes once.
+func (l *CSVLogger) LogBatch(data []*models.CombinedStats) error {
+	row := make([]string, len(csvColumns))
+	for _, d := range data {
+		for i, c := range csvColumns {
+			row[i] = c.get(d)
+		}
+		if err := l.writer.Write(row); err != nil {
+			l.writer.Flush()
+			l.buf.Reset()
+			return fmt.Errorf("could not write CSV row: %w", err)
+		}
+	}
+	return l.flush()
+}
+
+// WriteCSV writes the header and one row per sample to w, in the same
+// schema as CSVLogger.
+func WriteCSV(w io.Writer, data []models.CombinedStats) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader()); err != nil {
+		return fmt.Errorf("could not write CSV header: %w", err)
+	}
+	row := make([]string, len(csvColumns))
+	for i := range data {
+		for j, c := range csvColumns {
+			row[j] = c.get(&data[i])
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("could not write CSV row: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Sync commits the log file to stable storage.
+func (l *CSVLogger) Sync() error {
+	return l.file.Sync()
+}
 
 func (l *CSVLogger) Close() error {
-	l.writer.Flush()
 	return l.file.Close()
 }
+
+// csvColumn maps one CSV column to a sample field.
+type csvColumn struct {
+	name string
+	get  func(s *models.CombinedStats) string
+	set  func(s *models.CombinedStats, v string) error
+}
+
+// csvColumns is the current schema, one column per modelled field. Ping
+// options, the extra targets and annotations are JSON, as they nest.
+var csvColumns = slices.Concat(
+	[]csvColumn{
+		{"Version", func(*models.CombinedStats) string { return strconv.Itoa(CSVVersion) }, func(*models.CombinedStats, string) error { return nil }},
+		{"Timestamp", csvTimestamp, setCSVTimestamp},
+		strColumn("TimeZone", func(s *models.CombinedStats) *string { return &s.Gateway.Time.LocalTimeZone }),
+		intColumn("Uptime", func(s *models.CombinedStats) *int { return &s.Gateway.Time.UpTime }),
+
+		strColumn("Model", func(s *models.CombinedStats) *string { return &s.Gateway.Device.Model }),
+		strColumn("Manufacturer", func(s *models.CombinedStats) *string { return &s.Gateway.Device.Manufacturer }),
+		strColumn("Serial", func(s *models.CombinedStats) *string { return &s.Gateway.Device.Serial }),
+		strColumn("MacID", func(s *models.CombinedStats) *string { return &s.Gateway.Device.MacID }),
+		strColumn("Role", func(s *models.CombinedStats) *string { return &s.Gateway.Device.Role }),
+		strColumn("HardwareVersion", func(s *models.CombinedStats) *string { return &s.Gateway.Device.HardwareVersion }),
+		strColumn("SoftwareVersion", func(s *models.CombinedStats) *string { return &s.Gateway.Device.SoftwareVersion }),
+
+		strColumn("APN", func(s *models.CombinedStats) *string { return &s.Gateway.Signal.Generic.APN }),
+		strColumn("Registration", func(s *models.CombinedStats) *string { return &s.Gateway.Signal.Generic.Registration }),
+		boolColumn("HasIPv6", func(s *models.CombinedStats) *bool { return &s.Gateway.Signal.Generic.HasIPv6 }),
+	},
+	radioColumns("5G_", func(s *models.CombinedStats) *models.ConnectionStats { return &s.Gateway.Signal.FiveG }),
+	radioColumns("4G_", func(s *models.CombinedStats) *models.ConnectionStats { return &s.Gateway.Signal.FourG }),
+	[]csvColumn{
+		strColumn("Ping_Target", func(s *models.CombinedStats) *string { return &s.Ping.Target }),
+		jsonColumn("Ping_Options", func(s *models.CombinedStats) any { return &s.Ping.Options }),
+		floatColumn("Ping_Min", func(s *models.CombinedStats) *float64 { return &s.Ping.Min }),
+		floatColumn("Ping_Avg", func(s *models.CombinedStats) *float64 { return &s.Ping.Avg }),
+		floatColumn("Ping_Max", func(s *models.CombinedStats) *float64 { return &s.Ping.Max }),
+		floatColumn("Ping_StdDev", func(s *models.CombinedStats) *float64 { return &s.Ping.StdDev }),
+		floatColumn("Ping_Loss", func(s *models.CombinedStats) *float64 { return &s.Ping.Loss }),
+		floatColumn("Ping_LastRTT", func(s *models.CombinedStats) *float64 { return &s.Ping.LastRTT }),
+		intColumn("Ping_Sent", func(s *models.CombinedStats) *int { return &s.Ping.Sent }),
+		intColumn("Ping_Received", func(s *models.CombinedStats) *int { return &s.Ping.Received }),
+		jsonColumn("Targets", func(s *models.CombinedStats) any { return &s.Targets }),
+
+		boolColumn("Burst", func(s *models.CombinedStats) *bool { return &s.Burst }),
+		strColumn("Burst_Reason", func(s *models.CombinedStats) *string { return &s.BurstReason }),
+		floatColumn("Weight", func(s *models.CombinedStats) *float64 { return &s.Weight }),
+		jsonColumn("Annotations", func(s *models.CombinedStats) any { return &s.Annotations }),
+	},
+)
+
+// radioColumns returns the columns of one radio, named with prefix.
+func radioColumns(prefix string, radio func(s *models.CombinedStats) *models.ConnectionStats) []csvColumn {
+	return []csvColumn{
+		{prefix + "Band",
+			func(s *models.CombinedStats) string { return strings.Join(radio(s).Bands, ",") },
+			func(s *models.CombinedStats, v string) error {
+				if v != "" {
+					radio(s).Bands = strings.Split(v, ",")
+				}
+				return nil
+			},
+		},
+		floatColumn(prefix+"Bars", func(s *models.CombinedStats) *float64 { return &radio(s).Bars }),
+		intColumn(prefix+"RSRP", func(s *models.CombinedStats) *int { return &radio(s).RSRP }),
+		intColumn(prefix+"RSRQ", func(s *models.CombinedStats) *int { return &radio(s).RSRQ }),
+		intColumn(prefix+"RSSI", func(s *models.CombinedStats) *int { return &radio(s).RSSI }),
+		intColumn(prefix+"SINR", func(s *models.CombinedStats) *int { return &radio(s).SINR }),
+		intColumn(prefix+"CID", func(s *models.CombinedStats) *int { return &radio(s).CID }),
+		intColumn(prefix+"GNBID", func(s *models.CombinedStats) *int { return &radio(s).GNBID }),
+		intColumn(prefix+"EID", func(s *models.CombinedStats) *int { return &radio(s).EID }),
+		intColumn(prefix+"PCID", func(s *models.CombinedStats) *int { return &radio(s).PCID }),
+		strColumn(prefix+"Antenna", func(s *models.CombinedStats) *string { return &radio(s).AntennaUsed }),
+	}
+}
+
+// csvTimestamp is the gateway time, or the current time if the gateway
+// didn't report one.
+func csvTimestamp(s *models.CombinedStats) string {
+	t := time.Now()
+	if s.Gateway.Time.LocalTime != 0 {
+		t = time.Unix(s.Gateway.Time.LocalTime, 0)
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func setCSVTimestamp(s *models.CombinedStats, v string) error {
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return err
+	}
+	s.Gateway.Time.LocalTime = t.Unix()
+	return nil
+}
+
+func strColumn(name string, field func(s *models.CombinedStats) *string) csvColumn {
+	return csvColumn{name,
+		func(s *models.CombinedStats) string { return *field(s) },
+		func(s *models.CombinedStats, v string) error { *field(s) = v; return nil },
+	}
+}
+
+// Empty numeric and boolean cells read as zero.
+
+func intColumn(name string, field func(s *models.CombinedStats) *int) csvColumn {
+	return csvColumn{name,
+		func(s *models.CombinedStats) string { return strconv.Itoa(*field(s)) },
+		func(s *models.CombinedStats, v string) error {
+			if v == "" {
+				return nil
+			}
+			n, err := strconv.Atoi(v)
+			*field(s) = n
+			return err
+		},
+	}
+}
+
+func floatColumn(name string, field func(s *models.CombinedStats) *float64) csvColumn {
+	return csvColumn{name,
+		func(s *models.CombinedStats) string { return strconv.FormatFloat(*field(s), 'f', -1, 64) },
+		func(s *models.CombinedStats, v string) error {
+			if v == "" {
+				return nil
+			}
+			f, err := strconv.ParseFloat(v, 64)
+			*field(s) = f
+			return err
+		},
+	}
+}
+
+func boolColumn(name string, field func(s *models.CombinedStats) *bool) csvColumn {
+	return csvColumn{name,
+		func(s *models.CombinedStats) string { return strconv.FormatBool(*field(s)) },
+		func(s *models.CombinedStats, v string) error {
+			if v == "" {
+				return nil
+			}
+			b, err := strconv.ParseBool(v)
+			*field(s) = b
+			return err
+		},
+	}
+}
+
+// jsonColumn holds a pointer, slice or other nested field as JSON; nil and
+// empty values are left blank. field returns a pointer to the field.
+func jsonColumn(name string, field func(s *models.CombinedStats) any) csvColumn {
+	return csvColumn{name,
+		func(s *models.CombinedStats) string {
+			b, err := json.Marshal(field(s))
+			if err != nil || string(b) == "null" || string(b) == "[]" {
+				return ""
+			}
+			return string(b)
+		},
+		func(s *models.CombinedStats, v string) error {
+			if v == "" {
+				return nil
+			}
+			return json.Unmarshal([]byte(v), field(s))
+		},
+	}
+}
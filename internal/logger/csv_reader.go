@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"tmobile-stats/internal/models"
+)
+
+// CSVReader decodes samples from CSV logs of any schema version. Columns
+// are matched by header name, so older logs fill the fields they have and
+// unknown columns are ignored. A header row may appear anywhere, as when
+// rotated files are read as one stream or a log was appended to after an
+// upgrade.
+type CSVReader struct {
+	// OnReject, if set, is called with the line number of every row Read
+	// skips: corrupt for rows that don't parse or decode, otherwise rows
+	// that come before any header.
+	OnReject func(line int, corrupt bool)
+
+	r       *csv.Reader
+	cols    []func(s *models.CombinedStats, v string) error // By position; nil skips
+	skipped int
+}
+
+// NewCSVReader reads CSV rows from r.
+func NewCSVReader(r io.Reader) *CSVReader {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // Versions differ in width
+	cr.ReuseRecord = true
+	return &CSVReader{r: cr}
+}
+
+// IsCSVHeader reports whether record is a header row written by CSVLogger.
+func IsCSVHeader(record []string) bool {
+	return len(record) > 0 && (record[0] == "Version" || record[0] == "Timestamp")
+}
+
+// Read returns the next sample, or io.EOF. Rows that can't be decoded (or
+// that come before any header) are skipped and counted in Skipped.
+func (r *CSVReader) Read() (*models.CombinedStats, error) {
+	for {
+		record, err := r.r.Read()
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			r.reject(parseErr.StartLine, true)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if IsCSVHeader(record) {
+			r.setHeader(record)
+			continue
+		}
+		line, _ := r.r.FieldPos(0)
+		if r.cols == nil {
+			r.reject(line, false)
+			continue
+		}
+		s, err := r.decode(record)
+		if err != nil {
+			r.reject(line, true)
+			continue
+		}
+		return s, nil
+	}
+}
+
+func (r *CSVReader) reject(line int, corrupt bool) {
+	r.skipped++
+	if r.OnReject != nil {
+		r.OnReject(line, corrupt)
+	}
+}
+
+// Skipped returns the number of rows that could not be decoded so far.
+func (r *CSVReader) Skipped() int {
+	return r.skipped
+}
+
+func (r *CSVReader) setHeader(header []string) {
+	byName := make(map[string]csvColumn, len(csvColumns))
+	for _, c := range csvColumns {
+		byName[c.name] = c
+	}
+	r.cols = make([]func(*models.CombinedStats, string) error, len(header))
+	for i, name := range header {
+		if c, ok := byName[name]; ok {
+			r.cols[i] = c.set
+		}
+	}
+}
+
+func (r *CSVReader) decode(record []string) (*models.CombinedStats, error) {
+	if len(record) != len(r.cols) {
+		return nil, fmt.Errorf("row has %d fields, header %d", len(record), len(r.cols))
+	}
+	s := &models.CombinedStats{}
+	for i, v := range record {
+		if r.cols[i] == nil {
+			continue
+		}
+		if err := r.cols[i](s, v); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
@@ -1,7 +1,10 @@
 package logger
 
 import (
+	"io"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"tmobile-stats/internal/models"
@@ -50,3 +53,107 @@ func TestCSVLogger(t *testing.T) {
 	}
 }
 
+
+func fullSample() *models.CombinedStats {
+	s := &models.CombinedStats{
+		Ping: models.PingStats{Min: 10.5, Avg: 12.25, Max: 15, StdDev: 1.5, Loss: 10, LastRTT: 11, Sent: 10, Received: 9,
+			Target: "8.8.8.8", Options: &models.PingOptions{Network: "ip4", TOS: 184}},
+		Targets:     []models.PingStats{{Avg: 30, Sent: 10, Received: 10, Target: "2001:4860:4860::8888", Options: &models.PingOptions{Network: "ip6"}}},
+		Burst:       true,
+		BurstReason: "loss 10.0%",
+		Weight:      0.2,
+		Annotations: []string{"moved gateway, upstairs"},
+	}
+	s.Gateway.Device = models.DeviceInfo{HardwareVersion: "R01", MacID: "aa:bb:cc", Manufacturer: "Arcadyan", Model: "G4AR", Role: "gateway", Serial: "ABC123", SoftwareVersion: "1.2.3"}
+	s.Gateway.Signal.FiveG = models.ConnectionStats{AntennaUsed: "Internal", Bands: []string{"n41", "n71"}, Bars: 4.5, CID: 3, GNBID: 1234567, PCID: 512, RSRP: -92, RSRQ: -11, RSSI: -80, SINR: 14}
+	s.Gateway.Signal.FourG = models.ConnectionStats{Bands: []string{"b2"}, Bars: 3, CID: 7, EID: 98765, PCID: 101, RSRP: -100, RSRQ: -12, RSSI: -75, SINR: 5}
+	s.Gateway.Signal.Generic = models.GenericInfo{APN: "fbb.home", HasIPv6: true, Registration: "registered"}
+	s.Gateway.Time = models.TimeInfo{LocalTime: 1767614400, LocalTimeZone: "America/Chicago", UpTime: 3600}
+	return s
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal.csv")
+	l, err := NewCSVLogger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := fullSample()
+	l.Log(want)
+	l.Log(&models.CombinedStats{Gateway: models.GatewayResponse{Time: models.TimeInfo{LocalTime: 1767614405}}})
+	l.Close()
+
+	f, _ := os.Open(path)
+	defer f.Close()
+	r := NewCSVReader(f)
+	got, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", got, want)
+	}
+	empty, err := r.Read()
+	if err != nil || empty.Gateway.Time.LocalTime != 1767614405 || empty.Gateway.Signal.FiveG.Bands != nil || empty.Ping.Options != nil {
+		t.Errorf("Unexpected empty sample %+v (%v)", empty, err)
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+}
+
+func TestCSVReaderVersion1(t *testing.T) {
+	v1 := "Timestamp,5G_Band,5G_RSRP,5G_SINR,5G_Bars,4G_Band,4G_RSRP,4G_SINR,4G_Bars,Ping_Min,Ping_Avg,Ping_Max,Ping_StdDev,Ping_Loss\n" +
+		"2026-01-05T12:00:00-06:00,\"n41,n71\",-90,15,4.0,b2,-100,5,3.0,10.50,12.00,15.00,1.50,0.0\n" +
+		"garbage,row\n" +
+		"2026-01-05T12:00:05-06:00,n41,not-a-number,15,4.0,,0,0,0.0,10.50,12.00,15.00,1.50,0.0\n"
+	r := NewCSVReader(strings.NewReader(v1))
+	s, err := r.Read()
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if s.Gateway.Time.LocalTime != 1767636000 || s.Gateway.Signal.FiveG.RSRP != -90 || len(s.Gateway.Signal.FiveG.Bands) != 2 ||
+		s.Gateway.Signal.FourG.SINR != 5 || s.Ping.Avg != 12 {
+		t.Errorf("Unexpected v1 sample %+v", s)
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Expected EOF, got %v", err)
+	}
+	if r.Skipped() != 2 {
+		t.Errorf("Expected 2 skipped rows, got %d", r.Skipped())
+	}
+}
+
+func TestCSVLoggerUpgradesHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal.csv")
+	v1 := "Timestamp,5G_Band,5G_RSRP,5G_SINR,5G_Bars,4G_Band,4G_RSRP,4G_SINR,4G_Bars,Ping_Min,Ping_Avg,Ping_Max,Ping_StdDev,Ping_Loss\n" +
+		"2026-01-05T12:00:00-06:00,n41,-90,15,4.0,,0,0,0.0,10.50,12.00,15.00,1.50,0.0\n"
+	os.WriteFile(path, []byte(v1), 0644)
+
+	for i := 0; i < 2; i++ {
+		// The second open finds the current header already in place
+		l, err := NewCSVLogger(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Log(fullSample())
+		l.Close()
+	}
+
+	content, _ := os.ReadFile(path)
+	if n := strings.Count("\n"+string(content), "\nVersion,"); n != 1 {
+		t.Errorf("Expected the current header once, got %d", n)
+	}
+	r := NewCSVReader(strings.NewReader(string(content)))
+	var rsrp []int
+	for {
+		s, err := r.Read()
+		if err != nil {
+			break
+		}
+		rsrp = append(rsrp, s.Gateway.Signal.FiveG.RSRP)
+	}
+	if !reflect.DeepEqual(rsrp, []int{-90, -92, -92}) || r.Skipped() != 0 {
+		t.Errorf("Expected the v1 row and two v2 rows, got %v (%d skipped)", rsrp, r.Skipped())
+	}
+}
@@ -0,0 +1,317 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// BatchLogger is implemented by loggers that can write several samples at
+// once more cheaply than one by one, e.g. with a single flush.
+type BatchLogger interface {
+	LogBatch(data []*models.CombinedStats) error
+}
+
+// Syncer is implemented by loggers writing to a file that can be forced to
+// stable storage.
+type Syncer interface {
+	Sync() error
+}
+
+// SyncPolicy says when Fanout forces file sinks to disk.
+type SyncPolicy int
+
+const (
+	SyncNever    SyncPolicy = iota // Leave it to the OS; Close still syncs
+	SyncBatch                      // After every batch written
+	SyncInterval                   // At most once per FanoutOptions.SyncInterval
+)
+
+// FanoutOptions tune the queue of every sink.
+type FanoutOptions struct {
+	QueueSize    int // Entries kept per sink before the oldest are dropped (default 256)
+	BatchSize    int // Most samples written per batch (default 32)
+	Sync         SyncPolicy
+	SyncInterval time.Duration // For SyncInterval (default 30s)
+}
+
+// SinkStats reports the counters of one sink. Dropped counts samples
+// discarded unwritten because the sink fell behind; Errors counts failed
+// writes.
+type SinkStats struct {
+	Name      string `json:"name"`
+	Written   uint64 `json:"written"`
+	Dropped   uint64 `json:"dropped"`
+	Errors    uint64 `json:"errors"`
+	Queued    int    `json:"queued"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Fanout hands each sample to every sink through its own bounded queue and
+// goroutine, so a slow sink (a network write, a slow SD card) only ever
+// loses its own oldest samples and never holds up the caller or the other
+// sinks. It is itself a Logger, and a GatewayObserver for sinks that are.
+type Fanout struct {
+	opts  FanoutOptions
+	onErr func(error)
+	sinks []*sink
+}
+
+// fanoutEntry is a sample, or the error of a failed poll for observers; they
+// share the queue so a sink sees both in order.
+type fanoutEntry struct {
+	data *models.CombinedStats
+	err  error
+}
+
+type sink struct {
+	name string
+	l    Logger
+
+	mu     sync.Mutex
+	queue  []fanoutEntry
+	closed bool
+	stats  SinkStats
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+// NewFanout creates an empty fan-out; add sinks with Add. onErr, if not nil,
+// is called from the sink goroutines with every write error.
+func NewFanout(opts FanoutOptions, onErr func(error)) *Fanout {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 256
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 32
+	}
+	if opts.SyncInterval <= 0 {
+		opts.SyncInterval = 30 * time.Second
+	}
+	return &Fanout{opts: opts, onErr: onErr}
+}
+
+// Add starts a goroutine writing to l. name identifies it in Stats and in
+// errors. Sinks must be added before the first Log.
+func (f *Fanout) Add(name string, l Logger) {
+	s := &sink{
+		name:  name,
+		l:     l,
+		stats: SinkStats{Name: name},
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	f.sinks = append(f.sinks, s)
+	go f.run(s)
+}
+
+// Log queues data for every sink and returns at once. Write errors are
+// reported through onErr and Stats, as they happen later.
+func (f *Fanout) Log(data *models.CombinedStats) error {
+	for _, s := range f.sinks {
+		s.push(fanoutEntry{data: data}, f.opts.QueueSize)
+	}
+	return nil
+}
+
+// GatewayError queues err for the sinks that observe failed polls.
+func (f *Fanout) GatewayError(err error) error {
+	for _, s := range f.sinks {
+		if _, ok := s.l.(GatewayObserver); ok {
+			s.push(fanoutEntry{err: err}, f.opts.QueueSize)
+		}
+	}
+	return nil
+}
+
+func (s *sink) push(e fanoutEntry, limit int) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	if len(s.queue) >= limit {
+		// Drop the oldest to keep the latest, like the collector bus does
+		if s.queue[0].data != nil {
+			s.stats.Dropped++
+		}
+		s.queue = s.queue[1:]
+	}
+	s.queue = append(s.queue, e)
+	s.mu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stats returns the counters of every sink, in the order they were added.
+func (f *Fanout) Stats() []SinkStats {
+	stats := make([]SinkStats, len(f.sinks))
+	for i, s := range f.sinks {
+		s.mu.Lock()
+		stats[i] = s.stats
+		stats[i].Queued = len(s.queue)
+		s.mu.Unlock()
+	}
+	return stats
+}
+
+func (f *Fanout) run(s *sink) {
+	defer close(s.done)
+
+	var lastSync time.Time
+	for {
+		s.mu.Lock()
+		n := min(len(s.queue), f.opts.BatchSize)
+		batch := s.queue[:n:n]
+		s.queue = s.queue[n:]
+		closed := s.closed
+		s.mu.Unlock()
+
+		if n == 0 {
+			if closed {
+				return
+			}
+			<-s.wake
+			continue
+		}
+
+		f.write(s, batch)
+		if f.opts.Sync == SyncBatch || (f.opts.Sync == SyncInterval && time.Since(lastSync) >= f.opts.SyncInterval) {
+			if syncer, ok := s.l.(Syncer); ok {
+				if err := syncer.Sync(); err != nil {
+					f.fail(s, err, 0)
+				}
+			}
+			lastSync = time.Now()
+		}
+	}
+}
+
+// write hands a batch to the sink, using LogBatch for runs of samples when
+// the sink supports it.
+func (f *Fanout) write(s *sink, batch []fanoutEntry) {
+	bl, batched := s.l.(BatchLogger)
+	for len(batch) > 0 {
+		if batch[0].data == nil {
+			if err := s.l.(GatewayObserver).GatewayError(batch[0].err); err != nil {
+				f.fail(s, err, 0)
+			}
+			batch = batch[1:]
+			continue
+		}
+
+		// The run of samples up to the next poll error
+		n := 1
+		for n < len(batch) && batch[n].data != nil {
+			n++
+		}
+		run := batch[:n]
+		batch = batch[n:]
+
+		if !batched || n == 1 {
+			for _, e := range run {
+				if err := s.l.Log(e.data); err != nil {
+					f.fail(s, err, 1)
+				} else {
+					s.count(1)
+				}
+			}
+			continue
+		}
+		data := make([]*models.CombinedStats, n)
+		for i, e := range run {
+			data[i] = e.data
+		}
+		if err := bl.LogBatch(data); err != nil {
+			f.fail(s, err, uint64(n))
+		} else {
+			s.count(uint64(n))
+		}
+	}
+}
+
+func (s *sink) count(n uint64) {
+	s.mu.Lock()
+	s.stats.Written += n
+	s.mu.Unlock()
+}
+
+// fail records an error that lost samples (zero for a failed sync or
+// availability update, which still counts as an error).
+func (f *Fanout) fail(s *sink, err error, samples uint64) {
+	err = fmt.Errorf("%s: %w", s.name, err)
+	s.mu.Lock()
+	s.stats.Errors += max(samples, 1)
+	s.stats.LastError = err.Error()
+	s.mu.Unlock()
+	if f.onErr != nil {
+		f.onErr(err)
+	}
+}
+
+// Close writes everything still queued, then closes every sink.
+func (f *Fanout) Close() error {
+	for _, s := range f.sinks {
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	var errs []error
+	for _, s := range f.sinks {
+		<-s.done
+		if err := s.l.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// String summarises the counters, e.g. "influxdb 118 written, 2 dropped".
+func (st SinkStats) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %d written", st.Name, st.Written)
+	if st.Dropped > 0 {
+		fmt.Fprintf(&b, ", %d dropped", st.Dropped)
+	}
+	if st.Errors > 0 {
+		fmt.Fprintf(&b, ", %d errors", st.Errors)
+	}
+	return b.String()
+}
+
+// SummarizeSinks totals the counters of every sink for a one-line status,
+// e.g. "240 written, 3 dropped, 2 errors (influxdb: write failed)". It is
+// empty when there are no sinks.
+func SummarizeSinks(stats []SinkStats) string {
+	if len(stats) == 0 {
+		return ""
+	}
+	var total SinkStats
+	for _, st := range stats {
+		total.Written += st.Written
+		total.Dropped += st.Dropped
+		total.Errors += st.Errors
+		if st.LastError != "" {
+			total.LastError = st.LastError
+		}
+	}
+
+	s := fmt.Sprintf("%d written, %d dropped, %d errors", total.Written, total.Dropped, total.Errors)
+	if total.LastError != "" {
+		s += " (" + total.LastError + ")"
+	}
+	return s
+}
@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// recordingLogger records what it is given; gate, if set, blocks every
+// write until it is closed.
+type recordingLogger struct {
+	gate chan struct{}
+	fail error
+
+	mu      sync.Mutex
+	samples []int // Uptime of each sample, to identify it
+	events  []string
+	batches int
+	syncs   int
+	closed  bool
+}
+
+func (r *recordingLogger) wait() {
+	if r.gate != nil {
+		<-r.gate
+	}
+}
+
+func (r *recordingLogger) Log(data *models.CombinedStats) error {
+	r.wait()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.samples = append(r.samples, data.Gateway.Time.UpTime)
+	r.events = append(r.events, "sample")
+	return r.fail
+}
+
+func (r *recordingLogger) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
+
+// batchLogger also implements BatchLogger, Syncer and GatewayObserver.
+type batchLogger struct {
+	recordingLogger
+}
+
+func (b *batchLogger) LogBatch(data []*models.CombinedStats) error {
+	b.wait()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batches++
+	for _, d := range data {
+		b.samples = append(b.samples, d.Gateway.Time.UpTime)
+		b.events = append(b.events, "sample")
+	}
+	return b.fail
+}
+
+func (b *batchLogger) Sync() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.syncs++
+	return nil
+}
+
+func (b *batchLogger) GatewayError(err error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, "error")
+	return nil
+}
+
+func sample(n int) *models.CombinedStats {
+	s := &models.CombinedStats{}
+	s.Gateway.Time.UpTime = n
+	return s
+}
+
+func TestFanoutSlowSinkDoesNotBlock(t *testing.T) {
+	slow := &recordingLogger{gate: make(chan struct{})}
+	fast := &recordingLogger{}
+	f := NewFanout(FanoutOptions{QueueSize: 4, BatchSize: 1}, nil)
+	f.Add("slow", slow)
+	f.Add("fast", fast)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 1; i <= 20; i++ {
+			f.Log(sample(i))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Log blocked on a slow sink")
+	}
+
+	close(slow.gate)
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if len(fast.samples) == 0 || fast.samples[len(fast.samples)-1] != 20 {
+		t.Errorf("Fast sink wrote %v", fast.samples)
+	}
+	// The slow sink keeps the newest samples and counts the rest
+	if got := slow.samples[len(slow.samples)-1]; got != 20 {
+		t.Errorf("Slow sink's last sample = %d, want 20", got)
+	}
+	stats := f.Stats()
+	if st := stats[0]; st.Dropped == 0 || st.Written+st.Dropped != 20 {
+		t.Errorf("Slow sink stats = %+v", st)
+	}
+	if !slow.closed || !fast.closed {
+		t.Error("Close did not close every sink")
+	}
+}
+
+func TestFanoutBatchesAndSyncs(t *testing.T) {
+	b := &batchLogger{recordingLogger{gate: make(chan struct{})}}
+	f := NewFanout(FanoutOptions{BatchSize: 10, Sync: SyncBatch}, nil)
+	f.Add("csv", b)
+
+	for i := 1; i <= 5; i++ {
+		f.Log(sample(i))
+	}
+	f.GatewayError(errors.New("timeout"))
+	f.Log(sample(6))
+	close(b.gate)
+	f.Close()
+
+	if got := strings.Join(b.events, ","); got != "sample,sample,sample,sample,sample,error,sample" {
+		t.Errorf("Events = %s", got)
+	}
+	// The first sample may be written alone before the rest queue up
+	if b.batches == 0 || b.syncs == 0 {
+		t.Errorf("batches = %d, syncs = %d", b.batches, b.syncs)
+	}
+	if st := f.Stats()[0]; st.Written != 6 || st.Errors != 0 {
+		t.Errorf("Stats = %+v", st)
+	}
+}
+
+func TestFanoutCountsErrors(t *testing.T) {
+	bad := &recordingLogger{fail: errors.New("disk full")}
+	var mu sync.Mutex
+	var reported []error
+	f := NewFanout(FanoutOptions{}, func(err error) {
+		mu.Lock()
+		reported = append(reported, err)
+		mu.Unlock()
+	})
+	f.Add("json", bad)
+
+	f.Log(sample(1))
+	f.Log(sample(2))
+	// Only observers are told about failed polls
+	f.GatewayError(errors.New("timeout"))
+	f.Close()
+
+	st := f.Stats()[0]
+	if st.Errors != 2 || st.Written != 0 || st.LastError != "json: disk full" {
+		t.Errorf("Stats = %+v", st)
+	}
+	if len(reported) != 2 {
+		t.Errorf("Reported %v", reported)
+	}
+	if got := SummarizeSinks(f.Stats()); got != "0 written, 0 dropped, 2 errors (json: disk full)" {
+		t.Errorf("SummarizeSinks = %q", got)
+	}
+}
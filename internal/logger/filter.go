@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"tmobile-stats/internal/models"
+)
+
+// Filter thins out what reaches a sink: only every Nth sample, and only the
+// listed fields of each. It passes batches, syncs and failed polls through
+// to sinks that handle them.
+type Filter struct {
+	next   Logger
+	fields [][]string // Dotted JSON paths, split
+	every  int
+	seen   int
+}
+
+// NewFilter wraps next. fields are dotted JSON paths such as
+// "gateway.signal.5g.rsrp" or "ping"; gateway.time is always kept so
+// samples stay dated. every > 1 keeps one sample in every. With neither,
+// next is returned unwrapped.
+func NewFilter(next Logger, fields []string, every int) Logger {
+	if len(fields) == 0 && every <= 1 {
+		return next
+	}
+	f := &Filter{next: next, every: max(every, 1)}
+	if len(fields) > 0 {
+		f.fields = splitFields(fields)
+	}
+	return f
+}
+
+// keep reports whether the next sample is one in every.
+func (f *Filter) keep() bool {
+	f.seen++
+	return (f.seen-1)%f.every == 0
+}
+
+// project returns a copy of data with only the selected fields set.
+func (f *Filter) project(data *models.CombinedStats) (*models.CombinedStats, error) {
+	if len(f.fields) == 0 {
+		return data, nil
+	}
+	return projectPaths(data, f.fields)
+}
+
+// splitFields splits dotted paths, adding gateway.time.
+func splitFields(fields []string) [][]string {
+	paths := [][]string{{"gateway", "time"}}
+	for _, p := range fields {
+		paths = append(paths, strings.Split(p, "."))
+	}
+	return paths
+}
+
+// SelectFields returns the JSON tree of data cut down to the dotted paths in
+// fields, plus gateway.time, so the result only holds what was asked for.
+func SelectFields(data *models.CombinedStats, fields []string) (map[string]any, error) {
+	return selectPaths(data, splitFields(fields))
+}
+
+// ProjectFields returns a copy of data with only the fields SelectFields
+// keeps set and the rest zero.
+func ProjectFields(data *models.CombinedStats, fields []string) (*models.CombinedStats, error) {
+	return projectPaths(data, splitFields(fields))
+}
+
+func selectPaths(data *models.CombinedStats, paths [][]string) (map[string]any, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal JSON: %w", err)
+	}
+	var full map[string]any
+	if err := json.Unmarshal(raw, &full); err != nil {
+		return nil, err
+	}
+
+	kept := map[string]any{}
+	for _, path := range paths {
+		copyPath(kept, full, path)
+	}
+	return kept, nil
+}
+
+func projectPaths(data *models.CombinedStats, paths [][]string) (*models.CombinedStats, error) {
+	kept, err := selectPaths(data, paths)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(kept)
+	if err != nil {
+		return nil, err
+	}
+	out := &models.CombinedStats{}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// copyPath copies the value at path from src into dst, creating the parent
+// objects on the way. Missing paths are ignored.
+func copyPath(dst, src map[string]any, path []string) {
+	v, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = v
+		return
+	}
+	child, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	sub, ok := dst[path[0]].(map[string]any)
+	if !ok {
+		sub = map[string]any{}
+		dst[path[0]] = sub
+	}
+	copyPath(sub, child, path[1:])
+}
+
+func (f *Filter) Log(data *models.CombinedStats) error {
+	if !f.keep() {
+		return nil
+	}
+	out, err := f.project(data)
+	if err != nil {
+		return err
+	}
+	return f.next.Log(out)
+}
+
+// LogBatch filters the batch and hands what is left on in one go when the
+// sink takes batches.
+func (f *Filter) LogBatch(data []*models.CombinedStats) error {
+	var kept []*models.CombinedStats
+	for _, d := range data {
+		if !f.keep() {
+			continue
+		}
+		out, err := f.project(d)
+		if err != nil {
+			return err
+		}
+		kept = append(kept, out)
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	if bl, ok := f.next.(BatchLogger); ok {
+		return bl.LogBatch(kept)
+	}
+	for _, d := range kept {
+		if err := f.next.Log(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Filter) Sync() error {
+	if s, ok := f.next.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+func (f *Filter) GatewayError(err error) error {
+	if o, ok := f.next.(GatewayObserver); ok {
+		return o.GatewayError(err)
+	}
+	return nil
+}
+
+func (f *Filter) Close() error {
+	return f.next.Close()
+}
@@ -0,0 +1,176 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"tmobile-stats/internal/models"
+)
+
+// InfluxLogger writes samples as InfluxDB line protocol to a file or a
+// stream such as stdout (for a Telegraf execd input).
+type InfluxLogger struct {
+	w    io.Writer
+	file *rotatingFile // nil when writing to a stream we don't own
+}
+
+// NewInfluxLogger opens filename for appending and rotates it according to rot.
+func NewInfluxLogger(filename string, rot Rotation) (*InfluxLogger, error) {
+	f, err := openRotatingFile(filename, rot, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &InfluxLogger{w: f, file: f}, nil
+}
+
+// NewInfluxStreamLogger writes to w, which is not closed by Close.
+func NewInfluxStreamLogger(w io.Writer) *InfluxLogger {
+	return &InfluxLogger{w: w}
+}
+
+func (l *InfluxLogger) Log(data *models.CombinedStats) error {
+	// One write per sample keeps lines whole for a reader on a pipe
+	if _, err := l.w.Write(AppendInfluxLines(nil, data)); err != nil {
+		return fmt.Errorf("could not write line protocol: %w", err)
+	}
+	return nil
+}
+
+// Sync commits the log file to stable storage; streams are left alone.
+func (l *InfluxLogger) Sync() error {
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Sync()
+}
+
+func (l *InfluxLogger) Close() error {
+	if l.file == nil {
+		return nil
+	}
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return fmt.Errorf("could not sync log file: %w", err)
+	}
+	return l.file.Close()
+}
+
+// AppendInfluxLines appends the line protocol for one sample to buf: a
+// "signal" line per connected radio and a "ping" line per target, all
+// stamped with the gateway time in nanoseconds.
+//
+//	signal,radio=5g,band=n41,tower=1234567,pci=512,model=G4AR rsrp=-92i,... 1767614400000000000
+//	ping,target=8.8.8.8,band=n41,tower=1234567,pci=512,model=G4AR avg=25.3,loss=0,... 1767614400000000000
+func AppendInfluxLines(buf []byte, data *models.CombinedStats) []byte {
+	ts := strconv.FormatInt(data.Gateway.Time.LocalTime*1e9, 10)
+	model := data.Gateway.Device.Model
+
+	fiveG, fourG := &data.Gateway.Signal.FiveG, &data.Gateway.Signal.FourG
+	radios := []struct {
+		name  string
+		tower int
+		c     *models.ConnectionStats
+	}{{"5g", fiveG.GNBID, fiveG}, {"4g", fourG.EID, fourG}}
+
+	// Ping lines carry the serving cell of the primary radio
+	var cell *models.ConnectionStats
+	cellTower := 0
+	for _, r := range radios {
+		if !connected(r.c) {
+			continue
+		}
+		if cell == nil {
+			cell, cellTower = r.c, r.tower
+		}
+
+		buf = append(buf, "signal"...)
+		buf = appendTag(buf, "radio", r.name)
+		buf = appendCellTags(buf, r.c, r.tower, model)
+		buf = append(buf, ' ')
+		buf = appendIntField(buf, "rsrp", r.c.RSRP, true)
+		buf = appendIntField(buf, "rsrq", r.c.RSRQ, false)
+		buf = appendIntField(buf, "rssi", r.c.RSSI, false)
+		buf = appendIntField(buf, "sinr", r.c.SINR, false)
+		buf = appendFloatField(buf, "bars", r.c.Bars, false)
+		buf = appendIntField(buf, "cid", r.c.CID, false)
+		buf = append(buf, ' ')
+		buf = append(buf, ts...)
+		buf = append(buf, '\n')
+	}
+
+	for i, p := range append([]models.PingStats{data.Ping}, data.Targets...) {
+		buf = append(buf, "ping"...)
+		buf = appendTag(buf, "target", p.Label())
+		if cell != nil {
+			buf = appendCellTags(buf, cell, cellTower, model)
+		} else {
+			buf = appendTag(buf, "model", model)
+		}
+		buf = append(buf, ' ')
+		buf = appendFloatField(buf, "min", p.Min, true)
+		buf = appendFloatField(buf, "avg", p.Avg, false)
+		buf = appendFloatField(buf, "max", p.Max, false)
+		buf = appendFloatField(buf, "stddev", p.StdDev, false)
+		buf = appendFloatField(buf, "loss", p.Loss, false)
+		buf = appendFloatField(buf, "last_rtt", p.LastRTT, false)
+		buf = appendIntField(buf, "sent", p.Sent, false)
+		buf = appendIntField(buf, "received", p.Received, false)
+		if i == 0 {
+			buf = append(buf, ",burst="...)
+			buf = strconv.AppendBool(buf, data.Burst)
+			buf = appendFloatField(buf, "weight", data.SampleWeight(), false)
+		}
+		buf = append(buf, ' ')
+		buf = append(buf, ts...)
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+func connected(c *models.ConnectionStats) bool {
+	return len(c.Bands) > 0 || c.Bars > 0
+}
+
+func appendCellTags(buf []byte, c *models.ConnectionStats, tower int, model string) []byte {
+	buf = appendTag(buf, "band", strings.Join(c.Bands, ","))
+	if tower != 0 {
+		buf = appendTag(buf, "tower", strconv.Itoa(tower))
+	}
+	buf = appendTag(buf, "pci", strconv.Itoa(c.PCID))
+	return appendTag(buf, "model", model)
+}
+
+// appendTag appends ",key=value"; empty values are left out as line
+// protocol doesn't allow them.
+func appendTag(buf []byte, key, value string) []byte {
+	if value == "" {
+		return buf
+	}
+	buf = append(buf, ',')
+	buf = append(buf, key...)
+	buf = append(buf, '=')
+	return append(buf, tagEscaper.Replace(value)...)
+}
+
+var tagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", "")
+
+func appendIntField(buf []byte, key string, v int, first bool) []byte {
+	if !first {
+		buf = append(buf, ',')
+	}
+	buf = append(buf, key...)
+	buf = append(buf, '=')
+	buf = strconv.AppendInt(buf, int64(v), 10)
+	return append(buf, 'i')
+}
+
+func appendFloatField(buf []byte, key string, v float64, first bool) []byte {
+	if !first {
+		buf = append(buf, ',')
+	}
+	buf = append(buf, key...)
+	buf = append(buf, '=')
+	return strconv.AppendFloat(buf, v, 'f', -1, 64)
+}
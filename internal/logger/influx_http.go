@@ -0,0 +1,249 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// InfluxHTTPConfig describes an InfluxDB v2 write endpoint.
+type InfluxHTTPConfig struct {
+	URL           string        // Server base URL, e.g. http://localhost:8086
+	Org           string        // Organisation name or ID
+	Bucket        string        // Bucket name or ID
+	Token         string        // API token
+	BatchSize     int           // Samples per POST (default 10)
+	FlushInterval time.Duration // Send a partial batch after this long (default 10s)
+	MaxBuffer     int           // Samples kept while the server is unreachable (default 10000)
+	Client        *http.Client  // Default has a 10s timeout
+}
+
+// InfluxHTTPLogger batches samples and POSTs them to an InfluxDB v2 server
+// from a background goroutine, so a slow or unreachable server never stalls
+// the other loggers. Failed batches stay buffered and are retried with
+// backoff; once MaxBuffer samples are waiting the oldest are dropped.
+type InfluxHTTPLogger struct {
+	cfg      InfluxHTTPConfig
+	endpoint string
+
+	mu       sync.Mutex
+	pending  [][]byte // Line protocol per sample, oldest first
+	inflight int      // Leading pending samples being sent right now
+	dropped  int
+	lastErr  error
+
+	kick chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// maxInfluxBackoff caps the delay between retries of a failing server.
+const maxInfluxBackoff = 5 * time.Minute
+
+// NewInfluxHTTPLogger validates cfg and starts the sender.
+func NewInfluxHTTPLogger(cfg InfluxHTTPConfig) (*InfluxHTTPLogger, error) {
+	if cfg.URL == "" || cfg.Bucket == "" {
+		return nil, fmt.Errorf("influx: url and bucket are required")
+	}
+	base, err := url.Parse(strings.TrimRight(cfg.URL, "/") + "/api/v2/write")
+	if err != nil {
+		return nil, fmt.Errorf("influx: invalid url: %w", err)
+	}
+	q := base.Query()
+	q.Set("org", cfg.Org)
+	q.Set("bucket", cfg.Bucket)
+	q.Set("precision", "ns")
+	base.RawQuery = q.Encode()
+
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 10
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 10 * time.Second
+	}
+	if cfg.MaxBuffer <= 0 {
+		cfg.MaxBuffer = 10000
+	}
+	if cfg.MaxBuffer < cfg.BatchSize {
+		cfg.MaxBuffer = cfg.BatchSize
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	l := &InfluxHTTPLogger{
+		cfg:      cfg,
+		endpoint: base.String(),
+		kick:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go l.run()
+	return l, nil
+}
+
+// Log queues the sample. It returns the most recent send failure, if any,
+// once, so the caller can report it without being blocked by the network.
+func (l *InfluxHTTPLogger) Log(data *models.CombinedStats) error {
+	lines := AppendInfluxLines(nil, data)
+
+	l.mu.Lock()
+	l.pending = append(l.pending, lines)
+	if over := len(l.pending) - l.cfg.MaxBuffer; over > 0 {
+		l.pending = append([][]byte(nil), l.pending[over:]...)
+		l.inflight = max(0, l.inflight-over)
+		l.dropped += over
+	}
+	full := len(l.pending) >= l.cfg.BatchSize
+	err := l.lastErr
+	l.lastErr = nil
+	l.mu.Unlock()
+
+	if full {
+		select {
+		case l.kick <- struct{}{}:
+		default:
+		}
+	}
+	return err
+}
+
+// Pending returns the number of samples waiting to be sent and the number
+// dropped because the buffer was full.
+func (l *InfluxHTTPLogger) Pending() (pending, dropped int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.pending), l.dropped
+}
+
+func (l *InfluxHTTPLogger) run() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.cfg.FlushInterval)
+	defer ticker.Stop()
+	backoff := time.Duration(0)
+	var retryAt time.Time
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		case <-l.kick:
+		}
+		if time.Now().Before(retryAt) {
+			continue
+		}
+
+		if err := l.flush(); err != nil {
+			if backoff == 0 {
+				backoff = time.Second
+			} else if backoff *= 2; backoff > maxInfluxBackoff {
+				backoff = maxInfluxBackoff
+			}
+			retryAt = time.Now().Add(backoff)
+		} else {
+			backoff, retryAt = 0, time.Time{}
+		}
+	}
+}
+
+// flush sends pending samples in batches until the buffer is empty or a
+// batch fails with an error worth retrying.
+func (l *InfluxHTTPLogger) flush() error {
+	for {
+		l.mu.Lock()
+		n := min(len(l.pending), l.cfg.BatchSize)
+		batch := l.pending[:n:n]
+		l.inflight = n
+		l.mu.Unlock()
+		if n == 0 {
+			return nil
+		}
+
+		err := l.send(batch)
+		var perm *permanentError
+		retry := err != nil && !errors.As(err, &perm)
+
+		l.mu.Lock()
+		// Log may have dropped some of the batch to make room meanwhile
+		sent := l.inflight
+		l.inflight = 0
+		if !retry {
+			l.pending = l.pending[sent:]
+		}
+		if err != nil {
+			l.lastErr = err
+			if !retry {
+				l.dropped += sent // The server rejected the data; retrying won't help
+			}
+		}
+		l.mu.Unlock()
+		if retry {
+			return err // Keep the batch for the retry
+		}
+	}
+}
+
+// permanentError is a rejection of the data itself (malformed or too large)
+// that retrying won't fix. Auth and missing-bucket errors are retried, so a
+// configuration fix on the server doesn't lose the buffered samples.
+type permanentError struct {
+	status int
+	msg    string
+}
+
+func (e *permanentError) Error() string {
+	return fmt.Sprintf("influx: write rejected (%d): %s", e.status, e.msg)
+}
+
+func (l *InfluxHTTPLogger) send(batch [][]byte) error {
+	body := bytes.Join(batch, nil)
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, l.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if l.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+l.cfg.Token)
+	}
+
+	resp, err := l.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("influx: write failed: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	switch {
+	case resp.StatusCode/100 == 2:
+		return nil
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusRequestEntityTooLarge || resp.StatusCode == http.StatusUnprocessableEntity:
+		return &permanentError{status: resp.StatusCode, msg: strings.TrimSpace(string(msg))}
+	default:
+		return fmt.Errorf("influx: write failed (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+}
+
+// Close stops the sender and makes one last attempt to send everything
+// still buffered. It reports the samples that could not be delivered.
+func (l *InfluxHTTPLogger) Close() error {
+	close(l.stop)
+	<-l.done
+
+	err := l.flush()
+	pending, dropped := l.Pending()
+	if err != nil || pending > 0 || dropped > 0 {
+		return fmt.Errorf("influx: %d samples unsent, %d dropped: %v", pending, dropped, err)
+	}
+	return nil
+}
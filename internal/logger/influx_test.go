@@ -0,0 +1,177 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+func influxSample(ts int64) *models.CombinedStats {
+	s := &models.CombinedStats{
+		Ping:    models.PingStats{Min: 20, Avg: 25.5, Max: 40, StdDev: 3, Sent: 10, Received: 10, Target: "8.8.8.8"},
+		Targets: []models.PingStats{{Avg: 30, Sent: 10, Received: 9, Loss: 10, Target: "1.1.1.1", Options: &models.PingOptions{Network: "ip6"}}},
+	}
+	s.Gateway.Device.Model = "G4AR"
+	s.Gateway.Time.LocalTime = ts
+	s.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{"n41"}, Bars: 4, GNBID: 1234567, PCID: 512, RSRP: -92, RSRQ: -11, RSSI: -80, SINR: 14, CID: 3}
+	return s
+}
+
+func TestAppendInfluxLines(t *testing.T) {
+	got := string(AppendInfluxLines(nil, influxSample(1767614400)))
+	want := "signal,radio=5g,band=n41,tower=1234567,pci=512,model=G4AR rsrp=-92i,rsrq=-11i,rssi=-80i,sinr=14i,bars=4,cid=3i 1767614400000000000\n" +
+		"ping,target=8.8.8.8,band=n41,tower=1234567,pci=512,model=G4AR min=20,avg=25.5,max=40,stddev=3,loss=0,last_rtt=0,sent=10i,received=10i,burst=false,weight=1 1767614400000000000\n" +
+		"ping,target=1.1.1.1\\ ip6,band=n41,tower=1234567,pci=512,model=G4AR min=0,avg=30,max=0,stddev=0,loss=10,last_rtt=0,sent=10i,received=9i 1767614400000000000\n"
+	if got != want {
+		t.Errorf("Line protocol mismatch:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestInfluxStreamLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewInfluxStreamLogger(&buf)
+	if err := l.Log(influxSample(1767614400)); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if n := strings.Count(buf.String(), "\n"); n != 3 {
+		t.Errorf("Expected 3 lines, got %d", n)
+	}
+}
+
+// influxStandIn records write requests and answers with the queued statuses
+// (200 once they run out).
+type influxStandIn struct {
+	mu       sync.Mutex
+	statuses []int
+	bodies   []string
+	auth     []string
+	query    string
+}
+
+func (s *influxStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.query = r.URL.Path + "?" + r.URL.RawQuery
+	s.auth = append(s.auth, r.Header.Get("Authorization"))
+	status := http.StatusNoContent
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	if status == http.StatusNoContent {
+		s.bodies = append(s.bodies, string(body))
+	}
+	w.WriteHeader(status)
+}
+
+func (s *influxStandIn) accepted() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.bodies...)
+}
+
+func TestInfluxHTTPLoggerBatches(t *testing.T) {
+	standIn := &influxStandIn{}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	l, err := NewInfluxHTTPLogger(InfluxHTTPConfig{URL: srv.URL + "/", Org: "home", Bucket: "signal", Token: "secret", BatchSize: 2, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewInfluxHTTPLogger failed: %v", err)
+	}
+	for i := int64(0); i < 5; i++ {
+		if err := l.Log(influxSample(1767614400 + i)); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+	if err := l.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	bodies := standIn.accepted()
+	total := 0
+	for _, b := range bodies {
+		total += strings.Count(b, "\n")
+	}
+	if total != 5*3 {
+		t.Errorf("Expected 15 lines delivered, got %d in %d requests", total, len(bodies))
+	}
+	if standIn.query != "/api/v2/write?bucket=signal&org=home&precision=ns" {
+		t.Errorf("Unexpected endpoint %q", standIn.query)
+	}
+	if standIn.auth[0] != "Token secret" {
+		t.Errorf("Authorization = %q", standIn.auth[0])
+	}
+}
+
+func TestInfluxHTTPLoggerRetries(t *testing.T) {
+	// Server down twice, then a malformed-data rejection, then healthy
+	standIn := &influxStandIn{statuses: []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable}}
+	srv := httptest.NewServer(standIn)
+	defer srv.Close()
+
+	l, err := NewInfluxHTTPLogger(InfluxHTTPConfig{URL: srv.URL, Bucket: "signal", BatchSize: 1, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewInfluxHTTPLogger failed: %v", err)
+	}
+	l.Log(influxSample(1767614400))
+	// The first failure surfaces on a later Log without blocking it
+	deadline := time.Now().Add(2 * time.Second)
+	var logErr error
+	for logErr == nil && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		l.mu.Lock()
+		logErr = l.lastErr
+		l.mu.Unlock()
+	}
+	if logErr == nil {
+		t.Fatal("Expected a send error after the 503")
+	}
+	if pending, _ := l.Pending(); pending != 1 {
+		t.Errorf("Failed sample not kept for retry: %d pending", pending)
+	}
+
+	// Close retries once more (503), leaving the sample unsent
+	if err := l.Close(); err == nil {
+		t.Error("Close should report the undelivered sample")
+	}
+
+	// A new logger against the now healthy server delivers everything
+	standIn.mu.Lock()
+	standIn.statuses = []int{http.StatusBadRequest}
+	standIn.mu.Unlock()
+	l, _ = NewInfluxHTTPLogger(InfluxHTTPConfig{URL: srv.URL, Bucket: "signal", BatchSize: 1, FlushInterval: time.Hour})
+	l.Log(influxSample(1767614401)) // Rejected as malformed and dropped
+	l.Log(influxSample(1767614402))
+	err = l.Close()
+	if pending, dropped := l.Pending(); pending != 0 || dropped != 1 {
+		t.Errorf("pending=%d dropped=%d, want 0 and 1 (%v)", pending, dropped, err)
+	}
+	if bodies := standIn.accepted(); len(bodies) != 1 || !strings.Contains(bodies[0], " 1767614402000000000") {
+		t.Errorf("Unexpected deliveries: %q", bodies)
+	}
+}
+
+func TestInfluxHTTPLoggerBufferLimit(t *testing.T) {
+	l, err := NewInfluxHTTPLogger(InfluxHTTPConfig{URL: "http://127.0.0.1:1", Bucket: "signal", BatchSize: 100, MaxBuffer: 100, FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := int64(0); i < 150; i++ {
+		l.Log(influxSample(i))
+	}
+	if pending, dropped := l.Pending(); pending != 100 || dropped < 50 {
+		t.Errorf("pending=%d dropped=%d, want 100 and at least 50", pending, dropped)
+	}
+	l.Close()
+}
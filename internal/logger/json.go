@@ -3,18 +3,22 @@ package logger
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"tmobile-stats/internal/models"
 )
 
 type JSONLogger struct {
-	file *os.File
+	file *rotatingFile
 }
 
 func NewJSONLogger(filename string) (*JSONLogger, error) {
-	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	return NewRotatingJSONLogger(filename, Rotation{})
+}
+
+// NewRotatingJSONLogger opens filename for appending and rotates it according to rot.
+func NewRotatingJSONLogger(filename string, rot Rotation) (*JSONLogger, error) {
+	f, err := openRotatingFile(filename, rot, nil)
 	if err != nil {
-		return nil, fmt.Errorf("could not open log file: %w", err)
+		return nil, err
 	}
 	return &JSONLogger{file: f}, nil
 }
@@ -33,7 +37,32 @@ func (l *JSONLogger) Log(data *models.CombinedStats) error {
 	return nil
 }
 
+// LogBatch writes the samples with a single write.
+func (l *JSONLogger) LogBatch(data []*models.CombinedStats) error {
+	var buf []byte
+	for _, d := range data {
+		bytes, err := json.Marshal(d)
+		if err != nil {
+			return fmt.Errorf("could not marshal JSON: %w", err)
+		}
+		buf = append(append(buf, bytes...), '\n')
+	}
+	if _, err := l.file.Write(buf); err != nil {
+		return fmt.Errorf("could not write to log file: %w", err)
+	}
+	return nil
+}
+
+// Sync commits the log file to stable storage.
+func (l *JSONLogger) Sync() error {
+	return l.file.Sync()
+}
 
 func (l *JSONLogger) Close() error {
+	// Make sure everything written so far survives a power cut after shutdown
+	if err := l.file.Sync(); err != nil {
+		l.file.Close()
+		return fmt.Errorf("could not sync log file: %w", err)
+	}
 	return l.file.Close()
 }
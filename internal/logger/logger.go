@@ -8,3 +8,9 @@ type Logger interface {
 	Close() error
 }
 
+// GatewayObserver is implemented by loggers that also want to hear about
+// polls that failed, e.g. to mark the gateway unavailable. Such polls have
+// no sample, so Log is not called for them.
+type GatewayObserver interface {
+	GatewayError(err error) error
+}
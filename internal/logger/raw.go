@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"tmobile-stats/internal/models"
+)
+
+// RawLogger writes one JSON line per individual ping probe.
+// Unlike the interval loggers it is fed directly by the pinger, so it
+// preserves the full per-packet resolution.
+type RawLogger struct {
+	file *os.File
+	mu   sync.Mutex
+}
+
+func NewRawLogger(filename string) (*RawLogger, error) {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open raw log file: %w", err)
+	}
+	return &RawLogger{file: f}, nil
+}
+
+// WriteResult appends a single probe result to the log.
+func (l *RawLogger) WriteResult(r models.PingResult) error {
+	bytes, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("could not marshal JSON: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(bytes, '\n')); err != nil {
+		return fmt.Errorf("could not write to raw log file: %w", err)
+	}
+	return nil
+}
+
+func (l *RawLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+func TestRawLogger(t *testing.T) {
+	tmpFile := "test-raw.log"
+	defer os.Remove(tmpFile)
+
+	l, err := NewRawLogger(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create RawLogger: %v", err)
+	}
+
+	now := time.Now()
+	results := []models.PingResult{
+		{Time: now, Target: "8.8.8.8", Seq: 0, RTT: 21.5},
+		{Time: now.Add(time.Second), Target: "8.8.8.8", Seq: 1, Lost: true},
+	}
+	for _, r := range results {
+		if err := l.WriteResult(r); err != nil {
+			t.Fatalf("WriteResult failed: %v", err)
+		}
+	}
+	l.Close()
+
+	content, err := os.ReadFile(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to read raw log file: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(lines))
+	}
+
+	var got models.PingResult
+	if err := json.Unmarshal([]byte(lines[1]), &got); err != nil {
+		t.Fatalf("Failed to decode line: %v", err)
+	}
+	if !got.Lost || got.Seq != 1 || got.Target != "8.8.8.8" {
+		t.Errorf("Unexpected decoded result: %+v", got)
+	}
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"errors"
+
+	"tmobile-stats/internal/models"
+	"tmobile-stats/internal/redact"
+)
+
+// Redacting scrubs identifying details from samples before they reach a
+// sink, and IP addresses from the gateway errors it observes.
+type Redacting struct {
+	next Logger
+	r    *redact.Redactor
+}
+
+// NewRedacting wraps next, or returns it as is when r is nil.
+func NewRedacting(next Logger, r *redact.Redactor) Logger {
+	if r == nil {
+		return next
+	}
+	return &Redacting{next: next, r: r}
+}
+
+func (l *Redacting) Log(data *models.CombinedStats) error {
+	return l.next.Log(l.r.Sample(data))
+}
+
+func (l *Redacting) LogBatch(data []*models.CombinedStats) error {
+	out := make([]*models.CombinedStats, len(data))
+	for i, d := range data {
+		out[i] = l.r.Sample(d)
+	}
+	if bl, ok := l.next.(BatchLogger); ok {
+		return bl.LogBatch(out)
+	}
+	for _, d := range out {
+		if err := l.next.Log(d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *Redacting) Sync() error {
+	if s, ok := l.next.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+func (l *Redacting) GatewayError(err error) error {
+	if o, ok := l.next.(GatewayObserver); ok {
+		return o.GatewayError(errors.New(l.r.Text(err.Error())))
+	}
+	return nil
+}
+
+func (l *Redacting) Close() error {
+	return l.next.Close()
+}
+
+// RedactingRaw scrubs per-packet results before they reach the raw ping log.
+type RedactingRaw struct {
+	*RawLogger
+	R *redact.Redactor
+}
+
+func (l RedactingRaw) WriteResult(r models.PingResult) error {
+	return l.RawLogger.WriteResult(l.R.Result(r))
+}
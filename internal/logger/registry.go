@@ -0,0 +1,173 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SinkSpec describes one configured sink: its type, the type's own options
+// as raw JSON and the rotation policy shared by file sinks.
+type SinkSpec struct {
+	Type     string
+	Options  json.RawMessage
+	Rotation Rotation
+}
+
+// Factory builds a sink from its spec.
+type Factory func(spec SinkSpec) (Logger, error)
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]Factory{}
+)
+
+// Register makes a sink type available to Build. Packages outside logger
+// (the time-series store, MQTT) register theirs from the command.
+func Register(typ string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[typ]; dup {
+		panic("logger: sink type registered twice: " + typ)
+	}
+	registry[typ] = f
+}
+
+// Types lists the registered sink types, sorted.
+func Types() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	types := make([]string, 0, len(registry))
+	for t := range registry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Registered reports whether typ can be built.
+func Registered(typ string) bool {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	_, ok := registry[typ]
+	return ok
+}
+
+// Build creates a sink of spec.Type.
+func Build(spec SinkSpec) (Logger, error) {
+	registryMu.Lock()
+	f, ok := registry[spec.Type]
+	registryMu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown sink type %q", spec.Type)
+	}
+	return f(spec)
+}
+
+// DecodeOptions unmarshals spec.Options into v, rejecting unknown keys so a
+// typo in the config doesn't silently fall back to a default.
+func DecodeOptions(spec SinkSpec, v any) error {
+	if len(spec.Options) == 0 {
+		return nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(spec.Options))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("%s sink: invalid options: %w", spec.Type, err)
+	}
+	return nil
+}
+
+// FileOptions are the options of sinks writing to a single path.
+type FileOptions struct {
+	Path string `json:"path"`
+}
+
+// InfluxDBOptions are the options of the influxdb sink. Token falls back to
+// $INFLUX_TOKEN.
+type InfluxDBOptions struct {
+	URL          string `json:"url"`
+	Org          string `json:"org"`
+	Bucket       string `json:"bucket"`
+	Token        string `json:"token"`
+	BatchSize    int    `json:"batch_size"`
+	FlushSeconds int    `json:"flush_seconds"`
+	MaxBuffer    int    `json:"max_buffer"`
+}
+
+// filePath decodes FileOptions, defaulting the path to def.
+func filePath(spec SinkSpec, def string) (string, error) {
+	opts := FileOptions{Path: def}
+	if err := DecodeOptions(spec, &opts); err != nil {
+		return "", err
+	}
+	if opts.Path == "" {
+		opts.Path = def
+	}
+	return opts.Path, nil
+}
+
+func init() {
+	Register("json", func(spec SinkSpec) (Logger, error) {
+		path, err := filePath(spec, "signal-data.json")
+		if err != nil {
+			return nil, err
+		}
+		return NewRotatingJSONLogger(path, spec.Rotation)
+	})
+	Register("csv", func(spec SinkSpec) (Logger, error) {
+		path, err := filePath(spec, "signal-data.csv")
+		if err != nil {
+			return nil, err
+		}
+		return NewRotatingCSVLogger(path, spec.Rotation)
+	})
+	Register("sqlite", func(spec SinkSpec) (Logger, error) {
+		path, err := filePath(spec, "signal-data.db")
+		if err != nil {
+			return nil, err
+		}
+		// The database is indexed by time, so it is never rotated
+		return NewSQLiteLogger(path)
+	})
+	Register("influx", func(spec SinkSpec) (Logger, error) {
+		path, err := filePath(spec, "signal-data.lp")
+		if err != nil {
+			return nil, err
+		}
+		if path == "-" {
+			// For a Telegraf execd input; run with -silent or as the daemon
+			return NewInfluxStreamLogger(os.Stdout), nil
+		}
+		return NewInfluxLogger(path, spec.Rotation)
+	})
+	Register("influxdb", func(spec SinkSpec) (Logger, error) {
+		var opts InfluxDBOptions
+		if err := DecodeOptions(spec, &opts); err != nil {
+			return nil, err
+		}
+		if opts.Token == "" {
+			opts.Token = os.Getenv("INFLUX_TOKEN")
+		}
+		return NewInfluxHTTPLogger(InfluxHTTPConfig{
+			URL:           opts.URL,
+			Org:           opts.Org,
+			Bucket:        opts.Bucket,
+			Token:         opts.Token,
+			BatchSize:     opts.BatchSize,
+			FlushInterval: time.Duration(opts.FlushSeconds) * time.Second,
+			MaxBuffer:     opts.MaxBuffer,
+		})
+	})
+	Register("webhook", func(spec SinkSpec) (Logger, error) {
+		var opts WebhookOptions
+		if err := DecodeOptions(spec, &opts); err != nil {
+			return nil, err
+		}
+		return NewWebhookLogger(opts)
+	})
+}
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tmobile-stats/internal/models"
+)
+
+func TestBuild(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv")
+	l, err := Build(SinkSpec{Type: "csv", Options: json.RawMessage(`{"path": "` + path + `"}`)})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	l.Log(&models.CombinedStats{})
+	l.Close()
+	if data, err := os.ReadFile(path); err != nil || !strings.HasPrefix(string(data), "Version,") {
+		t.Errorf("Expected a CSV log, got %q (%v)", data, err)
+	}
+
+	if _, err := Build(SinkSpec{Type: "carrier-pigeon"}); err == nil {
+		t.Error("Expected an error for an unknown type")
+	}
+	if _, err := Build(SinkSpec{Type: "json", Options: json.RawMessage(`{"pth": "x.json"}`)}); err == nil {
+		t.Error("Expected an error for an unknown option")
+	}
+}
+
+func TestFilter(t *testing.T) {
+	rec := &recordingLogger{}
+	var got []*models.CombinedStats
+	capture := &captureLogger{Logger: rec, got: &got}
+	f := NewFilter(capture, []string{"gateway.signal.5g.rsrp", "ping"}, 2)
+
+	for i := 1; i <= 4; i++ {
+		s := sample(i)
+		s.Gateway.Time.LocalTime = int64(1767614400 + i)
+		s.Gateway.Device.Serial = "SECRET"
+		s.Gateway.Signal.FiveG.RSRP = -90 - i
+		s.Gateway.Signal.FiveG.SINR = 12
+		s.Ping.Avg = 25
+		f.Log(s)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("Expected every other sample, got %d", len(got))
+	}
+	s := got[1]
+	if s.Gateway.Time.LocalTime != 1767614403 || s.Gateway.Signal.FiveG.RSRP != -93 || s.Ping.Avg != 25 {
+		t.Errorf("Selected fields missing: %+v", s)
+	}
+	if s.Gateway.Device.Serial != "" || s.Gateway.Signal.FiveG.SINR != 0 {
+		t.Errorf("Unselected fields kept: %+v", s)
+	}
+
+	// Nothing to filter leaves the sink as is
+	if NewFilter(rec, nil, 1) != Logger(rec) {
+		t.Error("Expected the sink unwrapped")
+	}
+}
+
+type captureLogger struct {
+	Logger
+	got *[]*models.CombinedStats
+}
+
+func (c *captureLogger) Log(data *models.CombinedStats) error {
+	*c.got = append(*c.got, data)
+	return nil
+}
+
+func TestWebhookLogger(t *testing.T) {
+	var body []byte
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		auth = r.Header.Get("Authorization")
+		if strings.Contains(string(body), `"upTime":2`) {
+			http.Error(w, "nope", http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	l, err := Build(SinkSpec{Type: "webhook", Options: json.RawMessage(`{"url": "` + srv.URL + `", "headers": {"Authorization": "Bearer abc"}}`)})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if err := l.Log(sample(1)); err != nil {
+		t.Fatalf("Log: %v", err)
+	}
+	var got models.CombinedStats
+	if err := json.Unmarshal(body, &got); err != nil || got.Gateway.Time.UpTime != 1 || auth != "Bearer abc" {
+		t.Errorf("Posted %s with %q (%v)", body, auth, err)
+	}
+	if err := l.Log(sample(2)); err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Expected the server error, got %v", err)
+	}
+
+	if _, err := NewWebhookLogger(WebhookOptions{URL: "ftp://example.com"}); err == nil {
+		t.Error("Expected an error for a non-HTTP URL")
+	}
+}
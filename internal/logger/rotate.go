@@ -0,0 +1,333 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rotation controls when a log file is rotated and how long rotated files
+// are kept. The zero value never rotates.
+type Rotation struct {
+	MaxSize  int64         // Rotate once the file reaches this many bytes (0 = no limit)
+	Every    time.Duration // Rotate when a new period starts, aligned to UTC (e.g. 24h = daily)
+	MaxAge   time.Duration // Delete rotated files older than this (0 = keep)
+	MaxFiles int           // Keep at most this many rotated files (0 = keep all)
+	Compress bool          // Gzip rotated files
+
+	// OnError, if not nil, is told about failures to move aside, compress
+	// or prune rotated files. They don't fail the write that triggered the
+	// rotation, which still goes to a writable file.
+	OnError func(error)
+}
+
+func (r Rotation) enabled() bool {
+	return r.MaxSize > 0 || r.Every > 0
+}
+
+// RotatedTimeFormat is the timestamp embedded in rotated file names.
+const RotatedTimeFormat = "20060102T150405"
+
+// RotatedFile is one rotated segment of a log. It holds the samples
+// written up to End.
+type RotatedFile struct {
+	Path       string
+	End        time.Time
+	Compressed bool
+}
+
+// rotatedName returns "<dir>/<base>-<time>[-n]<ext>" for a log path,
+// e.g. stats.log -> stats-20260105T143000.log.
+func rotatedName(path string, t time.Time, n int) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	name := base + "-" + t.UTC().Format(RotatedTimeFormat)
+	if n > 0 {
+		name += "-" + strconv.Itoa(n)
+	}
+	return name + ext
+}
+
+// RotatedFiles lists the rotated segments of path, oldest first.
+func RotatedFiles(path string) ([]RotatedFile, error) {
+	dir := filepath.Dir(path)
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(filepath.Base(path), ext)
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(base) + `-(\d{8}T\d{6})(?:-(\d+))?` + regexp.QuoteMeta(ext) + `(\.gz)?$`)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type ordered struct {
+		RotatedFile
+		n int
+	}
+	var files []ordered
+	for _, e := range entries {
+		m := pattern.FindStringSubmatch(e.Name())
+		if m == nil || e.IsDir() {
+			continue
+		}
+		end, err := time.Parse(RotatedTimeFormat, m[1])
+		if err != nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[2])
+		files = append(files, ordered{
+			RotatedFile: RotatedFile{Path: filepath.Join(dir, e.Name()), End: end, Compressed: m[3] != ""},
+			n:           n,
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		if !files[i].End.Equal(files[j].End) {
+			return files[i].End.Before(files[j].End)
+		}
+		return files[i].n < files[j].n
+	})
+
+	out := make([]RotatedFile, len(files))
+	for i, f := range files {
+		out[i] = f.RotatedFile
+	}
+	return out, nil
+}
+
+// rotatingFile is an append-only log file that rotates itself according
+// to a Rotation policy. onCreate writes a preamble (e.g. a CSV header)
+// whenever a fresh, empty file is started.
+type rotatingFile struct {
+	path     string
+	rot      Rotation
+	onCreate func(w io.Writer) error
+
+	file   *os.File
+	size   int64
+	period time.Time
+}
+
+func openRotatingFile(path string, rot Rotation, onCreate func(w io.Writer) error) (*rotatingFile, error) {
+	rf := &rotatingFile{path: path, rot: rot, onCreate: onCreate}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+
+	// A file left over from an earlier period is rotated straight away
+	if rot.Every > 0 && rf.size > 0 {
+		if info, err := rf.file.Stat(); err == nil && info.ModTime().Truncate(rot.Every).Before(rf.period) {
+			if err := rf.rotate(info.ModTime()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not stat log file: %w", err)
+	}
+	size, err := repairTail(f, info.Size())
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("could not repair log file: %w", err)
+	}
+
+	rf.file = f
+	rf.size = size
+	if rf.rot.Every > 0 {
+		rf.period = time.Now().Truncate(rf.rot.Every)
+	}
+
+	if rf.size == 0 && rf.onCreate != nil {
+		if err := rf.onCreate(rf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Write appends p, rotating first if p would push the file past MaxSize
+// or a new period has started.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	if rf.file == nil {
+		// A reopen after rotating failed; try again
+		if err := rf.open(); err != nil {
+			return 0, err
+		}
+	} else if rf.rot.enabled() && rf.due(len(p)) {
+		if err := rf.rotate(time.Now()); err != nil {
+			return 0, err
+		}
+	}
+	// Every record is a single write; one that only partly made it (disk
+	// full, I/O error) is taken back so the next starts on a fresh line.
+	n, err := rf.file.Write(p)
+	if err == nil && n < len(p) {
+		err = io.ErrShortWrite
+	}
+	if err != nil && n > 0 && rf.file.Truncate(rf.size) == nil {
+		n = 0
+	}
+	rf.size += int64(n)
+	return n, err
+}
+
+// repairTail cuts off a record left half-written by a crash, i.e. anything
+// after the last newline, so the next append doesn't glue onto it. It
+// returns the size of the repaired file.
+func repairTail(f *os.File, size int64) (int64, error) {
+	buf := make([]byte, 4096)
+	end := size
+	for end > 0 {
+		n := min(int64(len(buf)), end)
+		if _, err := f.ReadAt(buf[:n], end-n); err != nil {
+			return size, err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			end -= n - int64(i) - 1
+			break
+		}
+		end -= n
+	}
+	if end == size {
+		return size, nil
+	}
+	return end, f.Truncate(end)
+}
+
+func (rf *rotatingFile) due(next int) bool {
+	if rf.rot.MaxSize > 0 && rf.size > 0 && rf.size+int64(next) > rf.rot.MaxSize {
+		return true
+	}
+	return rf.rot.Every > 0 && time.Now().Truncate(rf.rot.Every).After(rf.period)
+}
+
+// rotate moves the current file aside as a segment ending at end and starts
+// a new file, then compresses the segment and applies retention. Only
+// failing to open a file to write to is returned; the rest goes to
+// Rotation.OnError.
+func (rf *rotatingFile) rotate(end time.Time) error {
+	if err := rf.file.Close(); err != nil {
+		rf.report(fmt.Errorf("could not close log file: %w", err))
+	}
+	rf.file = nil
+
+	target := rotatedName(rf.path, end, 0)
+	for n := 1; exists(target) || exists(target+".gz"); n++ {
+		target = rotatedName(rf.path, end, n)
+	}
+	// If the segment can't be moved aside, the current file is reopened and
+	// kept on instead
+	renameErr := os.Rename(rf.path, target)
+	if err := rf.open(); err != nil {
+		return err
+	}
+	if renameErr != nil {
+		rf.report(fmt.Errorf("could not rotate log file: %w", renameErr))
+		return nil
+	}
+
+	if rf.rot.Compress {
+		if err := compressFile(target); err != nil {
+			rf.report(fmt.Errorf("could not compress rotated log: %w", err))
+		}
+	}
+	if err := rf.prune(time.Now()); err != nil {
+		rf.report(fmt.Errorf("could not prune rotated logs: %w", err))
+	}
+	return nil
+}
+
+func (rf *rotatingFile) report(err error) {
+	if rf.rot.OnError != nil {
+		rf.rot.OnError(err)
+	}
+}
+
+// prune deletes rotated files beyond MaxFiles or older than MaxAge.
+func (rf *rotatingFile) prune(now time.Time) error {
+	if rf.rot.MaxAge <= 0 && rf.rot.MaxFiles <= 0 {
+		return nil
+	}
+	files, err := RotatedFiles(rf.path)
+	if err != nil {
+		return err
+	}
+
+	for i, f := range files {
+		tooMany := rf.rot.MaxFiles > 0 && i < len(files)-rf.rot.MaxFiles
+		tooOld := rf.rot.MaxAge > 0 && now.Sub(f.End) > rf.rot.MaxAge
+		if tooMany || tooOld {
+			if err := os.Remove(f.Path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (rf *rotatingFile) Sync() error {
+	if rf.file == nil {
+		return nil
+	}
+	return rf.file.Sync()
+}
+
+func (rf *rotatingFile) Close() error {
+	if rf.file == nil {
+		return nil
+	}
+	return rf.file.Close()
+}
+
+// compressFile gzips path to path.gz and removes the original.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,208 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+func TestRotationBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "stats.log")
+
+	l, err := NewRotatingJSONLogger(path, Rotation{MaxSize: 1200, MaxFiles: 2, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := &models.CombinedStats{Gateway: models.GatewayResponse{Device: models.DeviceInfo{Model: "TEST"}}}
+	for i := 0; i < 20; i++ {
+		if err := l.Log(data); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+	l.Close()
+
+	files, err := RotatedFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected retention to keep 2 rotated files, got %d", len(files))
+	}
+	for _, f := range files {
+		if !f.Compressed || !strings.HasSuffix(f.Path, ".log.gz") {
+			t.Errorf("Expected compressed segment, got %s", f.Path)
+		}
+		if content := gunzip(t, f.Path); !strings.Contains(content, `"model":"TEST"`) {
+			t.Errorf("Segment %s lost its content: %q", f.Path, content)
+		}
+	}
+
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > 1200 {
+		t.Errorf("Active file should stay under the size limit, got %v %v", info.Size(), err)
+	}
+}
+
+func TestRotationCSVHeader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal-data.csv")
+
+	l, err := NewRotatingCSVLogger(path, Rotation{MaxSize: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		l.Log(&models.CombinedStats{})
+	}
+	l.Close()
+
+	files, _ := RotatedFiles(path)
+	if len(files) == 0 {
+		t.Fatal("Expected at least one rotation")
+	}
+	for _, p := range []string{files[0].Path, path} {
+		content, _ := os.ReadFile(p)
+		if !strings.HasPrefix(string(content), "Version,Timestamp,") {
+			t.Errorf("%s does not start with the CSV header", p)
+		}
+	}
+}
+
+func TestRotationOnNewPeriod(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.log")
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().Add(-25 * time.Hour)
+	os.Chtimes(path, yesterday, yesterday)
+
+	l, err := NewRotatingJSONLogger(path, Rotation{Every: 24 * time.Hour, MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// Yesterday's file is rotated on open, then pruned by MaxAge
+	if info, _ := os.Stat(path); info.Size() != 0 {
+		t.Errorf("Expected a fresh file for today, got %d bytes", info.Size())
+	}
+	if files, _ := RotatedFiles(path); len(files) != 0 {
+		t.Errorf("Expected old segment to be pruned, got %v", files)
+	}
+}
+
+func TestRotationCompressFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.log")
+	if err := os.WriteFile(path, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().Add(-25 * time.Hour)
+	os.Chtimes(path, yesterday, yesterday)
+	// A dangling link where the compressed segment goes makes gzip fail
+	segment := rotatedName(path, yesterday, 0)
+	if err := os.Symlink("missing", segment+".gz"); err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []error
+	l, err := NewRotatingJSONLogger(path, Rotation{Every: 24 * time.Hour, Compress: true, OnError: func(err error) {
+		errs = append(errs, err)
+	}})
+	if err != nil {
+		t.Fatalf("Compression failure failed the open: %v", err)
+	}
+	defer l.Close()
+
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "compress") {
+		t.Errorf("Reported errors = %v, want one compression error", errs)
+	}
+	if _, err := os.Stat(segment); err != nil {
+		t.Errorf("Uncompressed segment should be kept: %v", err)
+	}
+	if err := l.Log(&models.CombinedStats{}); err != nil {
+		t.Errorf("Log after failed compression: %v", err)
+	}
+}
+
+func TestRotatedFilesOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "stats.log")
+	for _, name := range []string{
+		"stats-20260105T120000.log.gz",
+		"stats-20260104T120000.log.gz",
+		"stats-20260105T120000-1.log",
+		"stats.log",
+		"other-20260105T120000.log",
+		"stats-bogus.log",
+	} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	files, err := RotatedFiles(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, filepath.Base(f.Path))
+	}
+	want := "stats-20260104T120000.log.gz stats-20260105T120000.log.gz stats-20260105T120000-1.log"
+	if strings.Join(names, " ") != want {
+		t.Errorf("Expected %s, got %v", want, names)
+	}
+}
+
+func gunzip(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRepairTruncatedTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stats.log")
+	// A crash cut the second record short
+	os.WriteFile(path, []byte(`{"gateway":{"device":{"model":"A"}}}`+"\n"+`{"gateway":{"dev`), 0644)
+
+	l, err := NewRotatingJSONLogger(path, Rotation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Log(&models.CombinedStats{Gateway: models.GatewayResponse{Device: models.DeviceInfo{Model: "B"}}})
+	l.Close()
+
+	data, _ := os.ReadFile(path)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"model":"A"`) || !strings.Contains(lines[1], `"model":"B"`) || strings.HasPrefix(lines[1], `{"gateway":{"dev{`) {
+		t.Errorf("Expected the partial record removed, got:\n%s", data)
+	}
+
+	// A file that is nothing but a fragment is emptied, so a CSV log gets its header back
+	csvPath := filepath.Join(t.TempDir(), "log.csv")
+	os.WriteFile(csvPath, []byte("Version,Timest"), 0644)
+	c, err := NewRotatingCSVLogger(csvPath, Rotation{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if data, _ := os.ReadFile(csvPath); string(data) != strings.Join(csvHeader(), ",")+"\n" {
+		t.Errorf("Expected a fresh header, got %q", data)
+	}
+}
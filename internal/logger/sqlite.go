@@ -0,0 +1,295 @@
+package logger
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/models"
+
+	_ "modernc.org/sqlite" // Pure Go driver, registers "sqlite"
+)
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS samples (
+	id       INTEGER PRIMARY KEY,
+	time     INTEGER NOT NULL,
+	band     TEXT    NOT NULL,
+	tower    INTEGER NOT NULL,
+	rsrp     INTEGER NOT NULL,
+	sinr     INTEGER NOT NULL,
+	bars     REAL    NOT NULL,
+	ping_avg REAL    NOT NULL,
+	loss     REAL    NOT NULL,
+	burst    INTEGER NOT NULL,
+	weight   REAL    NOT NULL,
+	digest   INTEGER NOT NULL,
+	data     TEXT    NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS samples_time_digest ON samples(time, digest);
+CREATE INDEX IF NOT EXISTS samples_tower_time ON samples(tower, time);
+CREATE INDEX IF NOT EXISTS samples_band_time ON samples(band, time);
+
+CREATE TABLE IF NOT EXISTS ping_windows (
+	sample_id INTEGER NOT NULL REFERENCES samples(id) ON DELETE CASCADE,
+	target    TEXT    NOT NULL,
+	sent      INTEGER NOT NULL,
+	received  INTEGER NOT NULL,
+	loss      REAL    NOT NULL,
+	min       REAL    NOT NULL,
+	avg       REAL    NOT NULL,
+	max       REAL    NOT NULL,
+	stddev    REAL    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS ping_windows_sample ON ping_windows(sample_id);
+
+CREATE TABLE IF NOT EXISTS events (
+	id     INTEGER PRIMARY KEY,
+	time   INTEGER NOT NULL,
+	kind   TEXT    NOT NULL,
+	detail TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS events_time ON events(time);
+`
+
+// Event kinds recorded in the events table.
+const (
+	EventAnnotation  = "annotation"
+	EventBurst       = "burst"
+	EventTowerChange = "tower_change"
+	EventBandChange  = "band_change"
+)
+
+// Event is a row of the events table.
+type Event struct {
+	Time   time.Time
+	Kind   string
+	Detail string
+}
+
+// SQLiteLogger stores samples in an SQLite database with indexed columns
+// for time, tower and band; the full sample is kept as JSON so readers get
+// back exactly what was logged.
+type SQLiteLogger struct {
+	db *sql.DB
+	mu sync.Mutex
+
+	lastTower int
+	lastBand  string
+	lastBurst bool
+}
+
+// NewSQLiteLogger opens (or creates) the database at filename.
+func NewSQLiteLogger(filename string) (*SQLiteLogger, error) {
+	db, err := sql.Open("sqlite", filename+"?_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)&_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("could not open database: %w", err)
+	}
+	// A single connection serialises writers and keeps WAL readers consistent
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("could not create schema: %w", err)
+	}
+
+	l := &SQLiteLogger{db: db}
+	// Continue change detection from the last stored sample
+	var band string
+	var tower int
+	if err := db.QueryRow(`SELECT band, tower FROM samples ORDER BY time DESC, id DESC LIMIT 1`).Scan(&band, &tower); err == nil {
+		l.lastBand, l.lastTower = band, tower
+	}
+	return l, nil
+}
+
+func (l *SQLiteLogger) Log(data *models.CombinedStats) error {
+	_, err := l.insert(data)
+	return err
+}
+
+// Import stores samples, skipping ones already present (e.g. from an
+// overlapping log).
+// It returns the number of samples added.
+func (l *SQLiteLogger) Import(samples []models.CombinedStats) (int, error) {
+	added := 0
+	for i := range samples {
+		ok, err := l.insert(&samples[i])
+		if err != nil {
+			return added, err
+		}
+		if ok {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// insert writes one sample with its ping windows and events; it reports
+// false if an identical sample was already stored.
+func (l *SQLiteLogger) insert(data *models.CombinedStats) (bool, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return false, fmt.Errorf("could not marshal JSON: %w", err)
+	}
+	h := fnv.New64a()
+	h.Write(payload)
+	digest := int64(h.Sum64())
+
+	radio := data.Gateway.Signal.FiveG
+	if len(radio.Bands) == 0 && radio.Bars == 0 {
+		radio = data.Gateway.Signal.FourG
+	}
+	band := ""
+	if len(radio.Bands) > 0 {
+		band = radio.Bands[0]
+	}
+	tower := radio.GNBID
+	if tower == 0 {
+		tower = radio.PCID
+	}
+	ts := data.Gateway.Time.LocalTime
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("could not begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`INSERT OR IGNORE INTO samples
+		(time, band, tower, rsrp, sinr, bars, ping_avg, loss, burst, weight, digest, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		ts, band, tower, radio.RSRP, radio.SINR, radio.Bars, data.Ping.Avg, data.Ping.Loss,
+		data.Burst, data.SampleWeight(), digest, string(payload))
+	if err != nil {
+		return false, fmt.Errorf("could not insert sample: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return false, err
+	}
+
+	windows := append([]models.PingStats{data.Ping}, data.Targets...)
+	for _, p := range windows {
+		if _, err := tx.Exec(`INSERT INTO ping_windows (sample_id, target, sent, received, loss, min, avg, max, stddev)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			id, p.Label(), p.Sent, p.Received, p.Loss, p.Min, p.Avg, p.Max, p.StdDev); err != nil {
+			return false, fmt.Errorf("could not insert ping window: %w", err)
+		}
+	}
+
+	var events []Event
+	for _, text := range data.Annotations {
+		events = append(events, Event{Kind: EventAnnotation, Detail: text})
+	}
+	if data.Burst && !l.lastBurst {
+		events = append(events, Event{Kind: EventBurst, Detail: data.BurstReason})
+	}
+	if tower != 0 && l.lastTower != 0 && tower != l.lastTower {
+		events = append(events, Event{Kind: EventTowerChange, Detail: fmt.Sprintf("%d -> %d", l.lastTower, tower)})
+	}
+	if band != "" && l.lastBand != "" && band != l.lastBand {
+		events = append(events, Event{Kind: EventBandChange, Detail: l.lastBand + " -> " + band})
+	}
+	for _, e := range events {
+		if _, err := tx.Exec(`INSERT INTO events (time, kind, detail) VALUES (?, ?, ?)`, ts, e.Kind, e.Detail); err != nil {
+			return false, fmt.Errorf("could not insert event: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("could not commit sample: %w", err)
+	}
+
+	l.lastBurst = data.Burst
+	if tower != 0 {
+		l.lastTower = tower
+	}
+	if band != "" {
+		l.lastBand = band
+	}
+	return true, nil
+}
+
+// Query returns the samples logged in [start, end] in time order.
+// Zero bounds are open, matching analysis.TimeFilter.
+func (l *SQLiteLogger) Query(start, end time.Time) ([]models.CombinedStats, error) {
+	where, args := timeRange(start, end)
+	rows, err := l.db.Query(`SELECT data FROM samples`+where+` ORDER BY time, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query samples: %w", err)
+	}
+	defer rows.Close()
+
+	var results []models.CombinedStats
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+		var stats models.CombinedStats
+		if err := json.Unmarshal([]byte(payload), &stats); err != nil {
+			continue // Skip malformed rows like ParseLog skips malformed lines
+		}
+		results = append(results, stats)
+	}
+	return results, rows.Err()
+}
+
+// Events returns the events recorded in [start, end] in time order.
+func (l *SQLiteLogger) Events(start, end time.Time) ([]Event, error) {
+	where, args := timeRange(start, end)
+	rows, err := l.db.Query(`SELECT time, kind, detail FROM events`+where+` ORDER BY time, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var ts int64
+		var e Event
+		if err := rows.Scan(&ts, &e.Kind, &e.Detail); err != nil {
+			return nil, err
+		}
+		e.Time = time.Unix(ts, 0)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func timeRange(start, end time.Time) (string, []any) {
+	var conds []string
+	var args []any
+	if !start.IsZero() {
+		// Samples have whole-second times, so round a fractional start up
+		sec := start.Unix()
+		if start.Nanosecond() > 0 {
+			sec++
+		}
+		conds = append(conds, "time >= ?")
+		args = append(args, sec)
+	}
+	if !end.IsZero() {
+		conds = append(conds, "time <= ?")
+		args = append(args, end.Unix())
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (l *SQLiteLogger) Close() error {
+	return l.db.Close()
+}
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+func sqliteSample(ts int64, gnbid int, band string) *models.CombinedStats {
+	s := &models.CombinedStats{Ping: models.PingStats{Sent: 10, Received: 10, Avg: 25}}
+	s.Gateway.Time.LocalTime = ts
+	s.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{band}, Bars: 4, GNBID: gnbid, RSRP: -90, SINR: 12}
+	return s
+}
+
+func TestSQLiteLoggerQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal.db")
+	l, err := NewSQLiteLogger(path)
+	if err != nil {
+		t.Fatalf("Failed to create SQLiteLogger: %v", err)
+	}
+	defer l.Close()
+
+	base := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC).Unix()
+	for i := int64(0); i < 5; i++ {
+		if err := l.Log(sqliteSample(base+i*60, 100, "n41")); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+
+	all, err := l.Query(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(all) != 5 {
+		t.Fatalf("Expected 5 samples, got %d", len(all))
+	}
+
+	got, err := l.Query(time.Unix(base+60, 0), time.Unix(base+180, 0))
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("Expected 3 samples in range, got %d", len(got))
+	}
+	if got[0].Gateway.Time.LocalTime != base+60 || got[0].Gateway.Signal.FiveG.GNBID != 100 {
+		t.Errorf("Unexpected first sample: %+v", got[0].Gateway)
+	}
+}
+
+func TestSQLiteLoggerDedupeAndEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal.db")
+	l, err := NewSQLiteLogger(path)
+	if err != nil {
+		t.Fatalf("Failed to create SQLiteLogger: %v", err)
+	}
+
+	base := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC).Unix()
+	samples := []models.CombinedStats{
+		*sqliteSample(base, 100, "n41"),
+		*sqliteSample(base+60, 200, "n41"),
+		*sqliteSample(base+120, 200, "n71"),
+	}
+	samples[1].Annotations = []string{"moved gateway"}
+	samples[2].Burst = true
+	samples[2].BurstReason = "sinr drop"
+
+	added, err := l.Import(samples)
+	if err != nil || added != 3 {
+		t.Fatalf("Import = %d, %v; want 3", added, err)
+	}
+	l.Close()
+
+	// Reopening and importing the same samples adds nothing
+	l, err = NewSQLiteLogger(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer l.Close()
+	added, err = l.Import(samples)
+	if err != nil || added != 0 {
+		t.Fatalf("Re-import = %d, %v; want 0", added, err)
+	}
+
+	events, err := l.Events(time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Events failed: %v", err)
+	}
+	kinds := map[string]string{}
+	for _, e := range events {
+		kinds[e.Kind] = e.Detail
+	}
+	want := map[string]string{
+		EventAnnotation:  "moved gateway",
+		EventTowerChange: "100 -> 200",
+		EventBandChange:  "n41 -> n71",
+		EventBurst:       "sinr drop",
+	}
+	for kind, detail := range want {
+		if kinds[kind] != detail {
+			t.Errorf("Event %s = %q, want %q", kind, kinds[kind], detail)
+		}
+	}
+	if len(events) != len(want) {
+		t.Errorf("Expected %d events, got %d: %+v", len(want), len(events), events)
+	}
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// WebhookOptions are the options of the webhook sink.
+type WebhookOptions struct {
+	URL            string            `json:"url"`
+	Headers        map[string]string `json:"headers"`         // e.g. an Authorization header
+	TimeoutSeconds int               `json:"timeout_seconds"` // Per request (default 10)
+}
+
+// WebhookLogger POSTs every sample as JSON to a URL. It is meant to run
+// behind a Fanout, which keeps a slow endpoint from holding up the rest.
+type WebhookLogger struct {
+	opts   WebhookOptions
+	client *http.Client
+}
+
+// NewWebhookLogger checks the URL; nothing is sent until the first sample.
+func NewWebhookLogger(opts WebhookOptions) (*WebhookLogger, error) {
+	if !strings.HasPrefix(opts.URL, "http://") && !strings.HasPrefix(opts.URL, "https://") {
+		return nil, fmt.Errorf("webhook: url must be an http(s) URL, got %q", opts.URL)
+	}
+	timeout := 10 * time.Second
+	if opts.TimeoutSeconds > 0 {
+		timeout = time.Duration(opts.TimeoutSeconds) * time.Second
+	}
+	return &WebhookLogger{opts: opts, client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (l *WebhookLogger) Log(data *models.CombinedStats) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("could not marshal JSON: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, l.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range l.opts.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func (l *WebhookLogger) Close() error {
+	return nil
+}
@@ -0,0 +1,4 @@
+// Package metrics exposes the live signal and ping state in the Prometheus
+// text exposition format. The Exporter is fed from a collector subscription,
+// so scrapes never touch the gateway or the logs.
+package metrics
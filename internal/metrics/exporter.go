@@ -0,0 +1,256 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/collector"
+	"tmobile-stats/internal/models"
+	"tmobile-stats/internal/pinger"
+)
+
+// ContentType is the Prometheus text exposition format served on /metrics.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// LatencyBuckets are the upper bounds (ms) of the ping latency histogram.
+var LatencyBuckets = []float64{5, 10, 20, 30, 40, 50, 75, 100, 150, 200, 300, 500, 1000}
+
+// Exporter keeps the latest sample and cumulative ping and poll counters.
+type Exporter struct {
+	version string
+	pg      *pinger.Group
+	started time.Time
+
+	mu      sync.Mutex
+	latest  *models.CombinedStats
+	polls   uint64
+	errors  uint64
+	seen    map[*pinger.Pinger]time.Time
+	targets map[string]*latency
+}
+
+// latency is one target's histogram plus sent/lost counters.
+type latency struct {
+	buckets []uint64 // Per bucket, not cumulative; the last is +Inf
+	count   uint64
+	sum     float64
+	sent    uint64
+	lost    uint64
+}
+
+// New creates an exporter. pg supplies the per-packet results for the
+// latency histogram; it may be nil.
+func New(version string, pg *pinger.Group) *Exporter {
+	return &Exporter{
+		version: version,
+		pg:      pg,
+		started: time.Now(),
+		seen:    make(map[*pinger.Pinger]time.Time),
+		targets: make(map[string]*latency),
+	}
+}
+
+// Consume feeds every sample from sub into the exporter until sub is closed.
+func (e *Exporter) Consume(sub *collector.Subscription) {
+	for s := range sub.C {
+		e.Observe(s)
+	}
+}
+
+// Observe records a collector sample and the ping results since the previous one.
+func (e *Exporter) Observe(s collector.Sample) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.polls++
+	if s.Err != nil {
+		e.errors++
+	} else if s.Stats != nil {
+		e.latest = s.Stats
+	}
+
+	if e.pg == nil {
+		return
+	}
+	now := time.Now()
+	for _, p := range e.pg.Pingers {
+		for _, r := range p.ResultsBetween(e.seen[p], now) {
+			e.observePing(r)
+		}
+		e.seen[p] = now
+	}
+}
+
+func (e *Exporter) observePing(r models.PingResult) {
+	label := models.PingStats{Target: r.Target, Options: r.Options}.Label()
+	t, ok := e.targets[label]
+	if !ok {
+		t = &latency{buckets: make([]uint64, len(LatencyBuckets)+1)}
+		e.targets[label] = t
+	}
+	t.sent++
+	if r.Lost {
+		t.lost++
+		return
+	}
+	i := sort.SearchFloat64s(LatencyBuckets, r.RTT)
+	t.buckets[i]++
+	t.count++
+	t.sum += r.RTT
+}
+
+// ServeHTTP writes the current metrics.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	bw := bufio.NewWriter(w)
+	e.Write(bw)
+	bw.Flush()
+}
+
+// Write renders the metrics in the Prometheus text format.
+func (e *Exporter) Write(w *bufio.Writer) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	m := &writer{w: w}
+	m.family("signal_sentry_build_info", "gauge", "Build information.")
+	m.sample("signal_sentry_build_info", labels{"version", e.version}, 1)
+	m.family("signal_sentry_uptime_seconds", "gauge", "Seconds since the monitor started.")
+	m.sample("signal_sentry_uptime_seconds", nil, time.Since(e.started).Seconds())
+	m.family("signal_sentry_gateway_polls_total", "counter", "Gateway polls attempted.")
+	m.sample("signal_sentry_gateway_polls_total", nil, float64(e.polls))
+	m.family("signal_sentry_gateway_fetch_errors_total", "counter", "Gateway polls that failed.")
+	m.sample("signal_sentry_gateway_fetch_errors_total", nil, float64(e.errors))
+
+	if s := e.latest; s != nil {
+		m.family("signal_sentry_last_sample_timestamp_seconds", "gauge", "Gateway time of the latest sample.")
+		m.sample("signal_sentry_last_sample_timestamp_seconds", nil, float64(s.Gateway.Time.LocalTime))
+		m.family("signal_sentry_gateway_uptime_seconds", "gauge", "Gateway uptime reported in the latest sample.")
+		m.sample("signal_sentry_gateway_uptime_seconds", nil, float64(s.Gateway.Time.UpTime))
+		writeRadio(m, s)
+	}
+	e.writePing(m)
+}
+
+type radio struct {
+	name  string
+	tower int
+	stats *models.ConnectionStats
+}
+
+func writeRadio(m *writer, s *models.CombinedStats) {
+	fiveG, fourG := &s.Gateway.Signal.FiveG, &s.Gateway.Signal.FourG
+	// The 5G tower is its gNB; LTE cells are grouped by eNB
+	radios := []radio{{"5g", fiveG.GNBID, fiveG}, {"4g", fourG.EID, fourG}}
+
+	gauges := []struct {
+		name, help string
+		value      func(c *models.ConnectionStats) float64
+	}{
+		{"signal_sentry_rsrp_dbm", "Reference signal received power.", func(c *models.ConnectionStats) float64 { return float64(c.RSRP) }},
+		{"signal_sentry_rsrq_db", "Reference signal received quality.", func(c *models.ConnectionStats) float64 { return float64(c.RSRQ) }},
+		{"signal_sentry_sinr_db", "Signal to interference plus noise ratio.", func(c *models.ConnectionStats) float64 { return float64(c.SINR) }},
+		{"signal_sentry_rssi_dbm", "Received signal strength indicator.", func(c *models.ConnectionStats) float64 { return float64(c.RSSI) }},
+		{"signal_sentry_bars", "Signal bars shown by the gateway.", func(c *models.ConnectionStats) float64 { return c.Bars }},
+	}
+	for _, g := range gauges {
+		m.family(g.name, "gauge", g.help)
+		for _, r := range radios {
+			if len(r.stats.Bands) == 0 && r.stats.Bars == 0 {
+				continue // Not connected on this radio
+			}
+			m.sample(g.name, labels{
+				"radio", r.name,
+				"band", strings.Join(r.stats.Bands, ","),
+				"tower", strconv.Itoa(r.tower),
+				"pcid", strconv.Itoa(r.stats.PCID),
+			}, g.value(r.stats))
+		}
+	}
+}
+
+func (e *Exporter) writePing(m *writer) {
+	names := make([]string, 0, len(e.targets))
+	for name := range e.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	m.family("signal_sentry_ping_latency_ms", "histogram", "Round-trip time of answered pings.")
+	for _, name := range names {
+		t := e.targets[name]
+		var cumulative uint64
+		for i, bound := range LatencyBuckets {
+			cumulative += t.buckets[i]
+			m.sample("signal_sentry_ping_latency_ms_bucket", labels{"target", name, "le", formatFloat(bound)}, float64(cumulative))
+		}
+		m.sample("signal_sentry_ping_latency_ms_bucket", labels{"target", name, "le", "+Inf"}, float64(t.count))
+		m.sample("signal_sentry_ping_latency_ms_sum", labels{"target", name}, t.sum)
+		m.sample("signal_sentry_ping_latency_ms_count", labels{"target", name}, float64(t.count))
+	}
+
+	m.family("signal_sentry_ping_sent_total", "counter", "Pings sent.")
+	for _, name := range names {
+		m.sample("signal_sentry_ping_sent_total", labels{"target", name}, float64(e.targets[name].sent))
+	}
+	m.family("signal_sentry_ping_lost_total", "counter", "Pings that got no reply.")
+	for _, name := range names {
+		m.sample("signal_sentry_ping_lost_total", labels{"target", name}, float64(e.targets[name].lost))
+	}
+
+	if e.latest != nil {
+		m.family("signal_sentry_ping_loss_percent", "gauge", "Packet loss over the latest sample window.")
+		for _, p := range append([]models.PingStats{e.latest.Ping}, e.latest.Targets...) {
+			if p.Sent > 0 {
+				m.sample("signal_sentry_ping_loss_percent", labels{"target", p.Label()}, p.Loss)
+			}
+		}
+	}
+}
+
+// labels is a flat list of name/value pairs.
+type labels []string
+
+type writer struct {
+	w *bufio.Writer
+}
+
+func (m *writer) family(name, kind, help string) {
+	fmt.Fprintf(m.w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (m *writer) sample(name string, l labels, value float64) {
+	m.w.WriteString(name)
+	if len(l) > 0 {
+		m.w.WriteByte('{')
+		for i := 0; i+1 < len(l); i += 2 {
+			if i > 0 {
+				m.w.WriteByte(',')
+			}
+			m.w.WriteString(l[i])
+			m.w.WriteString(`="`)
+			m.w.WriteString(escapeLabel(l[i+1]))
+			m.w.WriteByte('"')
+		}
+		m.w.WriteByte('}')
+	}
+	m.w.WriteByte(' ')
+	m.w.WriteString(formatFloat(value))
+	m.w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/collector"
+	"tmobile-stats/internal/models"
+)
+
+func TestExporter(t *testing.T) {
+	e := New("v1.2.3", nil)
+
+	stats := &models.CombinedStats{Ping: models.PingStats{Target: "8.8.8.8", Sent: 10, Received: 9, Loss: 10}}
+	stats.Gateway.Time = models.TimeInfo{LocalTime: 1767614400, UpTime: 3600}
+	stats.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{"n41"}, Bars: 4, GNBID: 1234567, PCID: 512, RSRP: -92, RSRQ: -11, RSSI: -80, SINR: 14}
+	e.Observe(collector.Sample{Time: time.Now(), Stats: stats})
+	e.Observe(collector.Sample{Time: time.Now(), Err: errors.New("timeout")})
+
+	for _, rtt := range []float64{4, 25, 25, 2000} {
+		e.observePing(models.PingResult{Target: "8.8.8.8", RTT: rtt})
+	}
+	e.observePing(models.PingResult{Target: "8.8.8.8", Lost: true})
+	e.observePing(models.PingResult{Target: "1.1.1.1", RTT: 12, Options: &models.PingOptions{Network: "ip6"}})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	want := []string{
+		`signal_sentry_build_info{version="v1.2.3"} 1`,
+		`signal_sentry_gateway_polls_total 2`,
+		`signal_sentry_gateway_fetch_errors_total 1`,
+		`signal_sentry_gateway_uptime_seconds 3600`,
+		`signal_sentry_rsrp_dbm{radio="5g",band="n41",tower="1234567",pcid="512"} -92`,
+		`signal_sentry_sinr_db{radio="5g",band="n41",tower="1234567",pcid="512"} 14`,
+		`signal_sentry_bars{radio="5g",band="n41",tower="1234567",pcid="512"} 4`,
+		"# TYPE signal_sentry_ping_latency_ms histogram",
+		`signal_sentry_ping_latency_ms_bucket{target="8.8.8.8",le="5"} 1`,
+		`signal_sentry_ping_latency_ms_bucket{target="8.8.8.8",le="20"} 1`,
+		`signal_sentry_ping_latency_ms_bucket{target="8.8.8.8",le="30"} 3`,
+		`signal_sentry_ping_latency_ms_bucket{target="8.8.8.8",le="1000"} 3`,
+		`signal_sentry_ping_latency_ms_bucket{target="8.8.8.8",le="+Inf"} 4`,
+		`signal_sentry_ping_latency_ms_sum{target="8.8.8.8"} 2054`,
+		`signal_sentry_ping_latency_ms_count{target="1.1.1.1 ip6"} 1`,
+		`signal_sentry_ping_sent_total{target="8.8.8.8"} 5`,
+		`signal_sentry_ping_lost_total{target="8.8.8.8"} 1`,
+		`signal_sentry_ping_loss_percent{target="8.8.8.8"} 10`,
+	}
+	for _, line := range want {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("Missing %q in:\n%s", line, out)
+		}
+	}
+	// Not connected on LTE, so no 4G series
+	if strings.Contains(out, `radio="4g"`) {
+		t.Errorf("Unexpected 4G series:\n%s", out)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("escapeLabel = %q", got)
+	}
+}
@@ -0,0 +1,315 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// OTLPConfig points the pusher at an OTLP/HTTP collector.
+type OTLPConfig struct {
+	Endpoint string            // Collector base URL; /v1/metrics is appended unless a path is given
+	Headers  map[string]string // Sent with every request, e.g. an API key
+	Interval time.Duration     // Default 60s
+	Site     string            // Reported as the "site" resource attribute
+	Client   *http.Client      // Default has a 10s timeout
+}
+
+// OTLPPusher periodically sends the exporter's metrics to an OpenTelemetry
+// collector as OTLP/HTTP JSON. Sums and histograms are cumulative since the
+// exporter started, as on /metrics.
+type OTLPPusher struct {
+	e   *Exporter
+	cfg OTLPConfig
+	url string
+}
+
+// NewOTLPPusher validates cfg.
+func NewOTLPPusher(e *Exporter, cfg OTLPConfig) (*OTLPPusher, error) {
+	if !strings.HasPrefix(cfg.Endpoint, "http://") && !strings.HasPrefix(cfg.Endpoint, "https://") {
+		return nil, fmt.Errorf("otlp: endpoint must be an http(s) URL, got %q", cfg.Endpoint)
+	}
+	url := strings.TrimRight(cfg.Endpoint, "/")
+	if rest := url[strings.Index(url, "//")+2:]; !strings.Contains(rest, "/") {
+		url += "/v1/metrics"
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 60 * time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OTLPPusher{e: e, cfg: cfg, url: url}, nil
+}
+
+// Run pushes every interval until ctx is cancelled, then pushes once more
+// so the final counts are not lost. onErr receives failed pushes.
+func (p *OTLPPusher) Run(ctx context.Context, onErr func(error)) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := p.Push(context.Background()); err != nil && onErr != nil {
+				onErr(err)
+			}
+			return
+		case <-ticker.C:
+			if err := p.Push(ctx); err != nil && onErr != nil {
+				onErr(err)
+			}
+		}
+	}
+}
+
+// Push sends the current metrics once.
+func (p *OTLPPusher) Push(ctx context.Context) error {
+	body, err := json.Marshal(p.e.otlpRequest(time.Now(), p.cfg.Site))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range p.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := p.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("otlp: push failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("otlp: push failed (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// The OTLP JSON encoding of ExportMetricsServiceRequest. 64-bit integers
+// are strings, as in the protobuf JSON mapping.
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string        `json:"key"`
+	Value otlpAttrValue `json:"value"`
+}
+
+type otlpAttrValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberPoint `json:"dataPoints"`
+}
+
+// aggregationCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+const aggregationCumulative = 2
+
+type otlpSum struct {
+	DataPoints             []otlpNumberPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type otlpNumberPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsDouble          *float64        `json:"asDouble,omitempty"`
+	AsInt             string          `json:"asInt,omitempty"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+	BucketCounts      []string        `json:"bucketCounts"`
+	ExplicitBounds    []float64       `json:"explicitBounds"`
+}
+
+func attrs(kv ...string) []otlpAttribute {
+	var a []otlpAttribute
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			a = append(a, otlpAttribute{Key: kv[i], Value: otlpAttrValue{StringValue: kv[i+1]}})
+		}
+	}
+	return a
+}
+
+func nanos(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func counter(v uint64) string {
+	return strconv.FormatUint(v, 10)
+}
+
+// otlpRequest snapshots the metrics: the radio gauges of the latest sample,
+// per-target RTT histograms and ping counters, and the poll counters.
+func (e *Exporter) otlpRequest(now time.Time, site string) otlpRequest {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	start, ts := nanos(e.started), nanos(now)
+	sum := func(name, unit, help string, points []otlpNumberPoint) otlpMetric {
+		return otlpMetric{Name: name, Unit: unit, Description: help,
+			Sum: &otlpSum{DataPoints: points, AggregationTemporality: aggregationCumulative, IsMonotonic: true}}
+	}
+	point := func(v uint64, a []otlpAttribute) otlpNumberPoint {
+		return otlpNumberPoint{Attributes: a, StartTimeUnixNano: start, TimeUnixNano: ts, AsInt: counter(v)}
+	}
+
+	metrics := []otlpMetric{
+		sum("signal_sentry.gateway.polls", "{poll}", "Gateway polls attempted.", []otlpNumberPoint{point(e.polls, nil)}),
+		sum("signal_sentry.gateway.fetch_errors", "{poll}", "Gateway polls that failed.", []otlpNumberPoint{point(e.errors, nil)}),
+	}
+
+	var dev models.DeviceInfo
+	if s := e.latest; s != nil {
+		dev = s.Gateway.Device
+		metrics = append(metrics, otlpRadio(s, ts)...)
+	}
+
+	names := make([]string, 0, len(e.targets))
+	for name := range e.targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rtt := &otlpHistogram{AggregationTemporality: aggregationCumulative}
+	var sent, lost []otlpNumberPoint
+	for _, name := range names {
+		t := e.targets[name]
+		a := attrs("target", name)
+		counts := make([]string, len(t.buckets))
+		for i, n := range t.buckets {
+			counts[i] = counter(n)
+		}
+		rtt.DataPoints = append(rtt.DataPoints, otlpHistogramPoint{
+			Attributes:        a,
+			StartTimeUnixNano: start,
+			TimeUnixNano:      ts,
+			Count:             counter(t.count),
+			Sum:               t.sum,
+			BucketCounts:      counts,
+			ExplicitBounds:    LatencyBuckets,
+		})
+		sent = append(sent, point(t.sent, a))
+		lost = append(lost, point(t.lost, a))
+	}
+	if len(names) > 0 {
+		metrics = append(metrics,
+			otlpMetric{Name: "signal_sentry.ping.rtt", Unit: "ms", Description: "Round-trip time of answered pings.", Histogram: rtt},
+			sum("signal_sentry.ping.sent", "{packet}", "Pings sent.", sent),
+			sum("signal_sentry.ping.lost", "{packet}", "Pings that got no reply.", lost),
+		)
+	}
+
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: attrs(
+			"service.name", "signal-sentry",
+			"service.version", e.version,
+			"gateway.model", dev.Model,
+			"gateway.serial", dev.Serial,
+			"gateway.manufacturer", dev.Manufacturer,
+			"site", site,
+		)},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope:   otlpScope{Name: "tmobile-stats/internal/metrics", Version: e.version},
+			Metrics: metrics,
+		}},
+	}}}
+}
+
+// otlpRadio returns the signal gauges for each connected radio, with the
+// same attributes as the Prometheus labels.
+func otlpRadio(s *models.CombinedStats, ts string) []otlpMetric {
+	fiveG, fourG := &s.Gateway.Signal.FiveG, &s.Gateway.Signal.FourG
+	radios := []radio{{"5g", fiveG.GNBID, fiveG}, {"4g", fourG.EID, fourG}}
+
+	gauges := []struct {
+		name, unit, help string
+		value            func(c *models.ConnectionStats) float64
+	}{
+		{"signal_sentry.radio.rsrp", "dBm", "Reference signal received power.", func(c *models.ConnectionStats) float64 { return float64(c.RSRP) }},
+		{"signal_sentry.radio.rsrq", "dB", "Reference signal received quality.", func(c *models.ConnectionStats) float64 { return float64(c.RSRQ) }},
+		{"signal_sentry.radio.sinr", "dB", "Signal to interference plus noise ratio.", func(c *models.ConnectionStats) float64 { return float64(c.SINR) }},
+		{"signal_sentry.radio.rssi", "dBm", "Received signal strength indicator.", func(c *models.ConnectionStats) float64 { return float64(c.RSSI) }},
+		{"signal_sentry.radio.bars", "{bar}", "Signal bars shown by the gateway.", func(c *models.ConnectionStats) float64 { return c.Bars }},
+	}
+	var metrics []otlpMetric
+	for _, g := range gauges {
+		gauge := &otlpGauge{}
+		for _, r := range radios {
+			if len(r.stats.Bands) == 0 && r.stats.Bars == 0 {
+				continue // Not connected on this radio
+			}
+			v := g.value(r.stats)
+			gauge.DataPoints = append(gauge.DataPoints, otlpNumberPoint{
+				Attributes: attrs(
+					"radio", r.name,
+					"band", strings.Join(r.stats.Bands, ","),
+					"tower", strconv.Itoa(r.tower),
+					"pcid", strconv.Itoa(r.stats.PCID),
+				),
+				TimeUnixNano: ts,
+				AsDouble:     &v,
+			})
+		}
+		if len(gauge.DataPoints) > 0 {
+			metrics = append(metrics, otlpMetric{Name: g.name, Unit: g.unit, Description: g.help, Gauge: gauge})
+		}
+	}
+	return metrics
+}
@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/collector"
+	"tmobile-stats/internal/models"
+)
+
+func TestOTLPPush(t *testing.T) {
+	e := New("v1.2.3", nil)
+	stats := &models.CombinedStats{}
+	stats.Gateway.Device = models.DeviceInfo{Model: "G4AR", Serial: "ABC123"}
+	stats.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{"n41"}, Bars: 4, GNBID: 1234567, PCID: 512, RSRP: -92, RSRQ: -11, SINR: 14}
+	e.Observe(collector.Sample{Time: time.Now(), Stats: stats})
+	e.Observe(collector.Sample{Time: time.Now(), Err: errors.New("timeout")})
+	for _, rtt := range []float64{4, 25, 25} {
+		e.observePing(models.PingResult{Target: "8.8.8.8", RTT: rtt})
+	}
+	e.observePing(models.PingResult{Target: "8.8.8.8", Lost: true})
+
+	var got otlpRequest
+	var path, ctype, key string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, ctype, key = r.URL.Path, r.Header.Get("Content-Type"), r.Header.Get("X-Api-Key")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("Invalid request body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := NewOTLPPusher(e, OTLPConfig{Endpoint: srv.URL, Site: "home", Headers: map[string]string{"X-Api-Key": "secret"}})
+	if err != nil {
+		t.Fatalf("NewOTLPPusher failed: %v", err)
+	}
+	if err := p.Push(context.Background()); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	if path != "/v1/metrics" || ctype != "application/json" || key != "secret" {
+		t.Errorf("Unexpected request: path %q, content type %q, key %q", path, ctype, key)
+	}
+
+	rm := got.ResourceMetrics[0]
+	resource := map[string]string{}
+	for _, a := range rm.Resource.Attributes {
+		resource[a.Key] = a.Value.StringValue
+	}
+	if resource["service.name"] != "signal-sentry" || resource["gateway.model"] != "G4AR" ||
+		resource["gateway.serial"] != "ABC123" || resource["site"] != "home" {
+		t.Errorf("Unexpected resource attributes %v", resource)
+	}
+
+	metrics := map[string]otlpMetric{}
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		metrics[m.Name] = m
+	}
+	if g := metrics["signal_sentry.radio.rsrp"].Gauge; g == nil || len(g.DataPoints) != 1 || *g.DataPoints[0].AsDouble != -92 {
+		t.Errorf("Unexpected RSRP gauge %+v", g)
+	} else if a := g.DataPoints[0].Attributes; len(a) != 4 || a[0].Value.StringValue != "5g" || a[2].Value.StringValue != "1234567" {
+		t.Errorf("Unexpected RSRP attributes %+v", a)
+	}
+	if s := metrics["signal_sentry.gateway.fetch_errors"].Sum; s == nil || s.DataPoints[0].AsInt != "1" || !s.IsMonotonic {
+		t.Errorf("Unexpected fetch error counter %+v", s)
+	}
+	if s := metrics["signal_sentry.ping.lost"].Sum; s == nil || s.DataPoints[0].AsInt != "1" {
+		t.Errorf("Unexpected lost counter %+v", s)
+	}
+	h := metrics["signal_sentry.ping.rtt"].Histogram
+	if h == nil || h.AggregationTemporality != aggregationCumulative {
+		t.Fatalf("Unexpected RTT histogram %+v", h)
+	}
+	hp := h.DataPoints[0]
+	if hp.Count != "3" || hp.Sum != 54 || len(hp.BucketCounts) != len(hp.ExplicitBounds)+1 ||
+		hp.BucketCounts[0] != "1" || hp.BucketCounts[3] != "2" {
+		t.Errorf("Unexpected RTT point %+v", hp)
+	}
+}
+
+func TestOTLPEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"http://otel:4318":                   "http://otel:4318/v1/metrics",
+		"https://otel.example.com/":          "https://otel.example.com/v1/metrics",
+		"https://ingest.example.com/otlp/v1": "https://ingest.example.com/otlp/v1",
+	}
+	for in, want := range tests {
+		p, err := NewOTLPPusher(New("", nil), OTLPConfig{Endpoint: in})
+		if err != nil {
+			t.Errorf("%s: %v", in, err)
+		} else if p.url != want {
+			t.Errorf("%s: got %q, want %q", in, p.url, want)
+		}
+	}
+	if _, err := NewOTLPPusher(New("", nil), OTLPConfig{Endpoint: "otel:4318"}); err == nil {
+		t.Error("Expected an error for an endpoint without a scheme")
+	}
+}
+
+func TestOTLPPushError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	p, _ := NewOTLPPusher(New("", nil), OTLPConfig{Endpoint: srv.URL})
+	if err := p.Push(context.Background()); err == nil || err.Error() != "otlp: push failed (429): quota exceeded" {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 // GatewayResponse data structures matching the T-Mobile Gateway JSON
 type GatewayResponse struct {
 	Device DeviceInfo `json:"device"`
@@ -49,20 +54,85 @@ type TimeInfo struct {
 	UpTime        int    `json:"upTime"`
 }
 
+// PingOptions describes the ICMP packet parameters used for a probe target.
+// Zero values mean the pinger default.
+type PingOptions struct {
+	Network      string `json:"network,omitempty"` // "ip4", "ip6" or empty for auto
+	Size         int    `json:"size,omitempty"`    // ICMP payload bytes
+	TTL          int    `json:"ttl,omitempty"`
+	TOS          int    `json:"tos,omitempty"` // DSCP/TOS byte, e.g. 184 for EF
+	DontFragment bool   `json:"dont_fragment,omitempty"`
+}
+
 // PingStats represents the latency statistics.
 type PingStats struct {
-	Min      float64 `json:"min"`
-	Avg      float64 `json:"avg"`
-	Max      float64 `json:"max"`
-	StdDev   float64 `json:"stddev"`
-	Loss     float64 `json:"loss"`
-	LastRTT  float64 `json:"last_rtt"`
-	Sent     int     `json:"sent"`
-	Received int     `json:"received"`
+	Min      float64      `json:"min"`
+	Avg      float64      `json:"avg"`
+	Max      float64      `json:"max"`
+	StdDev   float64      `json:"stddev"`
+	Loss     float64      `json:"loss"`
+	LastRTT  float64      `json:"last_rtt"`
+	Sent     int          `json:"sent"`
+	Received int          `json:"received"`
+	Target   string       `json:"target,omitempty"`
+	Options  *PingOptions `json:"options,omitempty"`
+}
+
+// Label returns a short human readable name for the probe target and its options.
+func (s PingStats) Label() string {
+	label := s.Target
+	if label == "" {
+		label = "default"
+	}
+	if o := s.Options; o != nil {
+		if o.Network != "" {
+			label += " " + o.Network
+		}
+		if o.TOS != 0 {
+			label += fmt.Sprintf(" tos=%d", o.TOS)
+		}
+		if o.Size != 0 {
+			label += fmt.Sprintf(" size=%d", o.Size)
+		}
+		if o.TTL != 0 {
+			label += fmt.Sprintf(" ttl=%d", o.TTL)
+		}
+	}
+	return label
 }
 
 // CombinedStats represents the full set of monitored data.
 type CombinedStats struct {
 	Gateway GatewayResponse `json:"gateway"`
 	Ping    PingStats       `json:"ping"`
-}
\ No newline at end of file
+	Targets []PingStats     `json:"targets,omitempty"` // Additional ping targets
+
+	// Burst marks samples taken at the short adaptive interval after a
+	// degradation. Weight is the share of a normal-interval sample such a
+	// sample represents (e.g. 0.2 for 1s bursts on a 5s interval).
+	Burst       bool    `json:"burst,omitempty"`
+	BurstReason string  `json:"burst_reason,omitempty"`
+	Weight      float64 `json:"weight,omitempty"`
+
+	// Annotations are operator notes (e.g. "moved gateway to window") added
+	// through the control socket, attached to the next sample.
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// SampleWeight returns the analysis weight of the sample; 1 unless it is a burst sample.
+func (c *CombinedStats) SampleWeight() float64 {
+	if c.Weight > 0 {
+		return c.Weight
+	}
+	return 1
+}
+
+// PingResult represents the outcome of a single ICMP probe.
+type PingResult struct {
+	Time    time.Time    `json:"time"`
+	Target  string       `json:"target"`
+	Seq     int          `json:"seq"`
+	RTT     float64      `json:"rtt,omitempty"` // Milliseconds, zero when lost
+	Lost    bool         `json:"lost,omitempty"`
+	Options *PingOptions `json:"options,omitempty"`
+}
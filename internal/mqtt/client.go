@@ -0,0 +1,218 @@
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configures a connection.
+type Options struct {
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration // Default 30s
+	Timeout   time.Duration // Dial, handshake and write timeout (default 10s)
+	Will      *Message      // Published by the broker if the connection drops
+}
+
+// Connack return codes.
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Client is a connected MQTT session. It is safe for concurrent use.
+type Client struct {
+	conn    net.Conn
+	timeout time.Duration
+
+	wmu  sync.Mutex // Serialises packet writes
+	done chan struct{}
+	once sync.Once
+	err  error
+}
+
+// Dial connects to broker, given as tcp://host:port, mqtt://, ssl://,
+// mqtts:// or tls:// (default ports 1883 and 8883) or a bare host[:port].
+func Dial(broker string, opts Options) (*Client, error) {
+	if opts.KeepAlive <= 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	addr, useTLS, err := parseBroker(broker)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: opts.Timeout}
+	var conn net.Conn
+	if useTLS {
+		host, _, _ := net.SplitHostPort(addr)
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mqtt: %w", err)
+	}
+
+	c := &Client{conn: conn, timeout: opts.Timeout, done: make(chan struct{})}
+	r := bufio.NewReader(conn)
+	if err := c.handshake(r, opts); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	go c.read(r, opts.KeepAlive)
+	go c.ping(opts.KeepAlive)
+	return c, nil
+}
+
+func parseBroker(broker string) (addr string, useTLS bool, err error) {
+	if !strings.Contains(broker, "://") {
+		broker = "tcp://" + broker
+	}
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return "", false, fmt.Errorf("mqtt: invalid broker address %q", broker)
+	}
+	port := "1883"
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "ssl", "mqtts", "tls":
+		useTLS, port = true, "8883"
+	default:
+		return "", false, fmt.Errorf("mqtt: unsupported scheme %q", u.Scheme)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	return net.JoinHostPort(u.Hostname(), port), useTLS, nil
+}
+
+func (c *Client) handshake(r *bufio.Reader, opts Options) error {
+	hello := &connect{
+		clientID:  opts.ClientID,
+		username:  opts.Username,
+		password:  opts.Password,
+		keepAlive: int(opts.KeepAlive / time.Second),
+		will:      opts.Will,
+	}
+	if err := c.write(hello.packet()); err != nil {
+		return err
+	}
+
+	c.conn.SetReadDeadline(time.Now().Add(c.timeout))
+	p, err := readPacket(r)
+	if err != nil {
+		return fmt.Errorf("mqtt: no CONNACK: %w", err)
+	}
+	if p.kind != typeConnack || len(p.body) != 2 {
+		return fmt.Errorf("mqtt: expected CONNACK, got packet type %d", p.kind)
+	}
+	if code := p.body[1]; code != 0 {
+		if msg, ok := connackErrors[code]; ok {
+			return fmt.Errorf("mqtt: connection refused: %s", msg)
+		}
+		return fmt.Errorf("mqtt: connection refused (code %d)", code)
+	}
+	return nil
+}
+
+// read consumes packets from the broker until the connection fails. A
+// broker that sends nothing (not even PINGRESP) for 1.5 keep-alive periods
+// is considered gone.
+func (c *Client) read(r *bufio.Reader, keepAlive time.Duration) {
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(keepAlive * 3 / 2))
+		if _, err := readPacket(r); err != nil {
+			c.fail(fmt.Errorf("mqtt: connection lost: %w", err))
+			return
+		}
+	}
+}
+
+func (c *Client) ping(keepAlive time.Duration) {
+	ticker := time.NewTicker(keepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			if err := c.write(packet{kind: typePingreq}); err != nil {
+				c.fail(err)
+				return
+			}
+		}
+	}
+}
+
+func (c *Client) write(p packet) error {
+	buf, err := p.encode()
+	if err != nil {
+		return err
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if err := c.Err(); err != nil {
+		return err
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	if _, err := c.conn.Write(buf); err != nil {
+		err = fmt.Errorf("mqtt: write failed: %w", err)
+		c.fail(err)
+		return err
+	}
+	return nil
+}
+
+// fail records the first error and tears the connection down.
+func (c *Client) fail(err error) {
+	c.once.Do(func() {
+		c.err = err
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// Publish sends m with QoS 0.
+func (c *Client) Publish(m Message) error {
+	return c.write(publishPacket(m))
+}
+
+// Done is closed when the connection is lost or closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns why the connection ended, or nil while it is up.
+func (c *Client) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// errClosed marks a connection ended by Close.
+var errClosed = errors.New("mqtt: client closed")
+
+// Close disconnects cleanly, so the broker discards the last will.
+func (c *Client) Close() error {
+	err := c.write(packet{kind: typeDisconnect})
+	c.fail(errClosed)
+	return err
+}
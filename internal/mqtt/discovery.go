@@ -0,0 +1,106 @@
+package mqtt
+
+import (
+	"encoding/json"
+
+	"tmobile-stats/internal/models"
+)
+
+// sensor is one Home Assistant entity read from the state topic.
+type sensor struct {
+	key         string // state field, also the entity's ID suffix
+	name        string
+	unit        string
+	deviceClass string
+	icon        string
+	measurement bool
+}
+
+var sensors = []sensor{
+	{key: "rsrp", name: "RSRP", unit: "dBm", deviceClass: "signal_strength", measurement: true},
+	{key: "rsrq", name: "RSRQ", unit: "dB", icon: "mdi:signal", measurement: true},
+	{key: "sinr", name: "SINR", unit: "dB", deviceClass: "signal_strength", measurement: true},
+	{key: "rssi", name: "RSSI", unit: "dBm", deviceClass: "signal_strength", measurement: true},
+	{key: "bars", name: "Bars", icon: "mdi:signal-cellular-3", measurement: true},
+	{key: "band", name: "Band", icon: "mdi:radio-tower"},
+	{key: "tower", name: "Tower", icon: "mdi:transmission-tower"},
+	{key: "health", name: "Signal health", icon: "mdi:heart-pulse", measurement: true},
+	{key: "ping", name: "Ping", unit: "ms", deviceClass: "duration", measurement: true},
+	{key: "jitter", name: "Jitter", unit: "ms", deviceClass: "duration", measurement: true},
+	{key: "ping_loss", name: "Packet loss", unit: "%", icon: "mdi:lan-disconnect", measurement: true},
+}
+
+// discoveryConfig is the payload of a Home Assistant MQTT discovery message.
+type discoveryConfig struct {
+	Name              string          `json:"name"`
+	UniqueID          string          `json:"unique_id"`
+	StateTopic        string          `json:"state_topic"`
+	ValueTemplate     string          `json:"value_template"`
+	AvailabilityTopic string          `json:"availability_topic"`
+	Unit              string          `json:"unit_of_measurement,omitempty"`
+	DeviceClass       string          `json:"device_class,omitempty"`
+	StateClass        string          `json:"state_class,omitempty"`
+	Icon              string          `json:"icon,omitempty"`
+	Device            discoveryDevice `json:"device"`
+}
+
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Model        string   `json:"model,omitempty"`
+	SWVersion    string   `json:"sw_version,omitempty"`
+	HWVersion    string   `json:"hw_version,omitempty"`
+}
+
+func (p *Publisher) discoveryTopic(s sensor) string {
+	return p.cfg.DiscoveryPrefix + "/sensor/" + p.node + "/" + s.key + "/config"
+}
+
+// announce publishes a retained discovery config per sensor, grouped under
+// one device for the gateway. p.mu is held.
+func (p *Publisher) announce(dev models.DeviceInfo) error {
+	// Entities follow the gateway, so a second instance watching another
+	// gateway (with its own topic prefix) gets separate entities
+	id := dev.Serial
+	if id == "" {
+		id = dev.MacID
+	}
+	if id == "" {
+		id = p.node
+	}
+	device := discoveryDevice{
+		Identifiers:  []string{"signal_sentry_" + nodeID(id)},
+		Name:         "T-Mobile Gateway",
+		Manufacturer: dev.Manufacturer,
+		Model:        dev.Model,
+		SWVersion:    dev.SoftwareVersion,
+		HWVersion:    dev.HardwareVersion,
+	}
+
+	for _, s := range sensors {
+		cfg := discoveryConfig{
+			Name:              s.name,
+			UniqueID:          device.Identifiers[0] + "_" + s.key,
+			StateTopic:        p.topic("state"),
+			ValueTemplate:     "{{ value_json." + s.key + " }}",
+			AvailabilityTopic: p.topic("availability"),
+			Unit:              s.unit,
+			DeviceClass:       s.deviceClass,
+			Icon:              s.icon,
+			Device:            device,
+		}
+		if s.measurement {
+			cfg.StateClass = "measurement"
+		}
+		payload, err := json.Marshal(cfg)
+		if err != nil {
+			return err
+		}
+		if err := p.client.Publish(Message{Topic: p.discoveryTopic(s), Payload: payload, Retain: true}); err != nil {
+			return err
+		}
+	}
+	p.announced, p.device = true, dev
+	return nil
+}
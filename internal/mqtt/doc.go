@@ -0,0 +1,14 @@
+// Package mqtt publishes samples to an MQTT broker for Home Assistant and
+// similar consumers. It carries its own minimal MQTT 3.1.1 client: QoS 0
+// publishes with retain, a last will, keep-alive pings and reconnects, which
+// is all a sensor feed needs.
+//
+// For a topic prefix of "signal-sentry" the Publisher writes:
+//
+//	signal-sentry/availability  "online" / "offline" (retained, also the last will)
+//	signal-sentry/state         flat JSON the discovered sensors read from
+//	signal-sentry/sample        the full sample as logged to stats.log
+//
+// plus retained Home Assistant discovery configs under
+// homeassistant/sensor/<node>/<sensor>/config.
+package mqtt
@@ -0,0 +1,340 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// broker is an in-process MQTT broker stand-in. It accepts connections,
+// keeps retained messages, answers pings and publishes the last will when a
+// connection drops without DISCONNECT.
+type broker struct {
+	ln net.Listener
+
+	mu        sync.Mutex
+	retained  map[string]string
+	published []Message
+	connects  []*connect
+	conns     []net.Conn
+	changed   chan struct{}
+}
+
+func newBroker(t *testing.T) *broker {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &broker{ln: ln, retained: make(map[string]string), changed: make(chan struct{}, 1)}
+	t.Cleanup(func() {
+		ln.Close()
+		b.dropAll()
+	})
+	go b.accept()
+	return b
+}
+
+func (b *broker) accept() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		go b.serve(conn)
+	}
+}
+
+func (b *broker) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	p, err := readPacket(r)
+	if err != nil || p.kind != typeConnect {
+		return
+	}
+	hello, err := parseConnect(p)
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	b.connects = append(b.connects, hello)
+	b.conns = append(b.conns, conn)
+	b.mu.Unlock()
+	b.reply(conn, packet{kind: typeConnack, body: []byte{0, 0}})
+
+	for {
+		p, err := readPacket(r)
+		if err != nil {
+			if hello.will != nil {
+				b.publish(*hello.will) // Dropped without DISCONNECT
+			}
+			return
+		}
+		switch p.kind {
+		case typePublish:
+			m, err := parsePublish(p)
+			if err != nil {
+				return
+			}
+			b.publish(m)
+		case typePingreq:
+			b.reply(conn, packet{kind: typePingresp})
+		case typeDisconnect:
+			return
+		}
+	}
+}
+
+func (b *broker) reply(conn net.Conn, p packet) {
+	buf, _ := p.encode()
+	conn.Write(buf)
+}
+
+func (b *broker) publish(m Message) {
+	b.mu.Lock()
+	m.Payload = bytes.Clone(m.Payload)
+	b.published = append(b.published, m)
+	if m.Retain {
+		b.retained[m.Topic] = string(m.Payload)
+	}
+	b.mu.Unlock()
+	select {
+	case b.changed <- struct{}{}:
+	default:
+	}
+}
+
+// dropAll cuts every client connection as if the network failed.
+func (b *broker) dropAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, c := range b.conns {
+		c.Close()
+	}
+	b.conns = nil
+}
+
+func (b *broker) get(topic string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.retained[topic]
+}
+
+func (b *broker) count(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := 0
+	for _, m := range b.published {
+		if m.Topic == topic {
+			n++
+		}
+	}
+	return n
+}
+
+// waitFor polls until cond holds or fails the test after two seconds.
+func (b *broker) waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.After(2 * time.Second)
+	for !cond() {
+		select {
+		case <-b.changed:
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+	}
+}
+
+func mqttSample() *models.CombinedStats {
+	s := &models.CombinedStats{Ping: models.PingStats{Avg: 28.5, StdDev: 4, Loss: 10, Sent: 10, Received: 9}}
+	s.Gateway.Device = models.DeviceInfo{Model: "G4AR", Manufacturer: "Arcadyan", Serial: "ABC123", SoftwareVersion: "1.2"}
+	s.Gateway.Time.LocalTime = 1767614400
+	s.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{"n41"}, Bars: 4, GNBID: 1234567, PCID: 512, RSRP: -92, RSRQ: -11, SINR: 14}
+	return s
+}
+
+// connectedPublisher starts a publisher against b and waits for its session.
+func connectedPublisher(t *testing.T, b *broker) *Publisher {
+	t.Helper()
+	p, err := NewPublisher(Config{Broker: "tcp://" + b.ln.Addr().String(), ClientID: "test"})
+	if err != nil {
+		t.Fatalf("NewPublisher failed: %v", err)
+	}
+	b.waitFor(t, "the connection", func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.client != nil
+	})
+	return p
+}
+
+func TestPublisherDiscoveryAndState(t *testing.T) {
+	b := newBroker(t)
+	p := connectedPublisher(t, b)
+	defer p.Close()
+
+	b.mu.Lock()
+	hello := b.connects[0]
+	b.mu.Unlock()
+	if hello.clientID != "test" || hello.will == nil || hello.will.Topic != "signal-sentry/availability" ||
+		string(hello.will.Payload) != Offline || !hello.will.Retain {
+		t.Fatalf("Unexpected CONNECT: %+v will %+v", hello, hello.will)
+	}
+
+	if err := p.Log(mqttSample()); err != nil {
+		t.Fatalf("Log failed: %v", err)
+	}
+	b.waitFor(t, "the state", func() bool { return b.get("signal-sentry/state") != "" })
+
+	if got := b.get("signal-sentry/availability"); got != Online {
+		t.Errorf("Availability = %q, want online", got)
+	}
+	var st state
+	if err := json.Unmarshal([]byte(b.get("signal-sentry/state")), &st); err != nil {
+		t.Fatal(err)
+	}
+	want := state{Time: 1767614400, Radio: "5g", Band: "n41", Tower: 1234567, PCID: 512, RSRP: -92, RSRQ: -11, SINR: 14, Bars: 4, Health: 4, Ping: 28.5, Jitter: 4, PingLoss: 10}
+	if st != want {
+		t.Errorf("State = %+v\nwant %+v", st, want)
+	}
+
+	for _, s := range sensors {
+		raw := b.get("homeassistant/sensor/signal-sentry/" + s.key + "/config")
+		var cfg discoveryConfig
+		if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+			t.Fatalf("%s: bad discovery config %q: %v", s.key, raw, err)
+		}
+		if cfg.StateTopic != "signal-sentry/state" || cfg.AvailabilityTopic != "signal-sentry/availability" ||
+			cfg.UniqueID != "signal_sentry_ABC123_"+s.key || cfg.Device.Model != "G4AR" ||
+			!strings.Contains(cfg.ValueTemplate, "value_json."+s.key) {
+			t.Errorf("%s: unexpected discovery config %+v", s.key, cfg)
+		}
+	}
+
+	// Discovery is sent once per connection, the full sample every time
+	p.Log(mqttSample())
+	b.waitFor(t, "the second sample", func() bool { return b.count("signal-sentry/sample") == 2 })
+	if n := b.count("homeassistant/sensor/signal-sentry/rsrp/config"); n != 1 {
+		t.Errorf("Discovery sent %d times, want 1", n)
+	}
+}
+
+func TestPublisherAvailability(t *testing.T) {
+	b := newBroker(t)
+	p := connectedPublisher(t, b)
+
+	p.Log(mqttSample())
+	p.GatewayError(nil)
+	b.waitFor(t, "offline", func() bool { return b.get("signal-sentry/availability") == Offline })
+	p.GatewayError(nil)
+	p.Log(mqttSample())
+	b.waitFor(t, "online", func() bool { return b.get("signal-sentry/availability") == Online })
+	if n := b.count("signal-sentry/availability"); n != 3 {
+		t.Errorf("Availability published %d times, want only the 3 changes", n)
+	}
+
+	// A clean shutdown reports offline itself; the broker drops the will
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	b.waitFor(t, "offline after Close", func() bool { return b.get("signal-sentry/availability") == Offline })
+	if n := b.count("signal-sentry/availability"); n != 4 {
+		t.Errorf("Availability published %d times after Close, want 4 (no last will)", n)
+	}
+}
+
+func TestPublisherReconnects(t *testing.T) {
+	defer func(d time.Duration) { minReconnectDelay = d }(minReconnectDelay)
+	minReconnectDelay = 10 * time.Millisecond
+
+	b := newBroker(t)
+	p := connectedPublisher(t, b)
+	defer p.Close()
+	p.Log(mqttSample())
+	b.waitFor(t, "the state", func() bool { return b.get("signal-sentry/state") != "" })
+
+	// The broker publishes the last will when the connection drops...
+	b.dropAll()
+	b.waitFor(t, "the last will", func() bool { return b.get("signal-sentry/availability") == Offline })
+
+	// ...and the publisher reconnects, restores availability and announces again
+	b.waitFor(t, "the reconnect", func() bool {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return len(b.connects) == 2
+	})
+	b.waitFor(t, "online again", func() bool { return b.get("signal-sentry/availability") == Online })
+	b.waitFor(t, "a logged sample", func() bool {
+		p.Log(mqttSample())
+		return b.count("homeassistant/sensor/signal-sentry/rsrp/config") == 2
+	})
+}
+
+func TestPublisherLogWhileDisconnected(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close() // Nothing listening
+
+	p, err := NewPublisher(Config{Broker: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		// The dial error is reported once, without blocking
+		if err := p.Log(mqttSample()); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the connection error to be reported")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := p.Log(mqttSample()); err != nil {
+		t.Errorf("Error should be reported once, got %v", err)
+	}
+}
+
+func TestRemainingLength(t *testing.T) {
+	for _, n := range []int{0, 127, 128, 16383, 16384, 2097151, 2097152} {
+		buf, err := packet{kind: typePublish, body: make([]byte, n)}.encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := readPacket(bufio.NewReader(bytes.NewReader(buf)))
+		if err != nil || p.kind != typePublish || len(p.body) != n {
+			t.Errorf("%d bytes: got %d (%v)", n, len(p.body), err)
+		}
+	}
+}
+
+func TestParseBroker(t *testing.T) {
+	tests := []struct {
+		in   string
+		addr string
+		tls  bool
+		err  bool
+	}{
+		{"tcp://broker:1884", "broker:1884", false, false},
+		{"mqtt://broker", "broker:1883", false, false},
+		{"mqtts://broker", "broker:8883", true, false},
+		{"broker.local", "broker.local:1883", false, false},
+		{"10.0.0.2:1883", "10.0.0.2:1883", false, false},
+		{"http://broker", "", false, true},
+	}
+	for _, tt := range tests {
+		addr, useTLS, err := parseBroker(tt.in)
+		if (err != nil) != tt.err || addr != tt.addr || useTLS != tt.tls {
+			t.Errorf("parseBroker(%q) = %q, %v, %v", tt.in, addr, useTLS, err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// MQTT 3.1.1 control packet types.
+const (
+	typeConnect    = 1
+	typeConnack    = 2
+	typePublish    = 3
+	typePingreq    = 12
+	typePingresp   = 13
+	typeDisconnect = 14
+)
+
+// CONNECT flags.
+const (
+	flagCleanSession = 0x02
+	flagWill         = 0x04
+	flagWillRetain   = 0x20
+	flagPassword     = 0x40
+	flagUsername     = 0x80
+)
+
+// maxRemaining is the largest remaining length four length bytes can encode.
+const maxRemaining = 268435455
+
+// packet is one control packet: the fixed header's type and flags plus
+// everything after the remaining length.
+type packet struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+// Message is an application message.
+type Message struct {
+	Topic   string
+	Payload []byte
+	Retain  bool
+}
+
+func readPacket(r *bufio.Reader) (packet, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+	n, mult := 0, 1
+	for i := 0; ; i++ {
+		if i == 4 {
+			return packet{}, errors.New("mqtt: malformed remaining length")
+		}
+		b, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+		n += int(b&0x7f) * mult
+		mult *= 128
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	p := packet{kind: first >> 4, flags: first & 0x0f, body: make([]byte, n)}
+	if _, err := io.ReadFull(r, p.body); err != nil {
+		return packet{}, err
+	}
+	return p, nil
+}
+
+// encode returns the packet with its fixed header, ready for a single write.
+func (p packet) encode() ([]byte, error) {
+	n := len(p.body)
+	if n > maxRemaining {
+		return nil, fmt.Errorf("mqtt: packet of %d bytes is too large", n)
+	}
+	buf := make([]byte, 0, n+5)
+	buf = append(buf, p.kind<<4|p.flags)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(buf, p.body...), nil
+}
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(s)))
+	return append(buf, s...)
+}
+
+// reader walks a packet body.
+type reader struct {
+	b   []byte
+	err error
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || len(r.b) < n {
+		r.err = errors.New("mqtt: truncated packet")
+		return nil
+	}
+	v := r.b[:n]
+	r.b = r.b[n:]
+	return v
+}
+
+func (r *reader) uint16() int {
+	if b := r.bytes(2); b != nil {
+		return int(binary.BigEndian.Uint16(b))
+	}
+	return 0
+}
+
+func (r *reader) string() string {
+	return string(r.bytes(r.uint16()))
+}
+
+// connect is the content of a CONNECT packet.
+type connect struct {
+	clientID  string
+	username  string
+	password  string
+	keepAlive int // Seconds
+	will      *Message
+}
+
+func (c *connect) packet() packet {
+	flags := byte(flagCleanSession)
+	if c.will != nil {
+		flags |= flagWill
+		if c.will.Retain {
+			flags |= flagWillRetain
+		}
+	}
+	if c.username != "" {
+		flags |= flagUsername
+	}
+	if c.password != "" {
+		flags |= flagPassword
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags) // Protocol level 4 is MQTT 3.1.1
+	body = binary.BigEndian.AppendUint16(body, uint16(c.keepAlive))
+	body = appendString(body, c.clientID)
+	if c.will != nil {
+		body = appendString(body, c.will.Topic)
+		body = appendString(body, string(c.will.Payload))
+	}
+	if c.username != "" {
+		body = appendString(body, c.username)
+	}
+	if c.password != "" {
+		body = appendString(body, c.password)
+	}
+	return packet{kind: typeConnect, body: body}
+}
+
+func parseConnect(p packet) (*connect, error) {
+	r := &reader{b: p.body}
+	if proto := r.string(); r.err == nil && proto != "MQTT" {
+		return nil, fmt.Errorf("mqtt: unsupported protocol %q", proto)
+	}
+	level := r.bytes(2)
+	c := &connect{keepAlive: r.uint16(), clientID: r.string()}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if level[0] != 4 {
+		return nil, fmt.Errorf("mqtt: unsupported protocol level %d", level[0])
+	}
+	flags := level[1]
+	if flags&flagWill != 0 {
+		c.will = &Message{Topic: r.string(), Payload: []byte(r.string()), Retain: flags&flagWillRetain != 0}
+	}
+	if flags&flagUsername != 0 {
+		c.username = r.string()
+	}
+	if flags&flagPassword != 0 {
+		c.password = r.string()
+	}
+	return c, r.err
+}
+
+func publishPacket(m Message) packet {
+	p := packet{kind: typePublish, body: append(appendString(nil, m.Topic), m.Payload...)}
+	if m.Retain {
+		p.flags = 0x01
+	}
+	return p
+}
+
+// parsePublish decodes a QoS 0 PUBLISH.
+func parsePublish(p packet) (Message, error) {
+	if qos := p.flags >> 1 & 0x03; qos != 0 {
+		return Message{}, fmt.Errorf("mqtt: unsupported QoS %d", qos)
+	}
+	r := &reader{b: p.body}
+	topic := r.string()
+	return Message{Topic: topic, Payload: r.b, Retain: p.flags&0x01 != 0}, r.err
+}
@@ -0,0 +1,282 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/models"
+)
+
+// Availability payloads.
+const (
+	Online  = "online"
+	Offline = "offline"
+)
+
+// Reconnect backoff bounds; variables so tests can shorten them.
+var (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// Config describes the broker and the topics to publish on.
+type Config struct {
+	Broker           string // e.g. tcp://homeassistant.local:1883
+	ClientID         string // Default signal-sentry-<hostname>
+	Username         string
+	Password         string
+	TopicPrefix      string // Default "signal-sentry"
+	DiscoveryPrefix  string // Default "homeassistant"
+	DisableDiscovery bool
+	KeepAlive        time.Duration // Default 30s
+}
+
+// Publisher is a logger.Logger that publishes every sample to MQTT and
+// announces the sensors to Home Assistant. The broker connection is kept up
+// in the background; samples logged while it is down are skipped rather
+// than queued, as only the latest state matters to a sensor.
+//
+// Availability follows the gateway: "online" after a successful poll,
+// "offline" after a failed one and, through the last will, when this
+// process loses its connection to the broker.
+type Publisher struct {
+	cfg  Config
+	node string // Topic prefix made safe for discovery topics and IDs
+
+	mu        sync.Mutex
+	client    *Client
+	reachable bool   // Gateway state from the latest poll
+	polled    bool   // reachable is known
+	availSent string // Availability published on this connection
+	announced bool   // Discovery published on this connection
+	device    models.DeviceInfo
+	lastErr   error
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPublisher validates cfg and starts connecting to the broker.
+func NewPublisher(cfg Config) (*Publisher, error) {
+	if cfg.Broker == "" {
+		return nil, fmt.Errorf("mqtt: broker is required")
+	}
+	if _, _, err := parseBroker(cfg.Broker); err != nil {
+		return nil, err
+	}
+	if cfg.TopicPrefix == "" {
+		cfg.TopicPrefix = "signal-sentry"
+	}
+	cfg.TopicPrefix = strings.TrimRight(cfg.TopicPrefix, "/")
+	if cfg.DiscoveryPrefix == "" {
+		cfg.DiscoveryPrefix = "homeassistant"
+	}
+	if cfg.ClientID == "" {
+		host, _ := os.Hostname()
+		cfg.ClientID = "signal-sentry-" + host
+	}
+
+	p := &Publisher{
+		cfg:  cfg,
+		node: nodeID(cfg.TopicPrefix),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go p.run()
+	return p, nil
+}
+
+// nodeID maps s to the characters Home Assistant allows in discovery topics.
+func nodeID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+func (p *Publisher) topic(name string) string {
+	return p.cfg.TopicPrefix + "/" + name
+}
+
+// run keeps a broker connection up until Close.
+func (p *Publisher) run() {
+	defer close(p.done)
+
+	backoff := minReconnectDelay
+	for {
+		c, err := Dial(p.cfg.Broker, Options{
+			ClientID:  p.cfg.ClientID,
+			Username:  p.cfg.Username,
+			Password:  p.cfg.Password,
+			KeepAlive: p.cfg.KeepAlive,
+			Will:      &Message{Topic: p.topic("availability"), Payload: []byte(Offline), Retain: true},
+		})
+		if err == nil {
+			backoff = minReconnectDelay
+			p.connected(c)
+			select {
+			case <-p.stop:
+				return // Close disconnects
+			case <-c.Done():
+			}
+			err = c.Err()
+		}
+
+		p.mu.Lock()
+		p.client = nil
+		p.lastErr = err
+		p.mu.Unlock()
+
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxReconnectDelay)
+	}
+}
+
+// connected starts a session: the broker just replaced our retained
+// availability with nothing or the last will, so it is sent again.
+func (p *Publisher) connected(c *Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.client, p.availSent, p.announced = c, "", false
+	if p.polled {
+		p.publishAvailability()
+	}
+}
+
+// Log publishes the sample, announcing the sensors first on a new
+// connection or device. While the broker is unreachable it returns the
+// connection error once.
+func (p *Publisher) Log(data *models.CombinedStats) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reachable, p.polled = true, true
+	if p.client == nil {
+		err := p.lastErr
+		p.lastErr = nil
+		return err
+	}
+
+	if !p.cfg.DisableDiscovery && (!p.announced || p.device != data.Gateway.Device) {
+		if err := p.announce(data.Gateway.Device); err != nil {
+			return err
+		}
+	}
+	if err := p.publishAvailability(); err != nil {
+		return err
+	}
+
+	state, err := json.Marshal(newState(data))
+	if err != nil {
+		return err
+	}
+	if err := p.client.Publish(Message{Topic: p.topic("state"), Payload: state, Retain: true}); err != nil {
+		return err
+	}
+	sample, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return p.client.Publish(Message{Topic: p.topic("sample"), Payload: sample})
+}
+
+// GatewayError marks the gateway unavailable.
+func (p *Publisher) GatewayError(error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reachable, p.polled = false, true
+	if p.client == nil {
+		return nil
+	}
+	return p.publishAvailability()
+}
+
+// publishAvailability sends the gateway state if it changed. p.mu is held.
+func (p *Publisher) publishAvailability() error {
+	want := Offline
+	if p.reachable {
+		want = Online
+	}
+	if want == p.availSent {
+		return nil
+	}
+	if err := p.client.Publish(Message{Topic: p.topic("availability"), Payload: []byte(want), Retain: true}); err != nil {
+		return err
+	}
+	p.availSent = want
+	return nil
+}
+
+// Close marks the gateway offline (a clean disconnect suppresses the last
+// will) and disconnects.
+func (p *Publisher) Close() error {
+	close(p.stop)
+	<-p.done
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client == nil {
+		return nil
+	}
+	err := p.client.Publish(Message{Topic: p.topic("availability"), Payload: []byte(Offline), Retain: true})
+	if cerr := p.client.Close(); err == nil {
+		err = cerr
+	}
+	p.client = nil
+	return err
+}
+
+// state is the flat JSON the discovered sensors read, describing the
+// primary radio (5G when connected, otherwise LTE) and the primary ping
+// target.
+type state struct {
+	Time     int64   `json:"time"`
+	Radio    string  `json:"radio,omitempty"`
+	Band     string  `json:"band"`
+	Tower    int     `json:"tower"`
+	PCID     int     `json:"pcid"`
+	RSRP     int     `json:"rsrp"`
+	RSRQ     int     `json:"rsrq"`
+	RSSI     int     `json:"rssi"`
+	SINR     int     `json:"sinr"`
+	Bars     float64 `json:"bars"`
+	Health   float64 `json:"health"`
+	Ping     float64 `json:"ping"`
+	Jitter   float64 `json:"jitter"`
+	PingLoss float64 `json:"ping_loss"`
+}
+
+func newState(data *models.CombinedStats) state {
+	s := state{
+		Time:     data.Gateway.Time.LocalTime,
+		Ping:     data.Ping.Avg,
+		Jitter:   data.Ping.StdDev,
+		PingLoss: data.Ping.Loss,
+	}
+
+	radio, name, tower := &data.Gateway.Signal.FiveG, "5g", data.Gateway.Signal.FiveG.GNBID
+	if len(radio.Bands) == 0 && radio.Bars == 0 {
+		radio, name, tower = &data.Gateway.Signal.FourG, "4g", data.Gateway.Signal.FourG.EID
+		if len(radio.Bands) == 0 && radio.Bars == 0 {
+			return s // Not connected
+		}
+	}
+	s.Radio, s.Tower = name, tower
+	s.Band = strings.Join(radio.Bands, ",")
+	s.PCID = radio.PCID
+	s.RSRP, s.RSRQ, s.RSSI, s.SINR = radio.RSRP, radio.RSRQ, radio.RSSI, radio.SINR
+	s.Bars = radio.Bars
+	s.Health = analysis.CalculateSignalHealth(radio.RSRP, radio.SINR)
+	return s
+}
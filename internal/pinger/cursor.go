@@ -0,0 +1,33 @@
+package pinger
+
+import (
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// Cursor tracks one consumer's position in the Pinger's result history.
+// Successive calls to Next return adjacent, non-overlapping windows, which
+// replaces the old destructive "get and reset" pattern.
+type Cursor struct {
+	p    *Pinger
+	last time.Time
+	mu   sync.Mutex
+}
+
+// NewCursor returns a cursor whose first window covers all retained history.
+func (p *Pinger) NewCursor() *Cursor {
+	return &Cursor{p: p}
+}
+
+// Next returns statistics for results recorded since the previous call.
+func (c *Cursor) Next() models.PingStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	stats := c.p.statsBetween(c.last, now)
+	c.last = now
+	return stats
+}
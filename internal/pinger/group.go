@@ -0,0 +1,65 @@
+package pinger
+
+import (
+	"context"
+
+	"tmobile-stats/internal/models"
+)
+
+// Group runs several Pingers side by side, e.g. the same host over IPv4 and
+// IPv6 or with different DSCP markings. The first Pinger is the primary
+// target whose stats fill CombinedStats.Ping.
+type Group struct {
+	Pingers []*Pinger
+}
+
+// NewGroup creates a group from the given pingers. At least one is required.
+func NewGroup(pingers ...*Pinger) *Group {
+	return &Group{Pingers: pingers}
+}
+
+// Primary returns the first Pinger in the group.
+func (g *Group) Primary() *Pinger {
+	return g.Pingers[0]
+}
+
+// Run starts every Pinger in the group and blocks until ctx is cancelled.
+func (g *Group) Run(ctx context.Context) {
+	for _, p := range g.Pingers[1:] {
+		go p.Run(ctx)
+	}
+	g.Primary().Run(ctx)
+}
+
+// SetRaw sends every Pinger's per-packet results to w.
+func (g *Group) SetRaw(w ResultWriter) {
+	for _, p := range g.Pingers {
+		p.Raw = w
+	}
+}
+
+// GroupCursor holds one Cursor per Pinger in a Group.
+type GroupCursor struct {
+	cursors []*Cursor
+}
+
+// NewCursor returns a cursor over all Pingers in the group.
+func (g *Group) NewCursor() *GroupCursor {
+	gc := &GroupCursor{}
+	for _, p := range g.Pingers {
+		gc.cursors = append(gc.cursors, p.NewCursor())
+	}
+	return gc
+}
+
+// Next returns the primary target's window stats plus the stats for every
+// additional target, all covering the time since the previous call.
+func (gc *GroupCursor) Next() (models.PingStats, []models.PingStats) {
+	primary := gc.cursors[0].Next()
+
+	var extra []models.PingStats
+	for _, c := range gc.cursors[1:] {
+		extra = append(extra, c.Next())
+	}
+	return primary, extra
+}
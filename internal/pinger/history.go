@@ -0,0 +1,91 @@
+package pinger
+
+import (
+	"math"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// DefaultHistorySize is the number of probe results retained for window queries.
+// At the default 1s probe interval this covers one hour.
+const DefaultHistorySize = 3600
+
+// entry is a probe result plus the moment it became known.
+// Windows are selected on the recorded time so that a probe still in flight
+// when a consumer takes a snapshot lands in that consumer's next window.
+type entry struct {
+	result   models.PingResult
+	recorded time.Time
+}
+
+// ring is a fixed-capacity circular buffer of entries.
+type ring struct {
+	entries []entry
+	start   int
+	size    int
+}
+
+func newRing(capacity int) *ring {
+	return &ring{entries: make([]entry, capacity)}
+}
+
+// push appends e, overwriting the oldest entry once the buffer is full.
+func (r *ring) push(e entry) {
+	if len(r.entries) == 0 {
+		return
+	}
+	if r.size < len(r.entries) {
+		r.entries[(r.start+r.size)%len(r.entries)] = e
+		r.size++
+		return
+	}
+	r.entries[r.start] = e
+	r.start = (r.start + 1) % len(r.entries)
+}
+
+// each calls fn for every entry, oldest first.
+func (r *ring) each(fn func(e entry)) {
+	for i := 0; i < r.size; i++ {
+		fn(r.entries[(r.start+i)%len(r.entries)])
+	}
+}
+
+// accumulator folds probe results into PingStats using Welford's algorithm.
+type accumulator struct {
+	stats models.PingStats
+	m2    float64
+}
+
+func (a *accumulator) add(r models.PingResult) {
+	s := &a.stats
+	s.Sent++
+
+	if !r.Lost {
+		s.Received++
+		s.LastRTT = r.RTT
+
+		if s.Received == 1 {
+			s.Min = r.RTT
+			s.Max = r.RTT
+			s.Avg = r.RTT
+			s.StdDev = 0
+			a.m2 = 0
+		} else {
+			if r.RTT < s.Min {
+				s.Min = r.RTT
+			}
+			if r.RTT > s.Max {
+				s.Max = r.RTT
+			}
+
+			delta := r.RTT - s.Avg
+			s.Avg += delta / float64(s.Received)
+			delta2 := r.RTT - s.Avg
+			a.m2 += delta * delta2
+			s.StdDev = math.Sqrt(a.m2 / float64(s.Received))
+		}
+	}
+
+	s.Loss = float64(s.Sent-s.Received) / float64(s.Sent) * 100
+}
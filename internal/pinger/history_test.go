@@ -0,0 +1,100 @@
+package pinger
+
+import (
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+func TestRingWraparound(t *testing.T) {
+	r := newRing(3)
+	for i := 0; i < 5; i++ {
+		r.push(entry{result: models.PingResult{Seq: i}})
+	}
+
+	var seqs []int
+	r.each(func(e entry) {
+		seqs = append(seqs, e.result.Seq)
+	})
+
+	expected := []int{2, 3, 4}
+	if len(seqs) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(seqs))
+	}
+	for i := range expected {
+		if seqs[i] != expected[i] {
+			t.Errorf("Entry %d: expected seq %d, got %d", i, expected[i], seqs[i])
+		}
+	}
+}
+
+func TestAccumulator(t *testing.T) {
+	var acc accumulator
+	acc.add(models.PingResult{RTT: 10})
+	acc.add(models.PingResult{RTT: 30})
+	acc.add(models.PingResult{Lost: true})
+	acc.add(models.PingResult{RTT: 20})
+
+	s := acc.stats
+	if s.Sent != 4 || s.Received != 3 {
+		t.Errorf("Expected 4 sent / 3 received, got %d / %d", s.Sent, s.Received)
+	}
+	if s.Min != 10 || s.Max != 30 || s.Avg != 20 {
+		t.Errorf("Unexpected min/avg/max: %v/%v/%v", s.Min, s.Avg, s.Max)
+	}
+	if s.Loss != 25 {
+		t.Errorf("Expected 25%% loss, got %v", s.Loss)
+	}
+	if s.LastRTT != 20 {
+		t.Errorf("Expected LastRTT 20, got %v", s.LastRTT)
+	}
+}
+
+func TestCursorsAreIndependent(t *testing.T) {
+	p := NewPinger("127.0.0.1", time.Second)
+	a := p.NewCursor()
+	b := p.NewCursor()
+
+	p.record(time.Now(), 10, false)
+	p.record(time.Now(), 0, true)
+
+	if s := a.Next(); s.Sent != 2 {
+		t.Errorf("Cursor A: expected 2 sent, got %d", s.Sent)
+	}
+
+	p.record(time.Now(), 30, false)
+
+	// A only sees the new result; B still sees everything
+	if s := a.Next(); s.Sent != 1 || s.Avg != 30 {
+		t.Errorf("Cursor A: expected 1 sent at 30ms, got %+v", s)
+	}
+	if s := b.Next(); s.Sent != 3 || s.Received != 2 {
+		t.Errorf("Cursor B: expected 3 sent / 2 received, got %+v", s)
+	}
+
+	// Window queries have no side effects on cursors or lifetime stats
+	if s := p.StatsForWindow(time.Minute); s.Sent != 3 {
+		t.Errorf("StatsForWindow: expected 3 sent, got %d", s.Sent)
+	}
+	if s := p.LifetimeStats(); s.Sent != 3 || s.Received != 2 {
+		t.Errorf("LifetimeStats: expected 3 sent / 2 received, got %+v", s)
+	}
+	if s := a.Next(); s.Sent != 0 || s.LastRTT != 30 {
+		t.Errorf("Cursor A: expected empty window preserving LastRTT, got %+v", s)
+	}
+}
+
+func TestStatsSince(t *testing.T) {
+	p := NewPinger("127.0.0.1", time.Second)
+	p.record(time.Now(), 10, false)
+	mark := time.Now()
+	p.record(time.Now(), 20, false)
+
+	if s := p.StatsSince(mark); s.Sent != 1 || s.Avg != 20 {
+		t.Errorf("Expected only the result after mark, got %+v", s)
+	}
+	if n := len(p.ResultsSince(time.Time{})); n != 2 {
+		t.Errorf("Expected 2 results, got %d", n)
+	}
+}
@@ -0,0 +1,86 @@
+package pinger
+
+import (
+	"errors"
+	"fmt"
+
+	"tmobile-stats/internal/models"
+)
+
+// Header overhead added to the ICMP payload: IP header plus 8 byte ICMP header.
+const (
+	IPv4Overhead = 28
+	IPv6Overhead = 48
+)
+
+// MTUResult describes the outcome of a path MTU sweep.
+type MTUResult struct {
+	Payload int // Largest payload that got through unfragmented
+	MTU     int // Payload plus IP/ICMP header overhead
+	Probes  int // Number of probes sent
+}
+
+// SweepMTU binary-searches the largest ICMP payload in [min, max] bytes that
+// reaches target with the don't-fragment bit set. Each size is probed up to
+// attempts times so a single lost packet is not mistaken for a size limit.
+func SweepMTU(target string, opts models.PingOptions, min, max, attempts int) (MTUResult, error) {
+	if min < 1 || max < min {
+		return MTUResult{}, fmt.Errorf("invalid payload range %d-%d", min, max)
+	}
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	opts.DontFragment = true
+	overhead := IPv4Overhead
+	if opts.Network == "ip6" {
+		overhead = IPv6Overhead
+	}
+
+	var result MTUResult
+	passes := func(size int) (bool, error) {
+		opts.Size = size
+		for i := 0; i < attempts; i++ {
+			result.Probes++
+			_, err := probeOnce(target, opts)
+			if err == nil {
+				return true, nil
+			}
+			if !errors.Is(err, errNoReply) {
+				// Oversized packets with DF set are rejected locally with EMSGSIZE;
+				// treat that as "too big" rather than a fatal error.
+				if size > min {
+					return false, nil
+				}
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	ok, err := passes(min)
+	if err != nil {
+		return result, err
+	}
+	if !ok {
+		return result, fmt.Errorf("no reply from %s even at %d bytes", target, min)
+	}
+
+	lo, hi := min, max
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		ok, err := passes(mid)
+		if err != nil {
+			return result, err
+		}
+		if ok {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+
+	result.Payload = lo
+	result.MTU = lo + overhead
+	return result, nil
+}
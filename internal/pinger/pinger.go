@@ -2,25 +2,32 @@ package pinger
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math"
 	"os"
-	"strings"
 	"sync"
 	"time"
 
-	probing "github.com/prometheus-community/pro-bing"
 	"tmobile-stats/internal/models"
 )
 
-// Pinger manages the background ping process.
+// ResultWriter receives every individual probe result.
+type ResultWriter interface {
+	WriteResult(r models.PingResult) error
+}
+
+// Pinger manages the background ping process for a single target.
+// Every probe result is kept in a timestamped ring buffer, so any number of
+// consumers can query statistics over their own windows without affecting
+// each other.
 type Pinger struct {
 	Target   string
 	Interval time.Duration
-	stats    models.PingStats // Reset every interval
-	lifetime models.PingStats // Cumulative for session
-	m2       float64          // for interval variance
-	lifeM2   float64          // for lifetime variance
+	Options  models.PingOptions // ICMP packet parameters
+	Raw      ResultWriter       // Optional per-packet log
+	seq      int                // Probe sequence number
+	history  *ring              // Recent results, oldest first
+	lifetime accumulator        // Cumulative for session, fed from the same results
 	mu       sync.RWMutex
 }
 
@@ -29,6 +36,7 @@ func NewPinger(target string, interval time.Duration) *Pinger {
 	return &Pinger{
 		Target:   target,
 		Interval: interval,
+		history:  newRing(DefaultHistorySize),
 	}
 }
 
@@ -50,131 +58,115 @@ func (p *Pinger) Run(ctx context.Context) {
 	}
 }
 
-// GetStats returns a copy of the current statistics.
-func (p *Pinger) GetStats() models.PingStats {
+// LifetimeStats returns the cumulative statistics for the session.
+func (p *Pinger) LifetimeStats() models.PingStats {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	return p.stats
+	return p.label(p.lifetime.stats)
+}
+
+// StatsSince returns statistics for all results recorded after t.
+// Windows reaching further back than the retained history are truncated.
+func (p *Pinger) StatsSince(t time.Time) models.PingStats {
+	return p.statsBetween(t, time.Now())
+}
+
+// StatsForWindow returns statistics for the trailing window of length d.
+func (p *Pinger) StatsForWindow(d time.Duration) models.PingStats {
+	now := time.Now()
+	return p.statsBetween(now.Add(-d), now)
+}
+
+// ResultsSince returns a copy of the individual results recorded after t, oldest first.
+func (p *Pinger) ResultsSince(t time.Time) []models.PingResult {
+	return p.ResultsBetween(t, time.Now())
 }
 
-// GetLifetimeStats returns the cumulative statistics for the session.
-func (p *Pinger) GetLifetimeStats() models.PingStats {
+// ResultsBetween returns a copy of the individual results recorded in
+// (from, to], oldest first. Adjacent windows never overlap.
+func (p *Pinger) ResultsBetween(from, to time.Time) []models.PingResult {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
-	return p.lifetime
+
+	var results []models.PingResult
+	p.history.each(func(e entry) {
+		if e.recorded.After(from) && !e.recorded.After(to) {
+			results = append(results, e.result)
+		}
+	})
+	return results
 }
 
-// GetStatsAndReset returns the current statistics and resets the internal counters.
-// This is useful for interval-based reporting (e.g. "stats for the last 10 seconds").
-func (p *Pinger) GetStatsAndReset() models.PingStats {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	// Capture current state
-	currentStats := p.stats
-
-	// Reset state for next window
-	p.stats = models.PingStats{
-		Min:      0,
-		Max:      0,
-		Avg:      0,
-		StdDev:   0,
-		Loss:     0,
-		Sent:     0,
-		Received: 0,
-		LastRTT:  currentStats.LastRTT, // Preserve LastRTT for continuity context if needed
+// statsBetween folds the results recorded in (from, to] into a summary.
+func (p *Pinger) statsBetween(from, to time.Time) models.PingStats {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	var acc accumulator
+	p.history.each(func(e entry) {
+		if e.recorded.After(from) && !e.recorded.After(to) {
+			acc.add(e.result)
+		}
+	})
+
+	// Preserve LastRTT for continuity when the window saw no replies
+	if acc.stats.Received == 0 {
+		acc.stats.LastRTT = p.lifetime.stats.LastRTT
 	}
-	p.m2 = 0
+	return p.label(acc.stats)
+}
 
-	return currentStats
+// label tags stats with the target and packet options they were measured with.
+func (p *Pinger) label(s models.PingStats) models.PingStats {
+	s.Target = p.Target
+	s.Options = p.loggedOptions()
+	return s
 }
 
-func (p *Pinger) ping() {
-	// Create a new pro-bing pinger
-	pinger, err := probing.NewPinger(p.Target)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing pinger: %v\n", err)
-		p.recordLoss()
-		return
+// loggedOptions returns the non-default packet options, or nil when all are defaults.
+func (p *Pinger) loggedOptions() *models.PingOptions {
+	if p.Options == (models.PingOptions{}) {
+		return nil
 	}
+	opts := p.Options
+	return &opts
+}
 
-	pinger.Count = 1
-	// DECOUPLED TIMEOUT: Allow 2.5 seconds for the packet to return,
-	// even if our loop interval is 1s. This handles system jitter/spikes without false loss.
-	pinger.Timeout = 2500 * time.Millisecond
-
-	// On macOS, unprivileged ping might be needed if sudo is not used,
-	// but we will assume sudo per user request for "native" behavior.
-	// However, setting SetPrivileged(true) is safer for ICMP on most systems if running as root.
-	pinger.SetPrivileged(true)
+func (p *Pinger) ping() {
+	sentAt := time.Now()
 
-	err = pinger.Run() // Blocks until finished
+	rtt, err := probeOnce(p.Target, p.Options)
 	if err != nil {
-		if strings.Contains(err.Error(), "operation not permitted") || strings.Contains(err.Error(), "permission denied") {
-			fmt.Fprintf(os.Stderr, "Ping Error: Permission denied. ICMP ping requires root privileges (sudo).\n")
-		} else {
-			fmt.Fprintf(os.Stderr, "Error running ping: %v\n", err)
+		if !errors.Is(err, errNoReply) {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
 		}
-		p.recordLoss()
+		p.record(sentAt, 0, true)
 		return
 	}
 
-	stats := pinger.Statistics()
-	if stats.PacketsRecv == 0 {
-		p.recordLoss()
-		return
-	}
-
-	// Success
-	rtt := float64(stats.AvgRtt.Milliseconds()) // stats.AvgRtt is the only RTT for Count=1
-
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	p.updateStats(&p.stats, rtt, &p.m2)
-	p.updateStats(&p.lifetime, rtt, &p.lifeM2)
+	p.record(sentAt, rtt, false)
 }
 
-func (p *Pinger) recordLoss() {
+// record stores a single probe outcome and forwards it to the raw log, if configured.
+func (p *Pinger) record(sentAt time.Time, rtt float64, lost bool) {
 	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	p.stats.Sent++
-	p.lifetime.Sent++
+	r := models.PingResult{
+		Time:    sentAt,
+		Target:  p.Target,
+		Seq:     p.seq,
+		RTT:     rtt,
+		Lost:    lost,
+		Options: p.loggedOptions(),
+	}
+	p.seq++
+	p.history.push(entry{result: r, recorded: time.Now()})
+	p.lifetime.add(r)
+	p.mu.Unlock()
 
-	// Recalc loss
-	if p.stats.Sent > 0 {
-		p.stats.Loss = float64(p.stats.Sent-p.stats.Received) / float64(p.stats.Sent) * 100
+	if p.Raw == nil {
+		return
 	}
-	if p.lifetime.Sent > 0 {
-		p.lifetime.Loss = float64(p.lifetime.Sent-p.lifetime.Received) / float64(p.lifetime.Sent) * 100
+	if err := p.Raw.WriteResult(r); err != nil {
+		fmt.Fprintf(os.Stderr, "Raw ping log error: %v\n", err)
 	}
 }
-
-func (p *Pinger) updateStats(s *models.PingStats, rtt float64, m2 *float64) {
-	s.Sent++
-	s.Received++
-	s.LastRTT = rtt
-	s.Loss = float64(s.Sent-s.Received) / float64(s.Sent) * 100
-
-	if s.Min == 0 {
-		s.Min = rtt
-		s.Max = rtt
-		s.Avg = rtt
-		s.StdDev = 0
-		*m2 = 0
-	} else {
-		if rtt < s.Min {
-			s.Min = rtt
-		}
-		if rtt > s.Max {
-			s.Max = rtt
-		}
-
-		delta := rtt - s.Avg
-		s.Avg += delta / float64(s.Received)
-		delta2 := rtt - s.Avg
-		*m2 += delta * delta2
-		s.StdDev = math.Sqrt(*m2 / float64(s.Received))
-	}
-}
\ No newline at end of file
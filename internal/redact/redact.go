@@ -0,0 +1,160 @@
+// Package redact scrubs identifying details from samples so logs can be
+// shared in public: the gateway serial and MAC address, the tower and cell
+// IDs, and IP addresses. Timestamps can be shifted as well.
+//
+// In hash mode identifiers are replaced by keyed pseudonyms, so the same
+// tower keeps the same ID throughout and handovers can still be analysed,
+// but the real values can't be recovered without the key. Drop mode clears
+// them instead.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"slices"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// Options configure a Redactor.
+type Options struct {
+	Mode  string        // "hash" (default), "drop", or "none" to only shift timestamps
+	Key   string        // Secret behind the pseudonyms; random if empty
+	Shift time.Duration // Added to every timestamp
+}
+
+// Redactor applies Options to samples. It is safe for concurrent use.
+type Redactor struct {
+	drop  bool
+	keep  bool // Mode "none"
+	key   []byte
+	shift time.Duration
+}
+
+// New returns a Redactor. Without a key, pseudonyms are only consistent
+// within the Redactor's lifetime.
+func New(opts Options) (*Redactor, error) {
+	r := &Redactor{shift: opts.Shift}
+	switch opts.Mode {
+	case "", "hash":
+	case "drop":
+		r.drop = true
+	case "none":
+		r.keep = true
+	default:
+		return nil, fmt.Errorf("unknown redaction mode %q (want hash, drop or none)", opts.Mode)
+	}
+	if opts.Key != "" {
+		r.key = []byte(opts.Key)
+	} else {
+		r.key = make([]byte, 32)
+		if _, err := rand.Read(r.key); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// sum is the keyed hash of value; kind keeps equal values of different
+// fields apart.
+func (r *Redactor) sum(kind, value string) []byte {
+	m := hmac.New(sha256.New, r.key)
+	m.Write([]byte(kind))
+	m.Write([]byte{0})
+	m.Write([]byte(value))
+	return m.Sum(nil)
+}
+
+// str redacts an identifying string; empty stays empty.
+func (r *Redactor) str(kind, v string) string {
+	if v == "" || r.keep {
+		return v
+	}
+	if r.drop {
+		return ""
+	}
+	return kind + "-" + hex.EncodeToString(r.sum(kind, v)[:6])
+}
+
+// id redacts a numeric ID; zero (not connected) stays zero. Pseudonyms are
+// positive and below 2^31.
+func (r *Redactor) id(kind string, v int) int {
+	if v == 0 || r.keep {
+		return v
+	}
+	if r.drop {
+		return 0
+	}
+	n := int(binary.BigEndian.Uint32(r.sum(kind, fmt.Sprint(v))) & 0x7fffffff)
+	return max(n, 1)
+}
+
+// ipv4Candidate and ipv6Candidate match what might be addresses; each match
+// is confirmed by parsing, so times like 12:30:45 are left alone. IPv4 goes
+// first so a port after it (1.2.3.4:53) doesn't hide the address.
+var (
+	ipv4Candidate = regexp.MustCompile(`\d{1,3}(?:\.\d{1,3}){3}`)
+	ipv6Candidate = regexp.MustCompile(`[0-9A-Fa-f]*:[0-9A-Fa-f:]*[0-9A-Fa-f:]`)
+)
+
+// Text replaces every IP address in s.
+func (r *Redactor) Text(s string) string {
+	if r.keep {
+		return s
+	}
+	replace := func(m string) string {
+		if net.ParseIP(m) == nil {
+			return m
+		}
+		if r.drop {
+			return "ip-redacted"
+		}
+		return r.str("ip", m)
+	}
+	s = ipv4Candidate.ReplaceAllStringFunc(s, replace)
+	return ipv6Candidate.ReplaceAllStringFunc(s, replace)
+}
+
+// Sample returns a redacted copy of s.
+func (r *Redactor) Sample(s *models.CombinedStats) *models.CombinedStats {
+	out := *s
+	dev := &out.Gateway.Device
+	dev.Serial = r.str("serial", dev.Serial)
+	dev.MacID = r.str("mac", dev.MacID)
+	for _, c := range []*models.ConnectionStats{&out.Gateway.Signal.FiveG, &out.Gateway.Signal.FourG} {
+		c.CID = r.id("cid", c.CID)
+		c.GNBID = r.id("tower", c.GNBID)
+		c.EID = r.id("tower", c.EID)
+	}
+	if out.Gateway.Time.LocalTime != 0 {
+		out.Gateway.Time.LocalTime += int64(r.shift / time.Second)
+	}
+
+	out.Ping.Target = r.Text(out.Ping.Target)
+	out.Targets = slices.Clone(out.Targets)
+	for i := range out.Targets {
+		out.Targets[i].Target = r.Text(out.Targets[i].Target)
+	}
+	out.BurstReason = r.Text(out.BurstReason)
+	out.Annotations = slices.Clone(out.Annotations)
+	for i := range out.Annotations {
+		out.Annotations[i] = r.Text(out.Annotations[i])
+	}
+	return &out
+}
+
+// Result returns a redacted copy of a per-packet ping result.
+func (r *Redactor) Result(res models.PingResult) models.PingResult {
+	res.Target = r.Text(res.Target)
+	if !res.Time.IsZero() {
+		res.Time = res.Time.Add(r.shift)
+	}
+	return res
+}
@@ -0,0 +1,90 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+func sample(gnb int) *models.CombinedStats {
+	s := &models.CombinedStats{
+		Ping:        models.PingStats{Avg: 25, Target: "192.168.12.1"},
+		Targets:     []models.PingStats{{Target: "2001:4860:4860::8888"}, {Target: "one.one.one.one"}},
+		Annotations: []string{"moved gateway, now at 10.0.0.7:8080 after 12:30:45"},
+	}
+	s.Gateway.Time.LocalTime = 1767614400
+	s.Gateway.Device = models.DeviceInfo{Model: "G4AR", Serial: "ABC123", MacID: "00:11:22:33:44:55"}
+	s.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{"n41"}, CID: 311, GNBID: gnb, RSRP: -90}
+	return s
+}
+
+func TestHash(t *testing.T) {
+	r, err := New(Options{Key: "secret", Shift: -24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := sample(1234567)
+	got := r.Sample(orig)
+
+	if orig.Gateway.Device.Serial != "ABC123" || orig.Targets[0].Target != "2001:4860:4860::8888" {
+		t.Fatal("Sample modified its input")
+	}
+	dev := got.Gateway.Device
+	if dev.Serial == "ABC123" || !strings.HasPrefix(dev.Serial, "serial-") || strings.Contains(dev.MacID, "00:11") {
+		t.Errorf("Device not scrubbed: %+v", dev)
+	}
+	if dev.Model != "G4AR" || got.Gateway.Signal.FiveG.RSRP != -90 {
+		t.Error("Non-identifying fields changed")
+	}
+	if got.Gateway.Signal.FiveG.GNBID == 1234567 || got.Gateway.Signal.FiveG.GNBID <= 0 {
+		t.Errorf("GNBID = %d, want a positive pseudonym", got.Gateway.Signal.FiveG.GNBID)
+	}
+	if got.Gateway.Time.LocalTime != orig.Gateway.Time.LocalTime-86400 {
+		t.Errorf("LocalTime = %d, want shifted back a day", got.Gateway.Time.LocalTime)
+	}
+
+	for _, text := range []string{got.Ping.Target, got.Targets[0].Target, got.Annotations[0]} {
+		for _, ip := range []string{"192.168.12.1", "2001:4860", "10.0.0.7"} {
+			if strings.Contains(text, ip) {
+				t.Errorf("%q still contains %s", text, ip)
+			}
+		}
+	}
+	if got.Targets[1].Target != "one.one.one.one" || !strings.HasSuffix(got.Annotations[0], ":8080 after 12:30:45") {
+		t.Errorf("Text other than IPs changed: %q, %q", got.Targets[1].Target, got.Annotations[0])
+	}
+
+	// Consistent within and across redactors with the same key, so a tower
+	// keeps its identity and handovers still show
+	again, _ := New(Options{Key: "secret"})
+	if a, b := got.Gateway.Signal.FiveG.GNBID, again.Sample(sample(1234567)).Gateway.Signal.FiveG.GNBID; a != b {
+		t.Errorf("Same tower hashed to %d and %d", a, b)
+	}
+	if r.Sample(sample(7654321)).Gateway.Signal.FiveG.GNBID == got.Gateway.Signal.FiveG.GNBID {
+		t.Error("Different towers hashed to the same ID")
+	}
+	other, _ := New(Options{Key: "other"})
+	if other.Sample(orig).Gateway.Device.Serial == dev.Serial {
+		t.Error("Pseudonyms don't depend on the key")
+	}
+}
+
+func TestDrop(t *testing.T) {
+	r, err := New(Options{Mode: "drop"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := r.Sample(sample(1234567))
+	if got.Gateway.Device.Serial != "" || got.Gateway.Device.MacID != "" || got.Gateway.Signal.FiveG.GNBID != 0 || got.Gateway.Signal.FiveG.CID != 0 {
+		t.Errorf("Identifiers not dropped: %+v %+v", got.Gateway.Device, got.Gateway.Signal.FiveG)
+	}
+	if got.Ping.Target != "ip-redacted" {
+		t.Errorf("Target = %q, want ip-redacted", got.Ping.Target)
+	}
+
+	if _, err := New(Options{Mode: "blur"}); err == nil {
+		t.Error("Expected an error for an unknown mode")
+	}
+}
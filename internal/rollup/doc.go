@@ -0,0 +1,14 @@
+// Package rollup keeps long-term history compact by summarising samples
+// into 1-minute and 1-hour buckets: min/avg/max and percentiles of the
+// radio and ping metrics, the dominant band and tower, and loss totals.
+//
+// Rollups live in a directory next to the raw log (stats.log rolls up into
+// stats.rollup), one JSON line per bucket, partitioned so retention can drop
+// whole files:
+//
+//	1m/2026-01.jsonl   one file per month of minute buckets
+//	1h/2026.jsonl      one file per year of hour buckets
+//
+// Readers convert buckets back into weighted samples, so the analysis and
+// charts work on any resolution unchanged.
+package rollup
@@ -0,0 +1,167 @@
+package rollup
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// Retention is how long the buckets of each resolution are kept; a missing
+// or zero entry keeps them forever.
+type Retention map[time.Duration]time.Duration
+
+// Roller turns a stream of samples into stored rollups of every resolution.
+// It implements logger.Logger, so it can be fed like any other sink.
+//
+// A bucket is written once a sample of a later bucket arrives, so the one
+// in progress is lost on shutdown; CatchUp rebuilds it from the raw log on
+// the next start.
+type Roller struct {
+	store     *Store
+	retention Retention
+	now       func() time.Time
+
+	mu       sync.Mutex
+	builders []*Builder  // One per entry of Resolutions
+	last     []time.Time // Start of the newest stored bucket per resolution
+	pruned   time.Time   // When retention was last applied
+	fed      time.Time   // Newest sample added, so none is counted twice
+	catching bool        // Live samples are held back while catching up
+	held     []models.CombinedStats
+	closed   bool
+}
+
+// ScanFunc calls fn with every raw sample from since on, oldest first.
+type ScanFunc func(since time.Time, fn func(s *models.CombinedStats) error) error
+
+// errClosed stops a catch-up still running when the roller is closed.
+var errClosed = errors.New("rollup closed")
+
+// Open continues the rollups stored in dir.
+func Open(dir string, ret Retention) (*Roller, error) {
+	r := &Roller{store: &Store{Dir: dir}, retention: ret, now: time.Now}
+	for _, res := range Resolutions {
+		last, err := r.store.Last(res)
+		if err != nil {
+			return nil, err
+		}
+		r.builders = append(r.builders, NewBuilder(res))
+		r.last = append(r.last, last)
+	}
+	return r, nil
+}
+
+// Since returns the time of the oldest sample still needed: the end of the
+// newest stored bucket of the coarsest-lagging resolution, or the zero time
+// when a resolution has nothing stored yet.
+func (r *Roller) Since() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.since()
+}
+
+func (r *Roller) since() time.Time {
+	var since time.Time
+	for i, res := range Resolutions {
+		if r.last[i].IsZero() {
+			return time.Time{}
+		}
+		end := r.last[i].Add(res)
+		if since.IsZero() || end.Before(since) {
+			since = end
+		}
+	}
+	return since
+}
+
+// CatchUp rolls up the raw samples logged since the newest stored buckets
+// in the background, holding back live samples until it is done. The
+// returned channel receives the outcome.
+func (r *Roller) CatchUp(scan ScanFunc) <-chan error {
+	r.mu.Lock()
+	r.catching = true
+	since := r.since()
+	r.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		err := scan(since, func(s *models.CombinedStats) error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.closed {
+				return errClosed
+			}
+			return r.add(s)
+		})
+		if errors.Is(err, errClosed) {
+			err = nil
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.catching = false
+		errs := []error{err}
+		for i := range r.held {
+			errs = append(errs, r.add(&r.held[i]))
+		}
+		r.held = nil
+		done <- errors.Join(errs...)
+	}()
+	return done
+}
+
+func (r *Roller) Log(s *models.CombinedStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.catching {
+		r.held = append(r.held, *s)
+		return nil
+	}
+	return r.add(s)
+}
+
+func (r *Roller) add(s *models.CombinedStats) error {
+	t := time.Unix(s.Gateway.Time.LocalTime, 0).UTC()
+	if !t.After(r.fed) {
+		return nil
+	}
+	r.fed = t
+
+	var errs []error
+	for i, res := range Resolutions {
+		// Skip samples whose bucket is already stored, as when catching up
+		if !r.last[i].IsZero() && !t.Truncate(res).After(r.last[i]) {
+			continue
+		}
+		done := r.builders[i].Add(s)
+		if done == nil {
+			continue
+		}
+		if err := r.store.Append(res, done); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		r.last[i] = done.Start
+	}
+
+	// Partitions span months, so checking hourly is plenty
+	if now := r.now(); now.Sub(r.pruned) >= time.Hour {
+		r.pruned = now
+		for _, res := range Resolutions {
+			if err := r.store.Prune(res, r.retention[res], now); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close stops a running catch-up. The bucket in progress is not written.
+func (r *Roller) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	return nil
+}
@@ -0,0 +1,351 @@
+package rollup
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// Resolutions are the bucket sizes rolled up, finest first.
+var Resolutions = []time.Duration{time.Minute, time.Hour}
+
+// Name returns the short name of a resolution used in paths and flags:
+// "1m", "1h", or "raw" for zero.
+func Name(res time.Duration) string {
+	switch res {
+	case 0:
+		return "raw"
+	case time.Minute:
+		return "1m"
+	case time.Hour:
+		return "1h"
+	}
+	return res.String()
+}
+
+// Parse is the inverse of Name for "raw" and the rolled-up resolutions.
+func Parse(name string) (time.Duration, error) {
+	if name == "raw" {
+		return 0, nil
+	}
+	for _, res := range Resolutions {
+		if name == Name(res) {
+			return res, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown resolution %q (want raw, 1m or 1h)", name)
+}
+
+// Summary describes the distribution of one metric within a bucket.
+type Summary struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+	P50 float64 `json:"p50"`
+	P95 float64 `json:"p95"`
+}
+
+// Radio summarises one radio while it was connected.
+type Radio struct {
+	Samples int     `json:"samples"` // Samples with the radio connected
+	Band    string  `json:"band,omitempty"`
+	Tower   int     `json:"tower,omitempty"` // gNB ID for 5G, eNB ID for LTE
+	PCID    int     `json:"pcid,omitempty"`
+	Bars    Summary `json:"bars"`
+	RSRP    Summary `json:"rsrp"`
+	RSRQ    Summary `json:"rsrq"`
+	RSSI    Summary `json:"rssi"`
+	SINR    Summary `json:"sinr"`
+}
+
+// Rollup summarises the samples of one bucket. Band, tower and PCID are
+// the ones seen most often.
+type Rollup struct {
+	Start      time.Time `json:"start"`
+	Resolution string    `json:"resolution"`
+	Samples    int       `json:"samples"`
+	Weight     float64   `json:"weight"` // Normal-interval samples represented; burst samples count less
+	Bursts     int       `json:"bursts,omitempty"`
+	Model      string    `json:"model,omitempty"`
+
+	FiveG Radio `json:"5g"`
+	FourG Radio `json:"4g"`
+
+	Latency  Summary `json:"latency"` // Per-sample average RTT, ms
+	Jitter   Summary `json:"jitter"`  // Per-sample RTT standard deviation, ms
+	PingMin  float64 `json:"ping_min"`
+	PingMax  float64 `json:"ping_max"`
+	Sent     int     `json:"sent"`
+	Received int     `json:"received"`
+
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+// Loss is the packet loss over the bucket, in percent.
+func (r *Rollup) Loss() float64 {
+	if r.Sent == 0 {
+		return 0
+	}
+	return float64(r.Sent-r.Received) / float64(r.Sent) * 100
+}
+
+// Sample converts the bucket into a sample stamped with the bucket start
+// and weighted by the samples it stands for, so weighted averages over
+// rollups match those over the raw samples.
+func (r *Rollup) Sample() models.CombinedStats {
+	var s models.CombinedStats
+	s.Gateway.Time.LocalTime = r.Start.Unix()
+	s.Gateway.Device.Model = r.Model
+	s.Gateway.Signal.FiveG = r.FiveG.conn()
+	s.Gateway.Signal.FiveG.GNBID = r.FiveG.Tower
+	s.Gateway.Signal.FourG = r.FourG.conn()
+	s.Gateway.Signal.FourG.EID = r.FourG.Tower
+	s.Ping = models.PingStats{
+		Min:      r.PingMin,
+		Avg:      r.Latency.Avg,
+		Max:      r.PingMax,
+		StdDev:   r.Jitter.Avg,
+		Loss:     r.Loss(),
+		Sent:     r.Sent,
+		Received: r.Received,
+	}
+	s.Weight = r.Weight
+	s.Annotations = r.Annotations
+	return s
+}
+
+func (r *Radio) conn() models.ConnectionStats {
+	c := models.ConnectionStats{
+		PCID: r.PCID,
+		Bars: r.Bars.Avg,
+		RSRP: int(math.Round(r.RSRP.Avg)),
+		RSRQ: int(math.Round(r.RSRQ.Avg)),
+		RSSI: int(math.Round(r.RSSI.Avg)),
+		SINR: int(math.Round(r.SINR.Avg)),
+	}
+	if r.Band != "" {
+		c.Bands = strings.Split(r.Band, ",")
+	}
+	return c
+}
+
+// Builder accumulates samples into buckets of one resolution.
+type Builder struct {
+	res time.Duration
+	cur *bucket
+}
+
+// NewBuilder returns an empty builder for res.
+func NewBuilder(res time.Duration) *Builder {
+	return &Builder{res: res}
+}
+
+// Add adds s to its bucket. When s starts a new bucket, the previous one is
+// complete and returned. Samples older than the current bucket are ignored.
+func (b *Builder) Add(s *models.CombinedStats) *Rollup {
+	start := time.Unix(s.Gateway.Time.LocalTime, 0).UTC().Truncate(b.res)
+	var done *Rollup
+	if b.cur != nil {
+		if start.Before(b.cur.start) {
+			return nil
+		}
+		if start.After(b.cur.start) {
+			done = b.cur.rollup(b.res)
+			b.cur = nil
+		}
+	}
+	if b.cur == nil {
+		b.cur = &bucket{start: start}
+	}
+	b.cur.add(s)
+	return done
+}
+
+// Pending returns the incomplete current bucket, or nil.
+func (b *Builder) Pending() *Rollup {
+	if b.cur == nil {
+		return nil
+	}
+	return b.cur.rollup(b.res)
+}
+
+// bucket keeps every value of its samples so percentiles are exact.
+type bucket struct {
+	start   time.Time
+	samples int
+	weight  float64
+	bursts  int
+	model   string
+
+	fiveG, fourG radioValues
+
+	latency, jitter  []float64
+	pingWeights      []float64
+	pingMin, pingMax float64
+	sent, received   int
+
+	annotations []string
+}
+
+type radioValues struct {
+	bands                        counter[string]
+	towers, pcids                counter[int]
+	bars, rsrp, rsrq, rssi, sinr []float64
+	weights                      []float64
+}
+
+// connected matches the analysis: a radio counts once it reports a band or bars.
+func connected(c *models.ConnectionStats) bool {
+	return len(c.Bands) > 0 || c.Bars > 0
+}
+
+func (v *radioValues) add(c *models.ConnectionStats, tower int, weight float64) {
+	if !connected(c) {
+		return
+	}
+	v.bands.add(strings.Join(c.Bands, ","))
+	if tower != 0 {
+		v.towers.add(tower)
+	}
+	v.pcids.add(c.PCID)
+	v.bars = append(v.bars, c.Bars)
+	v.rsrp = append(v.rsrp, float64(c.RSRP))
+	v.rsrq = append(v.rsrq, float64(c.RSRQ))
+	v.rssi = append(v.rssi, float64(c.RSSI))
+	v.sinr = append(v.sinr, float64(c.SINR))
+	v.weights = append(v.weights, weight)
+}
+
+func (v *radioValues) radio() Radio {
+	r := Radio{
+		Samples: len(v.bars),
+		Bars:    summarize(v.bars, v.weights),
+		RSRP:    summarize(v.rsrp, v.weights),
+		RSRQ:    summarize(v.rsrq, v.weights),
+		RSSI:    summarize(v.rssi, v.weights),
+		SINR:    summarize(v.sinr, v.weights),
+	}
+	r.Band = v.bands.top()
+	r.Tower = v.towers.top()
+	r.PCID = v.pcids.top()
+	return r
+}
+
+func (b *bucket) add(s *models.CombinedStats) {
+	w := s.SampleWeight()
+	b.samples++
+	b.weight += w
+	if s.Burst {
+		b.bursts++
+	}
+	if m := s.Gateway.Device.Model; m != "" {
+		b.model = m
+	}
+	b.fiveG.add(&s.Gateway.Signal.FiveG, s.Gateway.Signal.FiveG.GNBID, w)
+	b.fourG.add(&s.Gateway.Signal.FourG, s.Gateway.Signal.FourG.EID, w)
+
+	p := s.Ping
+	if p.Received > 0 {
+		b.latency = append(b.latency, p.Avg)
+		b.jitter = append(b.jitter, p.StdDev)
+		b.pingWeights = append(b.pingWeights, w)
+		// Zero minimums came from a bug in earlier versions
+		if p.Min > 0 && (b.pingMin == 0 || p.Min < b.pingMin) {
+			b.pingMin = p.Min
+		}
+		b.pingMax = max(b.pingMax, p.Max)
+	}
+	b.sent += p.Sent
+	b.received += p.Received
+	b.annotations = append(b.annotations, s.Annotations...)
+}
+
+func (b *bucket) rollup(res time.Duration) *Rollup {
+	return &Rollup{
+		Start:       b.start,
+		Resolution:  Name(res),
+		Samples:     b.samples,
+		Weight:      b.weight,
+		Bursts:      b.bursts,
+		Model:       b.model,
+		FiveG:       b.fiveG.radio(),
+		FourG:       b.fourG.radio(),
+		Latency:     summarize(b.latency, b.pingWeights),
+		Jitter:      summarize(b.jitter, b.pingWeights),
+		PingMin:     b.pingMin,
+		PingMax:     b.pingMax,
+		Sent:        b.sent,
+		Received:    b.received,
+		Annotations: b.annotations,
+	}
+}
+
+// summarize computes the distribution of values. The average is weighted
+// like the analysis weighs burst samples; percentiles use the nearest-rank
+// method over the values as sampled.
+func summarize(values, weights []float64) Summary {
+	if len(values) == 0 {
+		return Summary{}
+	}
+	var sum, total float64
+	for i, v := range values {
+		sum += v * weights[i]
+		total += weights[i]
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	return Summary{
+		Min: sorted[0],
+		Avg: sum / total,
+		Max: sorted[len(sorted)-1],
+		P50: percentile(sorted, 50),
+		P95: percentile(sorted, 95),
+	}
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// counter finds the most frequent value; ties go to the one seen first.
+type counter[T comparable] struct {
+	counts map[T]int
+	order  []T
+}
+
+func (c *counter[T]) add(v T) {
+	if c.counts == nil {
+		c.counts = map[T]int{}
+	}
+	if c.counts[v] == 0 {
+		c.order = append(c.order, v)
+	}
+	c.counts[v]++
+}
+
+func (c *counter[T]) top() T {
+	var best T
+	for i, v := range c.order {
+		if i == 0 || c.counts[v] > c.counts[best] {
+			best = v
+		}
+	}
+	return best
+}
+
+// Choose picks the coarsest resolution that still gives at least minPoints
+// buckets over [start, end), or raw (0) when even minutes would be too few.
+func Choose(start, end time.Time, minPoints int) time.Duration {
+	span := end.Sub(start)
+	for i := len(Resolutions) - 1; i >= 0; i-- {
+		if span/Resolutions[i] >= time.Duration(minPoints) {
+			return Resolutions[i]
+		}
+	}
+	return 0
+}
@@ -0,0 +1,177 @@
+package rollup
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+var base = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// sample is taken at base+offset with the given SINR and band.
+func sample(offset time.Duration, sinr int, band string) *models.CombinedStats {
+	s := &models.CombinedStats{
+		Ping: models.PingStats{Min: 20, Avg: 30, Max: 40, StdDev: 5, Sent: 10, Received: 9},
+	}
+	s.Gateway.Time.LocalTime = base.Add(offset).Unix()
+	s.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{band}, Bars: 4, GNBID: 1234, PCID: 7, RSRP: -90, SINR: sinr}
+	return s
+}
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(time.Minute)
+	for i, sinr := range []int{10, 20, 30, 40} {
+		band := "n41"
+		if i == 3 {
+			band = "n71"
+		}
+		if done := b.Add(sample(time.Duration(i)*10*time.Second, sinr, band)); done != nil {
+			t.Fatalf("bucket completed early at sample %d", i)
+		}
+	}
+	// Older than the current bucket: ignored
+	if done := b.Add(sample(-time.Minute, 99, "n41")); done != nil {
+		t.Fatal("an older sample completed the bucket")
+	}
+
+	done := b.Add(sample(time.Minute, 0, "n41"))
+	if done == nil {
+		t.Fatal("sample of the next minute did not complete the bucket")
+	}
+	if !done.Start.Equal(base) || done.Resolution != "1m" || done.Samples != 4 {
+		t.Errorf("bucket = %s %s with %d samples, want %s 1m with 4", done.Start, done.Resolution, done.Samples, base)
+	}
+	want := Summary{Min: 10, Avg: 25, Max: 40, P50: 20, P95: 40}
+	if done.FiveG.SINR != want {
+		t.Errorf("SINR = %+v, want %+v", done.FiveG.SINR, want)
+	}
+	if done.FiveG.Band != "n41" || done.FiveG.Tower != 1234 {
+		t.Errorf("dominant band/tower = %s/%d, want n41/1234", done.FiveG.Band, done.FiveG.Tower)
+	}
+	if done.Sent != 40 || done.Received != 36 || math.Abs(done.Loss()-10) > 1e-9 {
+		t.Errorf("ping totals = %d/%d (%.1f%% loss), want 40/36 (10%%)", done.Sent, done.Received, done.Loss())
+	}
+	if done.FourG.Samples != 0 {
+		t.Errorf("4G counted %d samples while disconnected", done.FourG.Samples)
+	}
+
+	s := done.Sample()
+	if s.Gateway.Time.LocalTime != base.Unix() || s.Weight != 4 || s.Gateway.Signal.FiveG.SINR != 25 {
+		t.Errorf("sample = time %d weight %v SINR %d, want %d 4 25", s.Gateway.Time.LocalTime, s.Weight, s.Gateway.Signal.FiveG.SINR, base.Unix())
+	}
+}
+
+func TestStore(t *testing.T) {
+	s := &Store{Dir: t.TempDir()}
+	jan := time.Date(2026, 1, 31, 23, 59, 0, 0, time.UTC)
+	for _, start := range []time.Time{jan, jan.Add(time.Minute), jan.Add(2 * time.Minute)} {
+		if err := s.Append(time.Minute, &Rollup{Start: start, Samples: 1}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := s.files(time.Minute)
+	if err != nil || len(files) != 2 {
+		t.Fatalf("files = %v, %v; want a January and a February partition", files, err)
+	}
+
+	got, err := s.Query(time.Minute, jan.Add(time.Minute), time.Time{})
+	if err != nil || len(got) != 2 {
+		t.Fatalf("Query = %d buckets, %v; want 2", len(got), err)
+	}
+	if last, _ := s.Last(time.Minute); !last.Equal(jan.Add(2 * time.Minute)) {
+		t.Errorf("Last = %s, want %s", last, jan.Add(2*time.Minute))
+	}
+
+	// January ended more than 30 days before March 5; February has not
+	if err := s.Prune(time.Minute, 30*24*time.Hour, time.Date(2026, 3, 5, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	if first, _ := s.First(time.Minute); first.Month() != time.February {
+		t.Errorf("First after pruning = %s, want February", first)
+	}
+}
+
+func TestRollerCatchUp(t *testing.T) {
+	dir := t.TempDir()
+	r, err := Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Since().IsZero() {
+		t.Errorf("Since = %s with nothing stored, want zero", r.Since())
+	}
+
+	// The raw log holds two hours of samples every 30s; a live sample of the
+	// third hour arrives while catching up
+	var logged []*models.CombinedStats
+	for i := 0; i < 240; i++ {
+		logged = append(logged, sample(time.Duration(i)*30*time.Second, 10, "n41"))
+	}
+	gate := make(chan struct{})
+	done := r.CatchUp(func(since time.Time, fn func(*models.CombinedStats) error) error {
+		<-gate
+		for _, s := range logged {
+			if err := fn(s); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	live := sample(2*time.Hour, 10, "n41")
+	if err := r.Log(live); err != nil {
+		t.Fatal(err)
+	}
+	// Logged and held: must be counted once
+	if err := r.Log(logged[len(logged)-1]); err != nil {
+		t.Fatal(err)
+	}
+	close(gate)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Store{Dir: dir}
+	minutes, _ := s.Query(time.Minute, time.Time{}, time.Time{})
+	hours, _ := s.Query(time.Hour, time.Time{}, time.Time{})
+	if len(minutes) != 120 || len(hours) != 2 {
+		t.Fatalf("stored %d minutes and %d hours, want 120 and 2", len(minutes), len(hours))
+	}
+	if hours[1].Samples != 120 {
+		t.Errorf("second hour has %d samples, want 120", hours[1].Samples)
+	}
+
+	// Reopening resumes after the stored buckets without rewriting them
+	r, err = Open(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := base.Add(2 * time.Hour); !r.Since().Equal(want) {
+		t.Errorf("Since after reopening = %s, want %s", r.Since(), want)
+	}
+	for _, s := range logged {
+		if err := r.Log(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if minutes, _ := s.Query(time.Minute, time.Time{}, time.Time{}); len(minutes) != 120 {
+		t.Errorf("stored %d minutes after replaying, want 120", len(minutes))
+	}
+}
+
+func TestChoose(t *testing.T) {
+	tests := []struct {
+		span time.Duration
+		want time.Duration
+	}{
+		{time.Hour, 0},
+		{24 * time.Hour, time.Minute},
+		{30 * 24 * time.Hour, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Choose(base, base.Add(tt.span), 300); got != tt.want {
+			t.Errorf("Choose(%s) = %s, want %s", tt.span, Name(got), Name(tt.want))
+		}
+	}
+}
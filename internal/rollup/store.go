@@ -0,0 +1,183 @@
+package rollup
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DirFor returns the rollup directory kept next to a raw log: stats.log
+// rolls up into stats.rollup.
+func DirFor(logPath string) string {
+	return strings.TrimSuffix(logPath, filepath.Ext(logPath)) + ".rollup"
+}
+
+// Store reads and writes the rollup files of one directory.
+type Store struct {
+	Dir string
+}
+
+// partition describes how the files of a resolution are split.
+type partition struct {
+	layout string // File name, as a time layout
+	next   func(t time.Time) time.Time
+}
+
+func partitionOf(res time.Duration) partition {
+	if res >= time.Hour {
+		return partition{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }}
+	}
+	return partition{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }}
+}
+
+// partFile is one partition file and the span it covers.
+type partFile struct {
+	path       string
+	start, end time.Time
+}
+
+// files lists the partitions of res, oldest first.
+func (s *Store) files(res time.Duration) ([]partFile, error) {
+	dir := filepath.Join(s.Dir, Name(res))
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := partitionOf(res)
+	var files []partFile
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".jsonl")
+		if !ok {
+			continue
+		}
+		start, err := time.Parse(p.layout, name)
+		if err != nil {
+			continue
+		}
+		files = append(files, partFile{path: filepath.Join(dir, e.Name()), start: start, end: p.next(start)})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].start.Before(files[j].start) })
+	return files, nil
+}
+
+// Append writes r to its partition with a single write.
+func (s *Store) Append(res time.Duration, r *Rollup) error {
+	dir := filepath.Join(s.Dir, Name(res))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create rollup directory: %w", err)
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, r.Start.UTC().Format(partitionOf(res).layout)+".jsonl")
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open rollup file: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("could not write rollup: %w", err)
+	}
+	return f.Close()
+}
+
+// Query returns the buckets of res starting in [start, end); zero times
+// leave that side open.
+func (s *Store) Query(res time.Duration, start, end time.Time) ([]Rollup, error) {
+	files, err := s.files(res)
+	if err != nil {
+		return nil, err
+	}
+	var out []Rollup
+	for _, pf := range files {
+		if (!start.IsZero() && !pf.end.After(start)) || (!end.IsZero() && !pf.start.Before(end)) {
+			continue
+		}
+		err := readFile(pf.path, func(r *Rollup) {
+			if (start.IsZero() || !r.Start.Before(start)) && (end.IsZero() || r.Start.Before(end)) {
+				out = append(out, *r)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// readFile decodes every bucket of a partition. Lines that don't decode,
+// like one torn by a crash, are skipped.
+func readFile(path string, fn func(r *Rollup)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Rollup
+		if json.Unmarshal(bytes.TrimSpace(scanner.Bytes()), &r) == nil {
+			fn(&r)
+		}
+	}
+	return scanner.Err()
+}
+
+// Last returns the start of the newest stored bucket of res, or the zero
+// time if there is none.
+func (s *Store) Last(res time.Duration) (time.Time, error) {
+	files, err := s.files(res)
+	if err != nil || len(files) == 0 {
+		return time.Time{}, err
+	}
+	var last time.Time
+	err = readFile(files[len(files)-1].path, func(r *Rollup) {
+		if r.Start.After(last) {
+			last = r.Start
+		}
+	})
+	return last, err
+}
+
+// First returns the start of the oldest stored partition of res, or the
+// zero time if there is none.
+func (s *Store) First(res time.Duration) (time.Time, error) {
+	files, err := s.files(res)
+	if err != nil || len(files) == 0 {
+		return time.Time{}, err
+	}
+	return files[0].start, nil
+}
+
+// Prune deletes the partitions of res that ended more than maxAge before
+// now; zero keeps everything.
+func (s *Store) Prune(res, maxAge time.Duration, now time.Time) error {
+	if maxAge <= 0 {
+		return nil
+	}
+	files, err := s.files(res)
+	if err != nil {
+		return err
+	}
+	for _, pf := range files {
+		if now.Sub(pf.end) > maxAge {
+			if err := os.Remove(pf.path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/config"
+	"tmobile-stats/internal/models"
+)
+
+// Adaptive decides how long to wait before the next gateway poll.
+// Normally it returns the base refresh interval. When a sample shows a
+// degradation (health drop, packet loss or a tower change) it switches to a
+// short burst interval for a configured period, then backs off again.
+type Adaptive struct {
+	enabled       bool
+	base          time.Duration
+	burstInterval time.Duration
+	burstDuration time.Duration
+	healthDrop    float64
+	lossThreshold float64
+
+	burstUntil time.Time
+	reason     string
+	lastHealth float64
+	lastTower  int
+	primed     bool
+	mu         sync.Mutex
+}
+
+// New creates a scheduler from the adaptive config section and the base interval.
+func New(cfg config.AdaptiveConfig, base time.Duration) *Adaptive {
+	return &Adaptive{
+		enabled:       cfg.Enabled,
+		base:          base,
+		burstInterval: time.Duration(cfg.BurstInterval) * time.Second,
+		burstDuration: time.Duration(cfg.BurstDuration) * time.Second,
+		healthDrop:    cfg.HealthDrop,
+		lossThreshold: cfg.LossThreshold,
+	}
+}
+
+// SetBase changes the normal (non-burst) interval, e.g. from the TUI +/- keys.
+func (a *Adaptive) SetBase(d time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.base = d
+}
+
+// Base returns the normal (non-burst) interval.
+func (a *Adaptive) Base() time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.base
+}
+
+// Observe inspects a freshly collected sample, starting or extending a burst
+// if it shows a degradation, and returns the delay until the next poll.
+func (a *Adaptive) Observe(s *models.CombinedStats, now time.Time) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.enabled {
+		if reason := a.degradation(s); reason != "" {
+			a.startBurst(reason, now)
+		}
+	}
+	return a.delay(now)
+}
+
+// Trigger starts (or extends) a burst regardless of the sample data.
+func (a *Adaptive) Trigger(reason string, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.startBurst(reason, now)
+}
+
+// Delay returns the wait before the next poll without observing a sample.
+func (a *Adaptive) Delay(now time.Time) time.Duration {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.delay(now)
+}
+
+// Burst reports whether a burst is active at now, and why it started.
+func (a *Adaptive) Burst(now time.Time) (bool, string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if now.Before(a.burstUntil) {
+		return true, a.reason
+	}
+	return false, ""
+}
+
+// Flag marks a sample collected at now as a burst sample and records the
+// weight analysis should give it relative to a normal-interval sample.
+func (a *Adaptive) Flag(s *models.CombinedStats, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !now.Before(a.burstUntil) || a.burstInterval >= a.base {
+		return
+	}
+	s.Burst = true
+	s.BurstReason = a.reason
+	s.Weight = a.burstInterval.Seconds() / a.base.Seconds()
+}
+
+// Status returns a short description for status lines.
+func (a *Adaptive) Status(now time.Time) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if now.Before(a.burstUntil) {
+		return fmt.Sprintf("BURST %v until %s (%s)", a.burstInterval, a.burstUntil.Format("15:04:05"), a.reason)
+	}
+	if a.enabled {
+		return "adaptive"
+	}
+	return ""
+}
+
+func (a *Adaptive) startBurst(reason string, now time.Time) {
+	if a.burstInterval <= 0 || a.burstDuration <= 0 {
+		return
+	}
+	until := now.Add(a.burstDuration)
+	if until.After(a.burstUntil) {
+		a.burstUntil = until
+	}
+	a.reason = reason
+}
+
+func (a *Adaptive) delay(now time.Time) time.Duration {
+	if now.Before(a.burstUntil) && a.burstInterval < a.base {
+		return a.burstInterval
+	}
+	return a.base
+}
+
+// degradation compares the sample against the previous one and returns the
+// reason for a burst, or "" if nothing changed for the worse.
+func (a *Adaptive) degradation(s *models.CombinedStats) string {
+	radio := s.Gateway.Signal.FiveG
+	if len(radio.Bands) == 0 && radio.Bars == 0 {
+		radio = s.Gateway.Signal.FourG
+	}
+	health := analysis.CalculateSignalHealth(radio.RSRP, radio.SINR)
+	tower := radio.GNBID
+	if tower == 0 {
+		tower = radio.PCID
+	}
+
+	reason := ""
+	switch {
+	case s.Ping.Sent > 0 && s.Ping.Loss > a.lossThreshold:
+		reason = fmt.Sprintf("loss %.1f%%", s.Ping.Loss)
+	case a.primed && tower != 0 && a.lastTower != 0 && tower != a.lastTower:
+		reason = fmt.Sprintf("tower %d -> %d", a.lastTower, tower)
+	case a.primed && a.healthDrop > 0 && a.lastHealth-health >= a.healthDrop:
+		reason = fmt.Sprintf("health %.1f -> %.1f", a.lastHealth, health)
+	}
+
+	a.lastHealth = health
+	if tower != 0 {
+		a.lastTower = tower
+	}
+	a.primed = true
+	return reason
+}
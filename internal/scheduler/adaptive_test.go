@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/config"
+	"tmobile-stats/internal/models"
+)
+
+func sample(rsrp, sinr, tower int, loss float64) *models.CombinedStats {
+	s := &models.CombinedStats{}
+	s.Gateway.Signal.FiveG = models.ConnectionStats{Bands: []string{"n41"}, Bars: 4, RSRP: rsrp, SINR: sinr, GNBID: tower}
+	s.Ping = models.PingStats{Sent: 5, Received: 5, Loss: loss}
+	return s
+}
+
+func newTestScheduler() *Adaptive {
+	return New(config.AdaptiveConfig{
+		Enabled:       true,
+		BurstInterval: 1,
+		BurstDuration: 30,
+		HealthDrop:    0.5,
+	}, 5*time.Second)
+}
+
+func TestAdaptiveTriggers(t *testing.T) {
+	tests := []struct {
+		name   string
+		next   *models.CombinedStats
+		reason string
+	}{
+		{"Stable", sample(-85, 25, 100, 0), ""},
+		{"Loss", sample(-85, 25, 100, 20), "loss"},
+		{"TowerChange", sample(-85, 25, 200, 0), "tower 100 -> 200"},
+		{"HealthDrop", sample(-115, 25, 100, 0), "health"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestScheduler()
+			now := time.Now()
+			a.Observe(sample(-85, 25, 100, 0), now)
+
+			delay := a.Observe(tt.next, now)
+			burst, reason := a.Burst(now)
+
+			if tt.reason == "" {
+				if burst || delay != 5*time.Second {
+					t.Errorf("Expected no burst, got burst=%v delay=%v reason=%q", burst, delay, reason)
+				}
+				return
+			}
+			if !burst || delay != time.Second {
+				t.Errorf("Expected 1s burst, got burst=%v delay=%v", burst, delay)
+			}
+			if !strings.Contains(reason, tt.reason) {
+				t.Errorf("Expected reason containing %q, got %q", tt.reason, reason)
+			}
+		})
+	}
+}
+
+func TestAdaptiveBackoffAndFlag(t *testing.T) {
+	a := newTestScheduler()
+	now := time.Now()
+	a.Observe(sample(-85, 25, 100, 50), now)
+
+	s := &models.CombinedStats{}
+	a.Flag(s, now.Add(10*time.Second))
+	if !s.Burst || s.Weight != 0.2 || s.SampleWeight() != 0.2 {
+		t.Errorf("Expected burst sample with weight 0.2, got %+v", s)
+	}
+
+	later := now.Add(31 * time.Second)
+	if d := a.Delay(later); d != 5*time.Second {
+		t.Errorf("Expected back-off to 5s after burst, got %v", d)
+	}
+
+	s = &models.CombinedStats{}
+	a.Flag(s, later)
+	if s.Burst || s.SampleWeight() != 1 {
+		t.Errorf("Expected normal sample after burst, got %+v", s)
+	}
+}
+
+func TestAdaptiveDisabled(t *testing.T) {
+	a := New(config.AdaptiveConfig{BurstInterval: 1, BurstDuration: 30}, 5*time.Second)
+	now := time.Now()
+	if d := a.Observe(sample(-85, 25, 100, 100), now); d != 5*time.Second {
+		t.Errorf("Disabled scheduler should keep base interval, got %v", d)
+	}
+
+	// Manual triggers still work when automatic detection is off
+	a.Trigger("manual", now)
+	if d := a.Delay(now); d != time.Second {
+		t.Errorf("Expected manual burst at 1s, got %v", d)
+	}
+}
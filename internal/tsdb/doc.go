@@ -0,0 +1,21 @@
+// Package tsdb is a compact append-only store for samples. Each sample is a
+// fixed-layout little-endian binary record; records are grouped into
+// time-partitioned segment files with a sparse time index so range queries
+// seek straight to the first matching record instead of parsing everything
+// before it.
+//
+// A store is a directory holding, per segment:
+//
+//	20260105T000000-0.seg    header + fixed-size records
+//	20260105T000000-0.idx    sparse index: (time, record number) every IndexStride records
+//	20260105T000000-0.notes  JSON lines for the rare fields that don't fit a record
+//
+// Values that rarely change (device info, APN, antenna, ping target identities)
+// live in the segment header; a sample that differs starts a new segment.
+//
+// Latency, loss, bars and weights are stored as float32 to keep records
+// small, so they read back rounded to about seven significant digits: a
+// ping average of 25.123456789 comes back as 25.123457. That is well past
+// what the gateway and pinger measure, but values don't round-trip exactly
+// the way they do through the JSON log.
+package tsdb
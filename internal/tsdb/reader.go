@@ -0,0 +1,142 @@
+package tsdb
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// Ext is the conventional extension of a store directory.
+const Ext = ".tsdb"
+
+// IsStore reports whether path is a store: a directory holding segments or
+// named *.tsdb (which may not exist yet).
+func IsStore(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return filepath.Ext(path) == Ext
+	}
+	if !info.IsDir() {
+		return false
+	}
+	if filepath.Ext(path) == Ext {
+		return true
+	}
+	segs, err := listSegments(path)
+	return err == nil && len(segs) > 0
+}
+
+// Query returns the samples in the store at dir inside [start, end] in time
+// order. Zero bounds are open. Only segments whose partition overlaps the
+// range are opened, and each is entered at the sparse index entry preceding
+// start. Records failing their checksum are skipped.
+func Query(dir string, start, end time.Time) ([]models.CombinedStats, error) {
+	segs, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var r rangeQuery
+	if !start.IsZero() {
+		// Samples have whole-second times, so round a fractional start up
+		r.start, r.hasStart = start.Unix(), true
+		if start.Nanosecond() > 0 {
+			r.start++
+		}
+	}
+	if !end.IsZero() {
+		r.end, r.hasEnd = end.Unix(), true
+	}
+
+	var results []models.CombinedStats
+	for _, sf := range segs {
+		if r.hasEnd && sf.Start.Unix() > r.end {
+			break
+		}
+		results, err = r.segment(sf, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Out-of-order samples start extra segments within a partition
+	if !sort.SliceIsSorted(results, func(i, j int) bool {
+		return results[i].Gateway.Time.LocalTime < results[j].Gateway.Time.LocalTime
+	}) {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Gateway.Time.LocalTime < results[j].Gateway.Time.LocalTime
+		})
+	}
+	return results, nil
+}
+
+type rangeQuery struct {
+	start, end       int64
+	hasStart, hasEnd bool
+}
+
+// segment appends the matching records of sf to out.
+func (r *rangeQuery) segment(sf segmentFile, out []models.CombinedStats) ([]models.CombinedStats, error) {
+	f, err := os.Open(sf.seg())
+	if err != nil {
+		return out, err
+	}
+	defer f.Close()
+
+	h, off, err := readHeader(f)
+	if err != nil {
+		return out, nil // Skip segments that are not ours or were torn while created
+	}
+	if r.hasStart && h.Start+h.Partition <= r.start {
+		return out, nil
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return out, err
+	}
+	size := int64(h.recordSize())
+	count := (info.Size() - off) / size
+
+	first := int64(0)
+	if r.hasStart {
+		// A missing or short index only means scanning from an earlier record
+		entries, _ := readIndex(sf.idx())
+		i := sort.Search(len(entries), func(i int) bool { return entries[i].Time >= r.start })
+		if i > 0 && int64(entries[i-1].Rec) < count {
+			first = int64(entries[i-1].Rec)
+		}
+	}
+
+	notes, err := readNotes(sf.notes())
+	if err != nil {
+		return out, err
+	}
+
+	out = slices.Grow(out, int(count-first))
+	br := bufio.NewReaderSize(io.NewSectionReader(f, off+first*size, (count-first)*size), 64*1024)
+	rec := make([]byte, size)
+	for n := first; n < count; n++ {
+		if _, err := io.ReadFull(br, rec); err != nil {
+			return out, err
+		}
+		if !validRecord(rec) {
+			continue
+		}
+		t := recordTime(rec)
+		if r.hasStart && t < r.start {
+			continue
+		}
+		if r.hasEnd && t > r.end {
+			break
+		}
+		out = append(out, decodeRecord(rec, h, notes[int(n)]))
+	}
+	return out, nil
+}
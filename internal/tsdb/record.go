@@ -0,0 +1,244 @@
+package tsdb
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"strconv"
+
+	"tmobile-stats/internal/models"
+)
+
+// Record layout (little-endian). Every record in a segment has the same
+// size: coreSize + pingSize per additional ping target + 4 bytes of CRC32
+// over everything before it.
+const (
+	offTime    = 0  // int64 gateway local time (unix seconds)
+	offUpTime  = 8  // uint32
+	offFlags   = 12 // uint16
+	offWeight  = 16 // float32
+	offFiveG   = 20 // connection block
+	offFourG   = offFiveG + connSize
+	offPing    = offFourG + connSize
+	coreSize   = offPing + pingSize
+	connSize   = 36 // bars f32, cid/gnbid/eid/pcid i32, rsrp/rsrq/rssi/sinr i16, bands [maxBands]u16
+	pingSize   = 32 // min/avg/max/stddev/loss/last_rtt f32, sent/received u32
+	crcSize    = 4
+	maxBands   = 4
+	flagBurst  = 1 << 0
+	flagIPv6   = 1 << 1
+	maxBandNum = 1<<11 - 1
+)
+
+func recordSize(targets int) int {
+	return coreSize + targets*pingSize + crcSize
+}
+
+// extras carries the parts of a sample a fixed record can't hold. They are
+// stored in the segment's notes file keyed by record number.
+type extras struct {
+	N           int      `json:"n"`
+	Annotations []string `json:"annotations,omitempty"`
+	BurstReason string   `json:"burst_reason,omitempty"`
+	Bands5G     []string `json:"bands_5g,omitempty"`
+	Bands4G     []string `json:"bands_4g,omitempty"`
+}
+
+func (e *extras) empty() bool {
+	return len(e.Annotations) == 0 && e.BurstReason == "" && e.Bands5G == nil && e.Bands4G == nil
+}
+
+// encodeRecord writes s into buf (len recordSize(len(s.Targets))) and
+// returns what didn't fit.
+func encodeRecord(buf []byte, s *models.CombinedStats) extras {
+	le := binary.LittleEndian
+	ex := extras{Annotations: s.Annotations, BurstReason: s.BurstReason}
+
+	le.PutUint64(buf[offTime:], uint64(s.Gateway.Time.LocalTime))
+	le.PutUint32(buf[offUpTime:], uint32(s.Gateway.Time.UpTime))
+	var flags uint16
+	if s.Burst {
+		flags |= flagBurst
+	}
+	if s.Gateway.Signal.Generic.HasIPv6 {
+		flags |= flagIPv6
+	}
+	le.PutUint16(buf[offFlags:], flags)
+	le.PutUint16(buf[offFlags+2:], 0)
+	putFloat(buf[offWeight:], s.Weight)
+
+	if !encodeConn(buf[offFiveG:], &s.Gateway.Signal.FiveG) {
+		ex.Bands5G = s.Gateway.Signal.FiveG.Bands
+	}
+	if !encodeConn(buf[offFourG:], &s.Gateway.Signal.FourG) {
+		ex.Bands4G = s.Gateway.Signal.FourG.Bands
+	}
+	encodePing(buf[offPing:], &s.Ping)
+	for i := range s.Targets {
+		encodePing(buf[coreSize+i*pingSize:], &s.Targets[i])
+	}
+
+	end := len(buf) - crcSize
+	le.PutUint32(buf[end:], crc32.ChecksumIEEE(buf[:end]))
+	return ex
+}
+
+// encodeConn reports false if the bands could not be encoded; they are then
+// left empty in the record.
+func encodeConn(buf []byte, c *models.ConnectionStats) bool {
+	le := binary.LittleEndian
+	putFloat(buf[0:], c.Bars)
+	le.PutUint32(buf[4:], uint32(int32(c.CID)))
+	le.PutUint32(buf[8:], uint32(int32(c.GNBID)))
+	le.PutUint32(buf[12:], uint32(int32(c.EID)))
+	le.PutUint32(buf[16:], uint32(int32(c.PCID)))
+	le.PutUint16(buf[20:], uint16(int16(c.RSRP)))
+	le.PutUint16(buf[22:], uint16(int16(c.RSRQ)))
+	le.PutUint16(buf[24:], uint16(int16(c.RSSI)))
+	le.PutUint16(buf[26:], uint16(int16(c.SINR)))
+
+	codes := make([]uint16, maxBands)
+	ok := len(c.Bands) <= maxBands
+	for i := 0; ok && i < len(c.Bands); i++ {
+		codes[i], ok = bandCode(c.Bands[i])
+	}
+	for i := range codes {
+		if !ok {
+			codes[i] = 0
+		}
+		le.PutUint16(buf[28+i*2:], codes[i])
+	}
+	return ok
+}
+
+func encodePing(buf []byte, p *models.PingStats) {
+	le := binary.LittleEndian
+	putFloat(buf[0:], p.Min)
+	putFloat(buf[4:], p.Avg)
+	putFloat(buf[8:], p.Max)
+	putFloat(buf[12:], p.StdDev)
+	putFloat(buf[16:], p.Loss)
+	putFloat(buf[20:], p.LastRTT)
+	le.PutUint32(buf[24:], uint32(p.Sent))
+	le.PutUint32(buf[28:], uint32(p.Received))
+}
+
+// recordTime returns the sample time of an encoded record.
+func recordTime(buf []byte) int64 {
+	return int64(binary.LittleEndian.Uint64(buf[offTime:]))
+}
+
+// validRecord checks the record's CRC.
+func validRecord(buf []byte) bool {
+	end := len(buf) - crcSize
+	return binary.LittleEndian.Uint32(buf[end:]) == crc32.ChecksumIEEE(buf[:end])
+}
+
+// decodeRecord rebuilds a sample from a record and its segment's header.
+func decodeRecord(buf []byte, h *header, ex *extras) models.CombinedStats {
+	le := binary.LittleEndian
+	var s models.CombinedStats
+
+	s.Gateway.Device = h.Device
+	s.Gateway.Signal.Generic = h.Generic
+	s.Gateway.Time.LocalTimeZone = h.TimeZone
+	s.Gateway.Time.LocalTime = recordTime(buf)
+	s.Gateway.Time.UpTime = int(le.Uint32(buf[offUpTime:]))
+
+	flags := le.Uint16(buf[offFlags:])
+	s.Burst = flags&flagBurst != 0
+	s.Gateway.Signal.Generic.HasIPv6 = flags&flagIPv6 != 0
+	s.Weight = getFloat(buf[offWeight:])
+
+	s.Gateway.Signal.FiveG = decodeConn(buf[offFiveG:])
+	s.Gateway.Signal.FiveG.AntennaUsed = h.Antenna5G
+	s.Gateway.Signal.FourG = decodeConn(buf[offFourG:])
+	s.Gateway.Signal.FourG.AntennaUsed = h.Antenna4G
+
+	s.Ping = decodePing(buf[offPing:], h.Ping)
+	if len(h.Targets) > 0 {
+		s.Targets = make([]models.PingStats, len(h.Targets))
+		for i, id := range h.Targets {
+			s.Targets[i] = decodePing(buf[coreSize+i*pingSize:], id)
+		}
+	}
+
+	if ex != nil {
+		s.Annotations = ex.Annotations
+		s.BurstReason = ex.BurstReason
+		if ex.Bands5G != nil {
+			s.Gateway.Signal.FiveG.Bands = ex.Bands5G
+		}
+		if ex.Bands4G != nil {
+			s.Gateway.Signal.FourG.Bands = ex.Bands4G
+		}
+	}
+	return s
+}
+
+func decodeConn(buf []byte) models.ConnectionStats {
+	le := binary.LittleEndian
+	c := models.ConnectionStats{
+		Bars:  getFloat(buf[0:]),
+		CID:   int(int32(le.Uint32(buf[4:]))),
+		GNBID: int(int32(le.Uint32(buf[8:]))),
+		EID:   int(int32(le.Uint32(buf[12:]))),
+		PCID:  int(int32(le.Uint32(buf[16:]))),
+		RSRP:  int(int16(le.Uint16(buf[20:]))),
+		RSRQ:  int(int16(le.Uint16(buf[22:]))),
+		RSSI:  int(int16(le.Uint16(buf[24:]))),
+		SINR:  int(int16(le.Uint16(buf[26:]))),
+	}
+	for i := 0; i < maxBands; i++ {
+		code := le.Uint16(buf[28+i*2:])
+		if code == 0 {
+			break
+		}
+		c.Bands = append(c.Bands, bandName(code))
+	}
+	return c
+}
+
+func decodePing(buf []byte, id pingID) models.PingStats {
+	le := binary.LittleEndian
+	return models.PingStats{
+		Min:      getFloat(buf[0:]),
+		Avg:      getFloat(buf[4:]),
+		Max:      getFloat(buf[8:]),
+		StdDev:   getFloat(buf[12:]),
+		Loss:     getFloat(buf[16:]),
+		LastRTT:  getFloat(buf[20:]),
+		Sent:     int(le.Uint32(buf[24:])),
+		Received: int(le.Uint32(buf[28:])),
+		Target:   id.Target,
+		Options:  id.Options,
+	}
+}
+
+// bandCode packs a band name such as "n41" or "b66" into 16 bits: the
+// letter (1-26) in the top five bits and the number in the low eleven.
+func bandCode(name string) (uint16, bool) {
+	if len(name) < 2 || name[0] < 'a' || name[0] > 'z' || (name[1] == '0' && len(name) > 2) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(name[1:])
+	if err != nil || n < 0 || n > maxBandNum || strconv.Itoa(n) != name[1:] {
+		return 0, false
+	}
+	return uint16(name[0]-'a'+1)<<11 | uint16(n), true
+}
+
+func bandName(code uint16) string {
+	return string(rune('a'+code>>11-1)) + strconv.Itoa(int(code&maxBandNum))
+}
+
+// Latency, loss and bars are stored as float32, which keeps about seven
+// significant digits - far more than the gateway and pinger report, but
+// lossy for values that need more (see the package doc).
+func putFloat(buf []byte, v float64) {
+	binary.LittleEndian.PutUint32(buf, math.Float32bits(float32(v)))
+}
+
+func getFloat(buf []byte) float64 {
+	return float64(math.Float32frombits(binary.LittleEndian.Uint32(buf)))
+}
@@ -0,0 +1,220 @@
+package tsdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+const (
+	// Version is the segment format version written to every header.
+	Version = 1
+
+	// IndexStride is the number of records between sparse index entries.
+	IndexStride = 128
+
+	// DefaultPartition is the time span of one segment.
+	DefaultPartition = 24 * time.Hour
+
+	segmentTimeFormat = "20060102T150405"
+	indexEntrySize    = 16 // int64 time, uint32 record number, 4 bytes padding
+	preambleSize      = 12 // magic, uint16 version, uint16 reserved, uint32 header length
+)
+
+var magic = [4]byte{'S', 'S', 'T', 'S'}
+
+// ErrNotSegment is returned for files that don't start with a segment header.
+var ErrNotSegment = errors.New("not a tsdb segment")
+
+// pingID identifies a ping target; the numbers live in the records.
+type pingID struct {
+	Target  string              `json:"target,omitempty"`
+	Options *models.PingOptions `json:"options,omitempty"`
+}
+
+// header is the JSON segment header holding everything that is constant
+// across the segment's records.
+type header struct {
+	Version   int                `json:"version"`
+	Start     int64              `json:"start"`     // Partition start (unix seconds)
+	Partition int64              `json:"partition"` // Partition length (seconds)
+	Device    models.DeviceInfo  `json:"device"`
+	Generic   models.GenericInfo `json:"generic"`
+	TimeZone  string             `json:"time_zone,omitempty"`
+	Antenna5G string             `json:"antenna_5g,omitempty"`
+	Antenna4G string             `json:"antenna_4g,omitempty"`
+	Ping      pingID             `json:"ping"`
+	Targets   []pingID           `json:"targets,omitempty"`
+}
+
+// headerFor returns the header a segment holding s must have.
+func headerFor(s *models.CombinedStats, partition time.Duration) header {
+	t := time.Unix(s.Gateway.Time.LocalTime, 0)
+	h := header{
+		Version:   Version,
+		Start:     t.Truncate(partition).Unix(),
+		Partition: int64(partition / time.Second),
+		Device:    s.Gateway.Device,
+		Generic:   s.Gateway.Signal.Generic,
+		TimeZone:  s.Gateway.Time.LocalTimeZone,
+		Antenna5G: s.Gateway.Signal.FiveG.AntennaUsed,
+		Antenna4G: s.Gateway.Signal.FourG.AntennaUsed,
+		Ping:      pingID{Target: s.Ping.Target, Options: s.Ping.Options},
+	}
+	h.Generic.HasIPv6 = false // Stored per record
+	for _, p := range s.Targets {
+		h.Targets = append(h.Targets, pingID{Target: p.Target, Options: p.Options})
+	}
+	return h
+}
+
+func (h *header) encode() ([]byte, error) {
+	meta, err := json.Marshal(h)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, preambleSize, preambleSize+len(meta))
+	copy(buf, magic[:])
+	binary.LittleEndian.PutUint16(buf[4:], Version)
+	binary.LittleEndian.PutUint32(buf[8:], uint32(len(meta)))
+	return append(buf, meta...), nil
+}
+
+func (h *header) recordSize() int {
+	return recordSize(len(h.Targets))
+}
+
+func (h *header) end() time.Time {
+	return time.Unix(h.Start+h.Partition, 0)
+}
+
+// readHeader reads a segment header and returns it with the offset of the
+// first record.
+func readHeader(r io.Reader) (*header, int64, error) {
+	var pre [preambleSize]byte
+	if _, err := io.ReadFull(r, pre[:]); err != nil {
+		return nil, 0, ErrNotSegment
+	}
+	if !bytes.Equal(pre[:4], magic[:]) {
+		return nil, 0, ErrNotSegment
+	}
+	if v := binary.LittleEndian.Uint16(pre[4:]); v != Version {
+		return nil, 0, fmt.Errorf("unsupported segment version %d", v)
+	}
+	n := binary.LittleEndian.Uint32(pre[8:])
+	meta := make([]byte, n)
+	if _, err := io.ReadFull(r, meta); err != nil {
+		return nil, 0, fmt.Errorf("truncated segment header: %w", err)
+	}
+	var h header
+	if err := json.Unmarshal(meta, &h); err != nil {
+		return nil, 0, fmt.Errorf("invalid segment header: %w", err)
+	}
+	return &h, int64(preambleSize) + int64(n), nil
+}
+
+// segmentFile is one segment found in a store directory.
+type segmentFile struct {
+	Base  string // Path without extension
+	Start time.Time
+	N     int
+}
+
+func (s segmentFile) seg() string   { return s.Base + ".seg" }
+func (s segmentFile) idx() string   { return s.Base + ".idx" }
+func (s segmentFile) notes() string { return s.Base + ".notes" }
+
+func segmentBase(dir string, start time.Time, n int) string {
+	return filepath.Join(dir, start.UTC().Format(segmentTimeFormat)+"-"+strconv.Itoa(n))
+}
+
+var segmentPattern = regexp.MustCompile(`^(\d{8}T\d{6})-(\d+)\.seg$`)
+
+// listSegments returns the segments in dir ordered by partition start and
+// sequence number.
+func listSegments(dir string) ([]segmentFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var segs []segmentFile
+	for _, e := range entries {
+		m := segmentPattern.FindStringSubmatch(e.Name())
+		if m == nil || e.IsDir() {
+			continue
+		}
+		start, err := time.Parse(segmentTimeFormat, m[1])
+		if err != nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[2])
+		segs = append(segs, segmentFile{Base: segmentBase(dir, start, n), Start: start, N: n})
+	}
+	sort.Slice(segs, func(i, j int) bool {
+		if !segs[i].Start.Equal(segs[j].Start) {
+			return segs[i].Start.Before(segs[j].Start)
+		}
+		return segs[i].N < segs[j].N
+	})
+	return segs, nil
+}
+
+// indexEntry points at a record: every IndexStride-th record's time and number.
+type indexEntry struct {
+	Time int64
+	Rec  uint32
+}
+
+func readIndex(path string) ([]indexEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]indexEntry, len(data)/indexEntrySize)
+	for i := range entries {
+		b := data[i*indexEntrySize:]
+		entries[i] = indexEntry{
+			Time: int64(binary.LittleEndian.Uint64(b)),
+			Rec:  binary.LittleEndian.Uint32(b[8:]),
+		}
+	}
+	return entries, nil
+}
+
+func encodeIndexEntry(e indexEntry) []byte {
+	buf := make([]byte, indexEntrySize)
+	binary.LittleEndian.PutUint64(buf, uint64(e.Time))
+	binary.LittleEndian.PutUint32(buf[8:], e.Rec)
+	return buf
+}
+
+// readNotes loads a segment's notes file, keyed by record number.
+func readNotes(path string) (map[int]*extras, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	notes := make(map[int]*extras)
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		var ex extras
+		if len(line) == 0 || json.Unmarshal(line, &ex) != nil {
+			continue // Skip a torn last line
+		}
+		notes[ex.N] = &ex
+	}
+	return notes, nil
+}
@@ -0,0 +1,235 @@
+package tsdb
+
+import (
+	"math"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+var testBase = time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+
+func testSample(t time.Time) *models.CombinedStats {
+	s := &models.CombinedStats{
+		Gateway: models.GatewayResponse{
+			Device: models.DeviceInfo{Model: "G4AR", Serial: "ABC123"},
+			Signal: models.SignalInfo{
+				FiveG: models.ConnectionStats{
+					AntennaUsed: "Internal_directional",
+					Bands:       []string{"n41"},
+					Bars:        4,
+					CID:         311,
+					GNBID:       1234567,
+					PCID:        512,
+					RSRP:        -92,
+					RSRQ:        -11,
+					RSSI:        -80,
+					SINR:        14,
+				},
+				FourG:   models.ConnectionStats{Bands: []string{"b66", "b2"}, Bars: 3.5, EID: 99, RSRP: -101, SINR: 6},
+				Generic: models.GenericInfo{APN: "fbb.home", HasIPv6: true, Registration: "registered"},
+			},
+			Time: models.TimeInfo{LocalTime: t.Unix(), LocalTimeZone: "UTC", UpTime: 3600},
+		},
+		Ping: models.PingStats{Min: 20.5, Avg: 25.25, Max: 40, StdDev: 3.5, Loss: 10, LastRTT: 22, Sent: 10, Received: 9, Target: "8.8.8.8"},
+	}
+	return s
+}
+
+func writeSamples(t *testing.T, dir string, samples ...*models.CombinedStats) {
+	t.Helper()
+	w, err := Open(dir, Options{})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for _, s := range samples {
+		if err := w.Log(s); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	in := testSample(testBase.Add(time.Hour))
+	in.Targets = []models.PingStats{{Avg: 30, Sent: 10, Received: 10, Target: "1.1.1.1", Options: &models.PingOptions{Network: "ip6", TOS: 184}}}
+	in.Burst = true
+	in.BurstReason = "sinr drop"
+	in.Weight = 0.25
+	in.Annotations = []string{"moved gateway"}
+	odd := testSample(testBase.Add(2 * time.Hour))
+	odd.Gateway.Signal.FiveG.Bands = []string{"n41", "n71", "n25", "n66", "n5"} // More than a record holds
+	odd.Gateway.Signal.FourG.Bands = []string{"B66"}
+
+	writeSamples(t, dir, in, odd)
+
+	got, err := Query(dir, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 samples, got %d", len(got))
+	}
+	if !reflect.DeepEqual(got[0], *in) {
+		t.Errorf("Round trip mismatch:\n got %+v\nwant %+v", got[0], *in)
+	}
+	if !reflect.DeepEqual(got[1], *odd) {
+		t.Errorf("Round trip mismatch for unencodable bands:\n got %+v\nwant %+v", got[1].Gateway.Signal, odd.Gateway.Signal)
+	}
+}
+
+func TestFloatPrecision(t *testing.T) {
+	dir := t.TempDir()
+	in := testSample(testBase)
+	in.Ping.Avg = 25.123456789
+	in.Gateway.Signal.FiveG.Bars = 3.3
+	writeSamples(t, dir, in)
+
+	got, err := Query(dir, time.Time{}, time.Time{})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("Query = %d samples, %v", len(got), err)
+	}
+	// Floats come back rounded to float32, not exactly
+	for _, v := range [][2]float64{{got[0].Ping.Avg, in.Ping.Avg}, {got[0].Gateway.Signal.FiveG.Bars, 3.3}} {
+		if v[0] != float64(float32(v[1])) || math.Abs(v[0]-v[1]) > 1e-6*v[1] {
+			t.Errorf("Read back %v for %v, want it rounded to float32", v[0], v[1])
+		}
+	}
+}
+
+func TestQueryRange(t *testing.T) {
+	dir := t.TempDir()
+	// Three days at one-minute intervals spans three partitions and many index strides
+	var samples []*models.CombinedStats
+	for i := 0; i < 3*24*60; i++ {
+		samples = append(samples, testSample(testBase.Add(time.Duration(i)*time.Minute)))
+	}
+	writeSamples(t, dir, samples...)
+
+	segs, err := listSegments(dir)
+	if err != nil || len(segs) != 3 {
+		t.Fatalf("Expected 3 segments, got %d (%v)", len(segs), err)
+	}
+
+	start := testBase.Add(36*time.Hour + 30*time.Second) // Rounds up to the next sample
+	end := testBase.Add(40 * time.Hour)
+	got, err := Query(dir, start, end)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if want := 4 * 60; len(got) != want {
+		t.Fatalf("Expected %d samples, got %d", want, len(got))
+	}
+	if first := time.Unix(got[0].Gateway.Time.LocalTime, 0); !first.Equal(testBase.Add(36*time.Hour + time.Minute)) {
+		t.Errorf("First sample at %v", first.UTC())
+	}
+	if last := time.Unix(got[len(got)-1].Gateway.Time.LocalTime, 0); !last.Equal(end) {
+		t.Errorf("Last sample at %v, want %v", last.UTC(), end)
+	}
+}
+
+func TestResumeRepairsTornTail(t *testing.T) {
+	dir := t.TempDir()
+	var samples []*models.CombinedStats
+	for i := 0; i < 200; i++ {
+		samples = append(samples, testSample(testBase.Add(time.Duration(i)*time.Minute)))
+	}
+	samples[199].Annotations = []string{"lost in the crash"}
+	writeSamples(t, dir, samples...)
+
+	segs, _ := listSegments(dir)
+	seg := segs[0]
+	info, _ := os.Stat(seg.seg())
+	// Tear the last record and drop the index, as a crash mid-write would
+	if err := os.Truncate(seg.seg(), info.Size()-10); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(seg.idx()); err != nil {
+		t.Fatal(err)
+	}
+
+	writeSamples(t, dir, testSample(testBase.Add(300*time.Minute)))
+
+	got, err := Query(dir, testBase.Add(150*time.Minute), time.Time{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(got) != 50 {
+		t.Fatalf("Expected 49 surviving samples plus the new one, got %d", len(got))
+	}
+	last := got[len(got)-1]
+	if last.Gateway.Time.LocalTime != testBase.Add(300*time.Minute).Unix() {
+		t.Errorf("Last sample at %v", time.Unix(last.Gateway.Time.LocalTime, 0).UTC())
+	}
+	if len(last.Annotations) != 0 {
+		t.Errorf("Note of the torn record attached to the new one: %v", last.Annotations)
+	}
+	if entries, _ := readIndex(seg.idx()); len(entries) != 2 || entries[1].Rec != IndexStride {
+		t.Errorf("Index not rebuilt: %+v", entries)
+	}
+	if segs, _ := listSegments(dir); len(segs) != 1 {
+		t.Errorf("Expected the segment to be resumed, got %d segments", len(segs))
+	}
+}
+
+func TestNewSegmentOnHeaderChangeAndReorder(t *testing.T) {
+	dir := t.TempDir()
+	a := testSample(testBase.Add(time.Hour))
+	b := testSample(testBase.Add(2 * time.Hour))
+	b.Targets = []models.PingStats{{Avg: 12, Sent: 5, Received: 5, Target: "1.1.1.1"}}
+	c := testSample(testBase.Add(90 * time.Minute)) // Gateway clock stepped back
+
+	writeSamples(t, dir, a, b, c)
+
+	segs, _ := listSegments(dir)
+	if len(segs) != 3 {
+		t.Fatalf("Expected 3 segments, got %d", len(segs))
+	}
+	got, err := Query(dir, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(got) != 3 || got[1].Gateway.Time.LocalTime != c.Gateway.Time.LocalTime || len(got[2].Targets) != 1 {
+		t.Errorf("Unexpected order or targets: %+v", got)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	writeSamples(t, dir, testSample(now.Add(-72*time.Hour)))
+
+	w, err := Open(dir, Options{MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Log(testSample(now)); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	segs, _ := listSegments(dir)
+	if len(segs) != 1 || !segs[0].Start.Equal(now.Truncate(DefaultPartition)) {
+		t.Errorf("Expected only today's segment, got %+v", segs)
+	}
+}
+
+func TestIsStore(t *testing.T) {
+	dir := t.TempDir()
+	if IsStore(dir) {
+		t.Error("Empty directory reported as a store")
+	}
+	writeSamples(t, dir, testSample(testBase))
+	if !IsStore(dir) {
+		t.Error("Directory with segments not reported as a store")
+	}
+	if !IsStore(dir + "/new" + Ext) {
+		t.Error("Missing *.tsdb path not reported as a store")
+	}
+}
@@ -0,0 +1,351 @@
+package tsdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"tmobile-stats/internal/models"
+)
+
+// Options configures a Writer.
+type Options struct {
+	Partition time.Duration // Time span of one segment (default DefaultPartition)
+	MaxAge    time.Duration // Delete segments whose partition ended longer ago than this (0 = keep)
+}
+
+// Writer appends samples to a store directory. It implements logger.Logger.
+type Writer struct {
+	dir  string
+	opts Options
+
+	seg   segmentFile
+	key   []byte // Encoded header of the open segment
+	size  int    // Record size of the open segment
+	data  *os.File
+	index *os.File
+	notes *os.File
+	count int
+	last  int64
+	buf   []byte
+}
+
+// Open creates dir if needed and returns a writer appending to it. Segments
+// left by an earlier run are continued when the header still matches, after
+// dropping a torn trailing record and completing the sparse index.
+func Open(dir string, opts Options) (*Writer, error) {
+	if opts.Partition <= 0 {
+		opts.Partition = DefaultPartition
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("could not create store: %w", err)
+	}
+	return &Writer{dir: dir, opts: opts}, nil
+}
+
+func (w *Writer) Log(s *models.CombinedStats) error {
+	h := headerFor(s, w.opts.Partition)
+	key, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	t := s.Gateway.Time.LocalTime
+
+	// Records within a segment stay in time order so the index can be searched
+	if w.data == nil || !bytes.Equal(key, w.key) || t < w.last {
+		if err := w.switchSegment(&h, key, t); err != nil {
+			return err
+		}
+	}
+
+	if cap(w.buf) < w.size {
+		w.buf = make([]byte, w.size)
+	}
+	buf := w.buf[:w.size]
+	ex := encodeRecord(buf, s)
+
+	if _, err := w.data.Write(buf); err != nil {
+		return fmt.Errorf("could not write record: %w", err)
+	}
+	if w.count%IndexStride == 0 {
+		if _, err := w.index.Write(encodeIndexEntry(indexEntry{Time: t, Rec: uint32(w.count)})); err != nil {
+			return fmt.Errorf("could not write index: %w", err)
+		}
+	}
+	if !ex.empty() {
+		ex.N = w.count
+		if err := w.writeNotes(&ex); err != nil {
+			return err
+		}
+	}
+	w.count++
+	w.last = t
+	return nil
+}
+
+func (w *Writer) writeNotes(ex *extras) error {
+	if w.notes == nil {
+		f, err := os.OpenFile(w.seg.notes(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("could not open notes: %w", err)
+		}
+		w.notes = f
+	}
+	line, err := json.Marshal(ex)
+	if err != nil {
+		return err
+	}
+	if _, err := w.notes.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("could not write notes: %w", err)
+	}
+	return nil
+}
+
+// switchSegment closes the open segment and opens the one a sample with
+// header h at time t belongs to: the partition's latest segment if its header
+// matches and it ends before t, otherwise a new one.
+func (w *Writer) switchSegment(h *header, key []byte, t int64) error {
+	if err := w.closeSegment(); err != nil {
+		return err
+	}
+
+	segs, err := listSegments(w.dir)
+	if err != nil {
+		return fmt.Errorf("could not list segments: %w", err)
+	}
+	start := time.Unix(h.Start, 0).UTC()
+	n := 0
+	var latest *segmentFile
+	for i := range segs {
+		if segs[i].Start.Equal(start) {
+			latest = &segs[i]
+			n = segs[i].N + 1
+		}
+	}
+
+	if latest != nil {
+		ok, err := w.resume(*latest, key, t)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	if err := w.create(segmentFile{Base: segmentBase(w.dir, start, n), Start: start, N: n}, h, key); err != nil {
+		return err
+	}
+	return w.prune()
+}
+
+func (w *Writer) create(sf segmentFile, h *header, key []byte) error {
+	hdr, err := h.encode()
+	if err != nil {
+		return err
+	}
+	data, err := os.OpenFile(sf.seg(), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("could not create segment: %w", err)
+	}
+	if _, err := data.Write(hdr); err != nil {
+		data.Close()
+		return fmt.Errorf("could not write segment header: %w", err)
+	}
+	index, err := os.OpenFile(sf.idx(), os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		data.Close()
+		return fmt.Errorf("could not create index: %w", err)
+	}
+	os.Remove(sf.notes()) // Left over from a segment that was never written
+
+	w.seg, w.key, w.size = sf, key, h.recordSize()
+	w.data, w.index, w.count, w.last = data, index, 0, 0
+	return nil
+}
+
+// resume reopens sf for appending if its header equals key and its last
+// record is not after t. It repairs a torn tail and a short index first.
+func (w *Writer) resume(sf segmentFile, key []byte, t int64) (bool, error) {
+	data, err := os.OpenFile(sf.seg(), os.O_RDWR, 0644)
+	if err != nil {
+		return false, fmt.Errorf("could not open segment: %w", err)
+	}
+	h, off, err := readHeader(data)
+	if err != nil {
+		data.Close()
+		return false, nil // Unreadable segments are left alone; a new one is started
+	}
+	if existing, err := json.Marshal(h); err != nil || !bytes.Equal(existing, key) {
+		data.Close()
+		return false, nil
+	}
+
+	info, err := data.Stat()
+	if err != nil {
+		data.Close()
+		return false, err
+	}
+	size := int64(h.recordSize())
+	count := (info.Size() - off) / size
+	if tail := off + count*size; tail != info.Size() {
+		if err := data.Truncate(tail); err != nil {
+			data.Close()
+			return false, fmt.Errorf("could not repair segment: %w", err)
+		}
+	}
+
+	var last int64
+	if count > 0 {
+		rec := make([]byte, size)
+		if _, err := data.ReadAt(rec, off+(count-1)*size); err != nil {
+			data.Close()
+			return false, err
+		}
+		last = recordTime(rec)
+	}
+	if last > t {
+		data.Close()
+		return false, nil
+	}
+
+	index, err := repairIndex(sf.idx(), data, off, size, count)
+	if err != nil {
+		data.Close()
+		return false, err
+	}
+	if err := repairNotes(sf.notes(), int(count)); err != nil {
+		data.Close()
+		index.Close()
+		return false, err
+	}
+	if _, err := data.Seek(0, io.SeekEnd); err != nil {
+		data.Close()
+		index.Close()
+		return false, err
+	}
+
+	w.seg, w.key, w.size = sf, key, int(size)
+	w.data, w.index, w.count, w.last = data, index, int(count), last
+	return true, nil
+}
+
+// repairIndex makes the index hold exactly one entry per IndexStride of the
+// count records and returns it opened for appending.
+func repairIndex(path string, data *os.File, off, size, count int64) (*os.File, error) {
+	want := (count + IndexStride - 1) / IndexStride
+	entries, err := readIndex(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	index, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not open index: %w", err)
+	}
+	have := int64(len(entries))
+	if have > want {
+		have = want
+	}
+	if err := index.Truncate(have * indexEntrySize); err != nil {
+		index.Close()
+		return nil, fmt.Errorf("could not repair index: %w", err)
+	}
+
+	rec := make([]byte, size)
+	for i := have; i < want; i++ {
+		n := i * IndexStride
+		if _, err := data.ReadAt(rec, off+n*size); err != nil {
+			index.Close()
+			return nil, err
+		}
+		if _, err := index.Write(encodeIndexEntry(indexEntry{Time: recordTime(rec), Rec: uint32(n)})); err != nil {
+			index.Close()
+			return nil, fmt.Errorf("could not repair index: %w", err)
+		}
+	}
+	return index, nil
+}
+
+// repairNotes drops notes for records lost when the segment was truncated,
+// so they don't attach to the records written next.
+func repairNotes(path string, count int) error {
+	notes, err := readNotes(path)
+	if err != nil || notes == nil {
+		return err
+	}
+	stale := false
+	for n := range notes {
+		stale = stale || n >= count
+	}
+	if !stale {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	for n := 0; n < count; n++ {
+		if ex, ok := notes[n]; ok {
+			line, err := json.Marshal(ex)
+			if err != nil {
+				return err
+			}
+			buf.Write(append(line, '\n'))
+		}
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("could not repair notes: %w", err)
+	}
+	return nil
+}
+
+// prune deletes segments whose partition ended more than MaxAge ago.
+func (w *Writer) prune() error {
+	if w.opts.MaxAge <= 0 {
+		return nil
+	}
+	segs, err := listSegments(w.dir)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Now().Add(-w.opts.MaxAge)
+	for _, sf := range segs {
+		if sf.Base == w.seg.Base {
+			continue
+		}
+		f, err := os.Open(sf.seg())
+		if err != nil {
+			continue
+		}
+		h, _, err := readHeader(f)
+		f.Close()
+		if err != nil || !h.end().Before(cutoff) {
+			continue
+		}
+		for _, p := range []string{sf.seg(), sf.idx(), sf.notes()} {
+			if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *Writer) closeSegment() error {
+	var firstErr error
+	for _, f := range []*os.File{w.data, w.index, w.notes} {
+		if f == nil {
+			continue
+		}
+		if err := f.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	w.data, w.index, w.notes = nil, nil, nil
+	return firstErr
+}
+
+func (w *Writer) Close() error {
+	return w.closeSegment()
+}
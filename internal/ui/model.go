@@ -2,33 +2,26 @@ package ui
 
 import (
 	"fmt"
-	"net/http"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/collector"
 	"tmobile-stats/internal/config"
-	"tmobile-stats/internal/gateway"
-	"tmobile-stats/internal/logger"
 	"tmobile-stats/internal/models"
-	"tmobile-stats/internal/pinger"
 )
 
 // Msg types
-type tickMsg time.Time
-type dataMsg struct {
-	Stats        *models.CombinedStats
-	LifetimePing models.PingStats
-	Err          error
-}
+type dataMsg collector.Sample
+type closedMsg struct{}
 
-// Model represents the state of the TUI.
+// Model represents the state of the TUI. It renders samples delivered by a
+// Source and never talks to the gateway itself.
 type Model struct {
 	cfg          *config.Config
-	client       *http.Client
-	pinger       *pinger.Pinger
-	loggers      []logger.Logger
+	src          Source
 	buffer       []*models.CombinedStats
 	lifetimePing models.PingStats
 	interval     time.Duration
@@ -38,22 +31,17 @@ type Model struct {
 	err          error
 }
 
-func NewModel(cfg *config.Config, client *http.Client, pg *pinger.Pinger, loggers []logger.Logger) *Model {
+func NewModel(cfg *config.Config, src Source) *Model {
 	return &Model{
 		cfg:      cfg,
-		client:   client,
-		pinger:   pg,
-		loggers:  loggers,
-		interval: time.Duration(cfg.RefreshInterval) * time.Second,
+		src:      src,
+		interval: src.Interval(),
 		buffer:   make([]*models.CombinedStats, 0, 30),
 	}
 }
 
 func (m *Model) Init() tea.Cmd {
-	return tea.Batch(
-		m.fetchData(),
-		m.tick(),
-	)
+	return m.waitForSample()
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -69,22 +57,25 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.interval > 60*time.Second {
 				m.interval = 60 * time.Second
 			}
+			if err := m.src.SetInterval(m.interval); err != nil {
+				m.err = err
+			}
 		case "-":
 			m.interval -= time.Second
 			if m.interval < time.Second {
 				m.interval = time.Second
 			}
+			if err := m.src.SetInterval(m.interval); err != nil {
+				m.err = err
+			}
 		}
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
 
-	case tickMsg:
-		return m, tea.Batch(
-			m.fetchData(),
-			m.tick(),
-		)
+	case closedMsg:
+		return m, tea.Quit
 
 	case dataMsg:
 		if msg.Err != nil {
@@ -92,46 +83,28 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.err = nil
 			m.lifetimePing = msg.LifetimePing // Update lifetime stats
-			
-			// 1. Log data
-			for _, l := range m.loggers {
-				_ = l.Log(msg.Stats) // Best effort logging
-			}
+			m.interval = m.src.Interval()     // May have been changed over the control socket
 
-			// 2. Prepend to buffer
+			// Prepend to buffer
 			m.buffer = append([]*models.CombinedStats{msg.Stats}, m.buffer...)
 			if len(m.buffer) > 30 {
 				m.buffer = m.buffer[:30]
 			}
 		}
+		return m, m.waitForSample()
 	}
 
 	return m, nil
 }
 
-func (m *Model) tick() tea.Cmd {
-	return tea.Tick(m.interval, func(t time.Time) tea.Msg {
-		return tickMsg(t)
-	})
-}
-
-func (m *Model) fetchData() tea.Cmd {
+// waitForSample blocks on the source until the next sample arrives.
+func (m *Model) waitForSample() tea.Cmd {
 	return func() tea.Msg {
-		gatewayData, err := gateway.FetchStats(m.client, m.cfg.RouterURL)
-		if err != nil {
-			return dataMsg{Err: err}
-		}
-
-		pingData := m.pinger.GetStatsAndReset()
-		lifetimePing := m.pinger.GetLifetimeStats()
-
-		return dataMsg{
-			Stats: &models.CombinedStats{
-				Gateway: *gatewayData,
-				Ping:    pingData,
-			},
-			LifetimePing: lifetimePing,
+		s, ok := m.src.Next()
+		if !ok {
+			return closedMsg{}
 		}
+		return dataMsg(s)
 	}
 }
 
@@ -182,17 +155,41 @@ func (m *Model) View() string {
 
 	// 2. Metrics Guide (Small version)
 	s.WriteString("RSRP: Exc >-80, Good -95, Fair -110, Poor <-110 | SINR: Exc >20, Poor <0\n")
-	
+
 	// 3. Lifetime Ping Stats
 	// PING: 531 packets transmitted, 531 packets received, 0.0% packet loss
 	// round-trip min/avg/max/stddev = 20.986/49.955/855.485/53.432 ms
 	lp := m.lifetimePing
-	s.WriteString(fmt.Sprintf("PING: %d packets transmitted, %d packets received, %.1f%% packet loss\n", 
+	s.WriteString(fmt.Sprintf("PING: %d packets transmitted, %d packets received, %.1f%% packet loss\n",
 		lp.Sent, lp.Received, lp.Loss))
-	s.WriteString(fmt.Sprintf("round-trip min/avg/max/stddev = %.3f/%.3f/%.3f/%.3f ms\n", 
+	s.WriteString(fmt.Sprintf("round-trip min/avg/max/stddev = %.3f/%.3f/%.3f/%.3f ms\n",
 		lp.Min, lp.Avg, lp.Max, lp.StdDev))
+	if lp.Sent > 0 {
+		voip := analysis.CalculateVoIPScoreForPing(lp)
+		s.WriteString(fmt.Sprintf("VOIP: MOS %.2f, R %.1f (%s)\n", voip.MOS, voip.R, voip.Rating()))
+	}
 
-	s.WriteString(fmt.Sprintf("Interval: %v (Press +/- to adjust, i for info, q to quit)\n\n", m.interval))
+	// Additional targets (e.g. IPv6 or QoS-marked probes), latest interval only
+	targetLines := 0
+	if len(m.buffer) > 0 {
+		for _, t := range m.buffer[0].Targets {
+			s.WriteString(fmt.Sprintf("TARGET %s: avg %.1f ms, stddev %.1f ms, %.1f%% loss\n",
+				t.Label(), t.Avg, t.StdDev, t.Loss))
+			targetLines++
+		}
+	}
+
+	intervalStr := m.interval.String()
+	if status := m.src.SchedulerStatus(); status != "" {
+		intervalStr += " [" + status + "]"
+	}
+	s.WriteString(fmt.Sprintf("Interval: %s (Press +/- to adjust, i for info, q to quit)\n", intervalStr))
+	logLines := 0
+	if logs := m.src.LogStatus(); logs != "" {
+		s.WriteString(fmt.Sprintf("Logs: %s\n", logs))
+		logLines++
+	}
+	s.WriteString("\n")
 
 	if m.err != nil {
 		s.WriteString(errorStyle.Render(fmt.Sprintf("Error: %v", m.err)) + "\n\n")
@@ -202,14 +199,14 @@ func (m *Model) View() string {
 		s.WriteString(helpText + "\n")
 	} else {
 		// 3. Header
-		s.WriteString(headerStyle.Render(" BANDS       | BARS    | RSRP      | SINR      | RSRQ      | RSSI      | CID         | TOWER             | MIN AVG MAX STD LOSS") + "\n")
-		s.WriteString("-------------+---------+-----------+-----------+-----------+-----------+-------------+-------------------+-------------------------\n")
+		s.WriteString(headerStyle.Render(" BANDS       | BARS    | RSRP      | SINR      | RSRQ      | RSSI      | CID         | TOWER             | MOS  | MIN AVG MAX STD LOSS") + "\n")
+		s.WriteString("-------------+---------+-----------+-----------+-----------+-----------+-------------+-------------------+------+-------------------------\n")
 
 		// 4. Buffer
 		// guideLines: Device(1), Metrics(1), PingStats(2), Interval(1), Empty(1), Header(1), Separator(1) = 8
-		guideLines := 9 // Adjusted for 2 extra ping lines + safety
+		guideLines := 10 // Adjusted for 2 extra ping lines, VoIP line + safety
 		linesUsed := 0
-		maxLines := m.height - guideLines
+		maxLines := m.height - guideLines - targetLines - logLines
 		if maxLines < 0 {
 			maxLines = 0
 		}
@@ -307,7 +304,12 @@ func (m *Model) renderRow(fiveG, fourG models.ConnectionStats, ping models.PingS
 	}
 
 	// Row Printf with explicit spaces to match header
-	return fmt.Sprintf(" %-11s | %s | %s | %s | %-9s | %-9s | %-11s | %-17s | %.1f %.1f %.1f %.1f %s \n",
+	mosStr := "----"
+	if ping.Sent > 0 {
+		mosStr = m.colorizeMOS(analysis.CalculateVoIPScoreForPing(ping).MOS)
+	}
+
+	return fmt.Sprintf(" %-11s | %s | %s | %s | %-9s | %-9s | %-11s | %-17s | %s | %.1f %.1f %.1f %.1f %s \n",
 		bandsStr,
 		barsStr,
 		rsrpStr,
@@ -316,6 +318,7 @@ func (m *Model) renderRow(fiveG, fourG models.ConnectionStats, ping models.PingS
 		combineInts(fiveG.RSSI, fourG.RSSI, has5g, has4g),
 		combineInts(fiveG.CID, fourG.CID, has5g, has4g),
 		combineInts(tower5g, tower4g, has5g, has4g),
+		mosStr,
 		ping.Min, ping.Avg, ping.Max, ping.StdDev, lossStr,
 	)
 }
@@ -341,6 +344,16 @@ func (m *Model) colorizeSINR(val int) string {
 	return lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(s)
 }
 
+func (m *Model) colorizeMOS(val float64) string {
+	s := fmt.Sprintf("%4.2f", val)
+	if val >= 4.0 {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("2")).Render(s)
+	} else if val >= 3.6 {
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("3")).Render(s)
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Render(s)
+}
+
 func (m *Model) colorizeBars(val float64) string {
 	s := fmt.Sprintf("%3.1f", val)
 	if val >= 4.0 {
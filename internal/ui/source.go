@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"time"
+
+	"tmobile-stats/internal/collector"
+	"tmobile-stats/internal/logger"
+)
+
+// Source feeds the dashboard. The TUI only renders what a source delivers,
+// so it works the same on top of an in-process collector or a stream from
+// a running daemon.
+type Source interface {
+	// Next blocks until the next sample; ok is false once the stream ended.
+	Next() (s collector.Sample, ok bool)
+	// Interval is the current base poll interval.
+	Interval() time.Duration
+	// SetInterval changes the base poll interval of the collector.
+	SetInterval(d time.Duration) error
+	// SchedulerStatus describes the adaptive scheduler state for the status line.
+	SchedulerStatus() string
+	// LogStatus summarises the written, dropped and failed log writes.
+	LogStatus() string
+}
+
+// LocalSource reads from a collector in the same process.
+type LocalSource struct {
+	coll *collector.Collector
+	sub  *collector.Subscription
+	logs *logger.Fanout
+}
+
+// NewLocalSource renders samples from sub, a subscription on coll. logs,
+// if not nil, feeds the log counters on the status line.
+func NewLocalSource(coll *collector.Collector, sub *collector.Subscription, logs *logger.Fanout) *LocalSource {
+	return &LocalSource{coll: coll, sub: sub, logs: logs}
+}
+
+func (s *LocalSource) Next() (collector.Sample, bool) {
+	sample, ok := <-s.sub.C
+	return sample, ok
+}
+
+func (s *LocalSource) Interval() time.Duration {
+	return s.coll.Scheduler().Base()
+}
+
+func (s *LocalSource) SetInterval(d time.Duration) error {
+	s.coll.SetInterval(d)
+	return nil
+}
+
+func (s *LocalSource) SchedulerStatus() string {
+	return s.coll.Scheduler().Status(time.Now())
+}
+
+func (s *LocalSource) LogStatus() string {
+	if s.logs == nil {
+		return ""
+	}
+	return logger.SummarizeSinks(s.logs.Stats())
+}
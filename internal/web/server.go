@@ -5,10 +5,8 @@ import (
 	"html/template"
 	"log"
 	"net/http"
-	"os"
 	"time"
 
-	"tmobile-stats/internal/analysis"
 	"tmobile-stats/internal/charting"
 )
 
@@ -124,20 +122,25 @@ type PageData struct {
 	LastUpdated  string
 }
 
-func Run(port int, logFile string, quiet bool) error {
+// Run serves the dashboard, charting samples from store. A non-nil
+// metrics handler is mounted on /metrics.
+func Run(port int, store Store, metrics http.Handler, quiet bool) error {
 	mux := http.NewServeMux()
+	if metrics != nil {
+		mux.Handle("/metrics", metrics)
+	}
 
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		handleIndex(w, r, quiet)
 	})
 
 	mux.HandleFunc("/chart.png", func(w http.ResponseWriter, r *http.Request) {
-		handleChart(w, r, logFile, quiet)
+		handleChart(w, r, store, quiet)
 	})
 
 	addr := fmt.Sprintf(":%d", port)
 	if !quiet {
-		log.Printf("Starting web server on http://localhost%s (Input: %s)", addr, logFile)
+		log.Printf("Starting web server on http://localhost%s", addr)
 	}
 	return http.ListenAndServe(addr, mux)
 }
@@ -204,23 +207,16 @@ func handleIndex(w http.ResponseWriter, r *http.Request, quiet bool) {
 	}
 }
 
-func handleChart(w http.ResponseWriter, r *http.Request, logFile string, quiet bool) {
+func handleChart(w http.ResponseWriter, r *http.Request, store Store, quiet bool) {
 	filter, _, err := parseTimeFilter(r)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Filter Error: %v", err), http.StatusBadRequest)
 		return
 	}
 
-	f, err := os.Open(logFile)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("File Error: %v", err), http.StatusInternalServerError)
-		return
-	}
-	defer f.Close()
-
-	data, err := analysis.ParseLog(f, filter)
+	data, err := store.Query(filter)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Parse Error: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Store Error: %v", err), http.StatusInternalServerError)
 		return
 	}
 
@@ -0,0 +1,113 @@
+package web
+
+import (
+	"os"
+	"sync"
+	"time"
+
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/models"
+)
+
+// Store supplies the samples the web server charts.
+type Store interface {
+	Query(filter *analysis.TimeFilter) ([]models.CombinedStats, error)
+}
+
+// FileStore reads samples from a log file, including its rotated
+// segments, or an SQLite database on every query (standalone mode).
+// Long ranges are read from the log's rollups when there are any.
+type FileStore struct {
+	Path       string
+	Resolution string // As for analysis.LoadHistory; empty means "auto"
+}
+
+// Query loads the samples inside filter from the log or its rollups.
+func (s *FileStore) Query(filter *analysis.TimeFilter) ([]models.CombinedStats, error) {
+	res := s.Resolution
+	if res == "" {
+		res = "auto"
+	}
+	data, _, err := analysis.LoadHistory(s.Path, filter, res)
+	return data, err
+}
+
+// LiveStore keeps recent samples in memory, fed from the collector in
+// unified mode, so charts no longer re-read the log on every request.
+// Queries reaching further back than the retained window go to Fallback.
+type LiveStore struct {
+	Retain   time.Duration
+	Fallback Store
+
+	mu      sync.RWMutex
+	samples []models.CombinedStats
+	since   time.Time
+}
+
+// NewLiveStore creates a store retaining samples for retain, seeded from
+// fallback (if non-nil) so the charts start with recent history.
+func NewLiveStore(retain time.Duration, fallback Store) (*LiveStore, error) {
+	s := &LiveStore{Retain: retain, Fallback: fallback, since: time.Now().Add(-retain)}
+	if fallback == nil {
+		return s, nil
+	}
+
+	// The retained window is kept at full resolution, even when a query
+	// that long would otherwise be answered from rollups
+	seeder := fallback
+	if fs, ok := fallback.(*FileStore); ok {
+		seeder = &FileStore{Path: fs.Path, Resolution: "raw"}
+	}
+	seed, err := seeder.Query(&analysis.TimeFilter{Start: s.since})
+	if err != nil && !os.IsNotExist(err) {
+		return s, err
+	}
+	s.samples = seed
+	return s, nil
+}
+
+// Add appends a freshly collected sample and trims samples older than Retain.
+func (s *LiveStore) Add(stats *models.CombinedStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples = append(s.samples, *stats)
+
+	cutoff := time.Now().Add(-s.Retain)
+	drop := 0
+	for drop < len(s.samples) && sampleTime(&s.samples[drop]).Before(cutoff) {
+		drop++
+	}
+	if drop > 0 {
+		s.samples = append([]models.CombinedStats(nil), s.samples[drop:]...)
+	}
+	if cutoff.After(s.since) {
+		s.since = cutoff
+	}
+}
+
+// Query returns the retained samples inside filter, or defers to Fallback
+// when the filter starts before the retained window.
+func (s *LiveStore) Query(filter *analysis.TimeFilter) ([]models.CombinedStats, error) {
+	s.mu.RLock()
+	covered := filter != nil && !filter.Start.Before(s.since)
+	s.mu.RUnlock()
+
+	if !covered && s.Fallback != nil {
+		return s.Fallback.Query(filter)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []models.CombinedStats
+	for i := range s.samples {
+		if filter.Contains(sampleTime(&s.samples[i])) {
+			out = append(out, s.samples[i])
+		}
+	}
+	return out, nil
+}
+
+func sampleTime(s *models.CombinedStats) time.Time {
+	return time.Unix(s.Gateway.Time.LocalTime, 0)
+}
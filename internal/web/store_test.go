@@ -0,0 +1,57 @@
+package web
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/models"
+)
+
+func statsAt(t time.Time, model string) *models.CombinedStats {
+	s := &models.CombinedStats{}
+	s.Gateway.Time.LocalTime = t.Unix()
+	s.Gateway.Device.Model = model
+	return s
+}
+
+func TestLiveStore(t *testing.T) {
+	now := time.Now()
+	path := filepath.Join(t.TempDir(), "stats.log")
+	log := `{"gateway":{"device":{"model":"OLD"},"time":{"localTime":` + itoa(now.Add(-3*time.Hour).Unix()) + `}}}
+{"gateway":{"device":{"model":"SEED"},"time":{"localTime":` + itoa(now.Add(-30*time.Minute).Unix()) + `}}}
+`
+	if err := os.WriteFile(path, []byte(log), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewLiveStore(time.Hour, &FileStore{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add(statsAt(now, "LIVE"))
+
+	// Inside the retained window: seeded history plus live samples, no file read
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := store.Query(&analysis.TimeFilter{Start: now.Add(-45 * time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 2 || data[0].Gateway.Device.Model != "SEED" || data[1].Gateway.Device.Model != "LIVE" {
+		t.Errorf("Expected [SEED LIVE], got %d samples", len(data))
+	}
+
+	// Beyond the retained window the store falls back to the (now missing) file
+	if _, err := store.Query(&analysis.TimeFilter{Start: now.Add(-4 * time.Hour)}); err == nil {
+		t.Error("Expected fallback to the log file for an older range")
+	}
+}
+
+func itoa(v int64) string {
+	return strconv.FormatInt(v, 10)
+}
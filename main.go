@@ -4,18 +4,20 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"tmobile-stats/internal/analysis"
 	"tmobile-stats/internal/charting"
+	"tmobile-stats/internal/collector"
 	"tmobile-stats/internal/config"
-	"tmobile-stats/internal/gateway"
-	"tmobile-stats/internal/logger"
 	"tmobile-stats/internal/models"
 	"tmobile-stats/internal/pinger"
+	"tmobile-stats/internal/rollup"
+	"tmobile-stats/internal/scheduler"
 	"tmobile-stats/internal/ui"
 	"tmobile-stats/internal/web"
 
@@ -25,6 +27,9 @@ import (
 const (
 	headerInterval = 20
 	Version        = "v1.1.0"
+
+	// How much history the unified-mode web server keeps in memory
+	liveStoreRetention = 24 * time.Hour
 )
 
 func main() {
@@ -33,18 +38,22 @@ func main() {
 
 	// Temporarily define other flags to avoid parsing errors
 	intervalFlag := flag.Int("interval", 0, "Refresh interval in seconds")
-	formatFlag := flag.String("format", "", "Output format (json or csv)")
-	outputFlag := flag.String("output", "", "Output filename")
+	formatFlag := flag.String("format", "", "Output format (json, csv, sqlite, tsdb or influx)")
+	outputFlag := flag.String("output", "", "Output filename (- for stdout with -format influx)")
 	versionFlag := flag.Bool("version", false, "Show version information")
 	liveFlag := flag.Bool("live", false, "Enable interactive live view")
 	noAutoLogFlag := flag.Bool("no-auto-log", false, "Disable automatic logging to stats.log")
 	webFlag := flag.Bool("web", false, "Enable background web server (Unified Mode)")
 	webPortFlag := flag.Int("web-port", 8080, "Port for background web server")
 	silentFlag := flag.Bool("silent", false, "Suppress all standard output (errors to stderr)")
+	rawLogFlag := flag.String("raw-log", "", "Write every individual ping result to this file")
+	adaptiveFlag := flag.Bool("adaptive", false, "Poll at a short burst interval after signal degradation")
+	metricsPortFlag := flag.Int("metrics-port", 0, "Serve Prometheus /metrics on this port (also on the -web server)")
+	socketFlag := flag.String("socket", "", "Control socket for status/ctl (default per-user runtime path, \"none\" disables)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Signal Sentry - T-Mobile Gateway Signal Monitor (%s)\n\n", Version)
-		fmt.Fprintf(os.Stderr, "Usage:\n  signal-sentry [flags]\n  signal-sentry analyze [flags]\n  signal-sentry chart [flags]\n  signal-sentry web [flags]\n\n")
+		fmt.Fprintf(os.Stderr, "Usage:\n  signal-sentry [flags]\n  signal-sentry analyze [flags]\n  signal-sentry chart [flags]\n  signal-sentry web [flags]\n  signal-sentry mtu [flags]\n  signal-sentry daemon [flags]\n  signal-sentry install-service [flags]\n  signal-sentry status [flags]\n  signal-sentry ctl [flags] <command>\n  signal-sentry attach [flags]\n  signal-sentry import [flags] <log>...\n  signal-sentry export [flags]\n  signal-sentry merge [flags] <log>...\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 	}
@@ -63,6 +72,33 @@ func main() {
 		case "web":
 			runWeb(os.Args[2:])
 			return
+		case "mtu":
+			runMTU(os.Args[2:])
+			return
+		case "daemon":
+			runDaemon(os.Args[2:])
+			return
+		case "install-service":
+			runInstallService(os.Args[2:])
+			return
+		case "status":
+			runStatus(os.Args[2:])
+			return
+		case "ctl":
+			runCtl(os.Args[2:])
+			return
+		case "attach":
+			runAttach(os.Args[2:])
+			return
+		case "import":
+			runImport(os.Args[2:])
+			return
+		case "export":
+			runExport(os.Args[2:])
+			return
+		case "merge":
+			runMerge(os.Args[2:])
+			return
 		}
 	}
 
@@ -97,108 +133,84 @@ func main() {
 			cfg.WebPort = *webPortFlag
 		case "silent":
 			cfg.Silent = *silentFlag
+		case "raw-log":
+			cfg.RawPingLog = *rawLogFlag
+		case "adaptive":
+			cfg.Adaptive.Enabled = *adaptiveFlag
+		case "socket":
+			cfg.ControlSocket = *socketFlag
+		case "metrics-port":
+			cfg.MetricsPort = *metricsPortFlag
 		}
 	})
 
 	// 4. Validate Final Config
-	if err := validateInterval(cfg.RefreshInterval); err != nil {
+	if err := validateConfig(cfg); err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
-	if err := validateFormat(cfg.Format); err != nil {
+	// 5. Initialize loggers, pingers and the collector
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	m, err := newMonitor(cfg)
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
-	// Determine default filename if not provided but format is set
-	if cfg.Format != "" && cfg.Output == "" {
-		if cfg.Format == "json" {
-			cfg.Output = "signal-data.json"
-		} else {
-			cfg.Output = "signal-data.csv"
-		}
-	}
-
-	// Initialize Loggers
-	var loggers []logger.Logger
-	if cfg.Format != "" {
-		var l logger.Logger
-		var err error
-		if cfg.Format == "json" {
-			l, err = logger.NewJSONLogger(cfg.Output)
-		} else if cfg.Format == "csv" {
-			l, err = logger.NewCSVLogger(cfg.Output)
-		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
-			os.Exit(1)
-		}
-		loggers = append(loggers, l)
-		defer l.Close()
-	}
-
-	if !cfg.DisableAutoLog && cfg.Output != "stats.log" {
-		l, err := logger.NewJSONLogger("stats.log")
-		if err == nil {
-			loggers = append(loggers, l)
-			defer l.Close()
-		}
-	}
-
-	// 5. Initialize Pinger
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	pg := pinger.NewPinger(cfg.PingTarget, 1*time.Second)
-	go pg.Run(ctx)
-
-	client := &http.Client{Timeout: 5 * time.Second}
-
 	// 6. Start Web Server (Unified Mode)
 	if cfg.WebEnabled {
-		// Use stats.log as input, or cfg.Output if set and auto-log is disabled (edge case)
-		// For consistency, we always prefer stats.log for the web view unless it's disabled.
-		inputLog := "stats.log"
-		if cfg.DisableAutoLog && cfg.Output != "" {
-			inputLog = cfg.Output
-		}
-		
 		// If we are not in live mode, we can print a startup message.
 		// If we are in live mode, we must be quiet.
-		quiet := cfg.LiveMode || cfg.Silent
-		if !quiet {
-			fmt.Printf("Starting background web server on port %d (reading %s)...\n", cfg.WebPort, inputLog)
-		}
-
-		go func() {
-			if err := web.Run(cfg.WebPort, inputLog, quiet); err != nil {
-				// If live mode, we can't really log this without breaking TUI. 
-				if !cfg.LiveMode {
-					fmt.Fprintf(os.Stderr, "Web server error: %v\n", err)
-				}
-			}
-		}()
+		m.startWeb(cfg.LiveMode || cfg.Silent)
+	}
+	m.startMetrics(cfg.LiveMode || cfg.Silent)
+	if err := m.startOTLP(ctx, cfg.LiveMode || cfg.Silent); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		m.Close()
+		os.Exit(1)
 	}
+	m.startControl(ctx, cfg.LiveMode)
 
 	// 7. Branch Execution
 	if cfg.LiveMode {
-		p := tea.NewProgram(ui.NewModel(cfg, client, pg, loggers), tea.WithAltScreen())
-		if _, err := p.Run(); err != nil {
+		uiSub := m.coll.Subscribe("tui", 4)
+		go m.Run(ctx)
+
+		p := tea.NewProgram(ui.NewModel(cfg, ui.NewLocalSource(m.coll, uiSub, m.logs)), tea.WithAltScreen())
+		go func() {
+			<-ctx.Done()
+			p.Quit()
+		}()
+		_, err := p.Run()
+
+		// Stop polling and let the loggers drain before closing them
+		stop()
+		m.Close()
+		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error running UI: %v\n", err)
 			os.Exit(1)
 		}
 	} else {
-		runLegacyLoop(cfg, client, pg, loggers)
+		printSub := m.coll.Subscribe("printer", 16)
+		go m.Run(ctx)
+		runLegacyLoop(cfg, m.coll.Scheduler(), printSub)
+		if err := m.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error closing logs: %v\n", err)
+		}
 	}
 }
 
 func runAnalysis(args []string) {
 	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
-	inputPtr := fs.String("input", "stats.log", "Path to log file to analyze")
+	inputPtr := fs.String("input", "stats.log", "Path to log file or SQLite database to analyze")
 	startPtr := fs.String("start", "", "Start time (YYYY-MM-DD [HH:MM:SS])")
 	endPtr := fs.String("end", "", "End time (YYYY-MM-DD [HH:MM:SS])")
 	rangePtr := fs.Duration("range", 0, "Relative time range from now (e.g. 24h, 1h30m)")
+	rawPtr := fs.String("raw", "", "Path to a per-packet ping log for loss-burst analysis")
+	resPtr := fs.String("resolution", "auto", "Data to read: raw, 1m or 1h rollups, or auto to pick by range")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: signal-sentry analyze [flags]\n\n")
@@ -212,19 +224,30 @@ func runAnalysis(args []string) {
 		os.Exit(1)
 	}
 
-	if err := analysis.Run(*inputPtr, filter); err != nil {
+	if err := analysis.Run(*inputPtr, filter, *resPtr); err != nil {
 		fmt.Fprintf(os.Stderr, "Analysis failed: %v\n", err)
 		os.Exit(1)
 	}
+
+	if *rawPtr != "" {
+		fmt.Println()
+		if err := analysis.RunRaw(*rawPtr, filter); err != nil {
+			fmt.Fprintf(os.Stderr, "Raw ping analysis failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
 
 func runChart(args []string) {
 	fs := flag.NewFlagSet("chart", flag.ExitOnError)
-	inputPtr := fs.String("input", "stats.log", "Path to log file to analyze")
+	inputPtr := fs.String("input", "stats.log", "Path to log file or SQLite database to analyze")
 	outputPtr := fs.String("output", "signal-analysis.png", "Path to save the chart image")
 	startPtr := fs.String("start", "", "Start time (YYYY-MM-DD [HH:MM:SS])")
 	endPtr := fs.String("end", "", "End time (YYYY-MM-DD [HH:MM:SS])")
 	rangePtr := fs.Duration("range", 0, "Relative time range from now (e.g. 24h, 1h30m)")
+	rawPtr := fs.String("raw", "", "Path to a per-packet ping log for a high-resolution latency chart")
+	rawOutputPtr := fs.String("raw-output", "signal-latency.png", "Path to save the per-packet latency chart")
+	resPtr := fs.String("resolution", "auto", "Data to read: raw, 1m or 1h rollups, or auto to pick by range")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: signal-sentry chart [flags]\n\n")
@@ -238,32 +261,49 @@ func runChart(args []string) {
 		os.Exit(1)
 	}
 
-	file, err := os.Open(*inputPtr)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to open input file: %v\n", err)
-		os.Exit(1)
-	}
-	defer file.Close()
-
 	fmt.Printf("Parsing log file: %s ...\n", *inputPtr)
-	data, err := analysis.ParseLog(file, filter)
+	data, res, err := analysis.LoadHistory(*inputPtr, filter, *resPtr)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to parse log: %v\n", err)
 		os.Exit(1)
 	}
+	if res != 0 {
+		fmt.Printf("Using %s rollups\n", rollup.Name(res))
+	}
 
 	fmt.Printf("Generating chart: %s ...\n", *outputPtr)
 	if err := charting.Generate(data, *outputPtr); err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to generate chart: %v\n", err)
 		os.Exit(1)
 	}
+
+	if *rawPtr != "" {
+		rawFile, err := os.Open(*rawPtr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open raw ping log: %v\n", err)
+			os.Exit(1)
+		}
+		defer rawFile.Close()
+
+		results, err := analysis.ParseRawLog(rawFile, filter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to parse raw ping log: %v\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Generating latency chart: %s ...\n", *rawOutputPtr)
+		if err := charting.GenerateLatency(results, *rawOutputPtr); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to generate latency chart: %v\n", err)
+			os.Exit(1)
+		}
+	}
 	fmt.Println("Done!")
 }
 
 func runWeb(args []string) {
 	fs := flag.NewFlagSet("web", flag.ExitOnError)
 	portPtr := fs.Int("port", 8080, "Port to listen on")
-	inputPtr := fs.String("input", "stats.log", "Path to log file to analyze")
+	inputPtr := fs.String("input", "stats.log", "Path to log file or SQLite database to analyze")
 
 	fs.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: signal-sentry web [flags]\n\n")
@@ -271,38 +311,68 @@ func runWeb(args []string) {
 	}
 	fs.Parse(args)
 
-	if err := web.Run(*portPtr, *inputPtr, false); err != nil {
+	if err := web.Run(*portPtr, &web.FileStore{Path: *inputPtr}, nil, false); err != nil {
 		fmt.Fprintf(os.Stderr, "Web server failed: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-func runLegacyLoop(cfg *config.Config, client *http.Client, pg *pinger.Pinger, loggers []logger.Logger) {
-	refreshDuration := time.Duration(cfg.RefreshInterval) * time.Second
+func runMTU(args []string) {
+	fs := flag.NewFlagSet("mtu", flag.ExitOnError)
+	targetPtr := fs.String("target", "8.8.8.8", "Host to probe")
+	networkPtr := fs.String("network", "", "Address family to use (ip4 or ip6)")
+	minPtr := fs.Int("min", 548, "Smallest ICMP payload to try (bytes)")
+	maxPtr := fs.Int("max", 0, "Largest ICMP payload to try (default 1472 for ip4, 1452 for ip6)")
+	tosPtr := fs.Int("tos", 0, "DSCP/TOS byte to mark probes with")
+	attemptsPtr := fs.Int("attempts", 3, "Probes per size before treating it as too big")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: signal-sentry mtu [flags]\n\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	opts := models.PingOptions{Network: *networkPtr, TOS: *tosPtr}
+	if err := validatePingTargets([]config.PingTarget{{Host: *targetPtr, PingOptions: opts}}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *minPtr < minPingPayload {
+		fmt.Fprintf(os.Stderr, "invalid -min: %d. Must be at least %d bytes\n", *minPtr, minPingPayload)
+		os.Exit(1)
+	}
+
+	max := *maxPtr
+	if max == 0 {
+		max = 1500 - pinger.IPv4Overhead
+		if opts.Network == "ip6" {
+			max = 1500 - pinger.IPv6Overhead
+		}
+	}
+
+	fmt.Printf("Sweeping ICMP payload %d-%d bytes to %s with don't-fragment set...\n", *minPtr, max, *targetPtr)
+	res, err := pinger.SweepMTU(*targetPtr, opts, *minPtr, max, *attemptsPtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "MTU sweep failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Largest unfragmented payload: %d bytes (path MTU %d, %d probes)\n", res.Payload, res.MTU, res.Probes)
+}
+
+// runLegacyLoop prints collector samples as a scrolling table.
+func runLegacyLoop(cfg *config.Config, sched *scheduler.Adaptive, sub *collector.Subscription) {
 	firstRun := true
 	linesPrinted := 0
 
-	for {
-		gatewayData, err := gateway.FetchStats(client, cfg.RouterURL)
-		if err != nil {
+	for s := range sub.C {
+		if s.Err != nil {
 			if !cfg.Silent {
-				fmt.Fprintf(os.Stderr, "Error fetching stats: %v\n", err)
+				fmt.Fprintf(os.Stderr, "Error fetching stats: %v\n", s.Err)
 			}
-			time.Sleep(refreshDuration)
 			continue
 		}
-
-		pingData := pg.GetStatsAndReset()
-		data := &models.CombinedStats{
-			Gateway: *gatewayData,
-			Ping:    pingData,
-		}
-
-		for _, l := range loggers {
-			if err := l.Log(data); err != nil {
-				fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
-			}
-		}
+		data := s.Stats
 
 		if firstRun {
 			if !cfg.Silent {
@@ -320,7 +390,12 @@ func runLegacyLoop(cfg *config.Config, client *http.Client, pg *pinger.Pinger, l
 			printRow(data.Gateway.Signal.FiveG, data.Gateway.Signal.FourG, data.Ping)
 			linesPrinted++
 		}
-		time.Sleep(refreshDuration)
+
+		// A burst that this very sample started is not flagged on the sample itself
+		if burst, reason := sched.Burst(s.Time); burst && !data.Burst && !cfg.Silent {
+			fmt.Printf(">>> Burst capture at %v for %s\n", sched.Delay(s.Time), reason)
+			linesPrinted = 0
+		}
 	}
 }
 
@@ -466,8 +541,6 @@ func printRow(fiveG, fourG models.ConnectionStats, ping models.PingStats) {
 	)
 }
 
-
-
 func colorizeRSRP(val int) string {
 	s := fmt.Sprintf("%4d", val)
 	if val > -80 {
@@ -496,4 +569,4 @@ func colorizeBars(val float64) string {
 		return fmt.Sprintf("%s%s%s", ColorYellow, s, ColorReset)
 	}
 	return fmt.Sprintf("%s%s%s", ColorRed, s, ColorReset)
-}
\ No newline at end of file
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/export"
+	"tmobile-stats/internal/models"
+)
+
+// maxReportedSpans caps each list of overlaps or gaps merge prints.
+const maxReportedSpans = 20
+
+// runMerge combines logs from several collectors, or from restarts that
+// wrote to different files, into one JSON log in time order.
+func runMerge(args []string) {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	var output string
+	fs.StringVar(&output, "o", "merged.log", "Merged JSON log to write")
+	fs.StringVar(&output, "output", "merged.log", "Same as -o")
+	gapPtr := fs.Duration("gap", time.Minute, "Report stretches without samples longer than this")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: signal-sentry merge [flags] <log> <log>...\n\n")
+		fs.PrintDefaults()
+	}
+	inputs := parseInterspersed(fs, args)
+	if len(inputs) == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *gapPtr <= 0 {
+		fmt.Fprintf(os.Stderr, "Error: -gap must be positive\n")
+		os.Exit(2)
+	}
+	outAbs, _ := filepath.Abs(output)
+	for _, in := range inputs {
+		if inAbs, _ := filepath.Abs(in); inAbs == outAbs {
+			fmt.Fprintf(os.Stderr, "Error: %s is both an input and the output\n", in)
+			os.Exit(2)
+		}
+	}
+
+	var data [][]models.CombinedStats
+	for _, path := range inputs {
+		d, err := analysis.LoadLog(path, nil)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		data = append(data, d)
+	}
+
+	merged, st, err := analysis.MergeLogs(data, *gapPtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Merge failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", output, err)
+		os.Exit(1)
+	}
+	err = export.Write(f, "jsonl", merged, export.Options{})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(output)
+		fmt.Fprintf(os.Stderr, "Failed to write %s: %v\n", output, err)
+		os.Exit(1)
+	}
+
+	for i, path := range inputs {
+		fmt.Printf("%s: %d samples%s\n", path, st.Read[i], spanOf(data[i]))
+	}
+	fmt.Printf("Wrote %d samples to %s (%d duplicates dropped", st.Written, output, st.Duplicates)
+	if st.Conflicts > 0 {
+		fmt.Printf(", %d kept that share a time with a different sample", st.Conflicts)
+	}
+	fmt.Println(")")
+
+	if len(st.Overlaps) > 0 {
+		fmt.Println("\nOverlaps:")
+		printSpans(st.Overlaps, func(s analysis.Span) string {
+			names := make([]string, len(s.Inputs))
+			for i, in := range s.Inputs {
+				names[i] = inputs[in]
+			}
+			return strings.Join(names, ", ")
+		})
+	}
+	if len(st.Gaps) > 0 {
+		fmt.Printf("\nGaps longer than %s:\n", *gapPtr)
+		printSpans(st.Gaps, nil)
+	}
+}
+
+// parseInterspersed parses flags that may come before, between or after
+// the positional arguments, which it returns.
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var rest []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			return rest
+		}
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// spanOf describes the time a log covers.
+func spanOf(data []models.CombinedStats) string {
+	if len(data) == 0 {
+		return ""
+	}
+	first, last := data[0].Gateway.Time.LocalTime, data[0].Gateway.Time.LocalTime
+	for i := range data {
+		first = min(first, data[i].Gateway.Time.LocalTime)
+		last = max(last, data[i].Gateway.Time.LocalTime)
+	}
+	return fmt.Sprintf(", %s to %s", formatSpanTime(time.Unix(first, 0)), formatSpanTime(time.Unix(last, 0)))
+}
+
+func printSpans(spans []analysis.Span, detail func(analysis.Span) string) {
+	for i, s := range spans {
+		if i == maxReportedSpans {
+			fmt.Printf("  ... and %d more\n", len(spans)-i)
+			break
+		}
+		line := fmt.Sprintf("  %s to %s (%s)", formatSpanTime(s.Start), formatSpanTime(s.End), s.End.Sub(s.Start))
+		if detail != nil {
+			line += ": " + detail(s)
+		}
+		fmt.Println(line)
+	}
+}
+
+func formatSpanTime(t time.Time) string {
+	return t.Format("2006-01-02 15:04:05")
+}
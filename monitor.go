@@ -0,0 +1,333 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	"tmobile-stats/internal/analysis"
+	"tmobile-stats/internal/collector"
+	"tmobile-stats/internal/config"
+	"tmobile-stats/internal/control"
+	"tmobile-stats/internal/logger"
+	"tmobile-stats/internal/metrics"
+	"tmobile-stats/internal/models"
+	"tmobile-stats/internal/pinger"
+	"tmobile-stats/internal/redact"
+	"tmobile-stats/internal/rollup"
+	"tmobile-stats/internal/scheduler"
+	"tmobile-stats/internal/tsdb"
+	"tmobile-stats/internal/web"
+)
+
+// monitor wires the loggers, pingers and collector shared by the live,
+// legacy and daemon modes.
+type monitor struct {
+	cfg      *config.Config
+	logs     *logger.Fanout // One queue and goroutine per log sink
+	raw      *logger.RawLogger
+	pg       *pinger.Group
+	coll     *collector.Collector
+	metrics  *metrics.Exporter
+	logDone  chan struct{}
+	otlpDone chan struct{} // Closed after the final OTLP push; nil if disabled
+}
+
+// newMonitor opens the configured logs and builds the collector. Nothing
+// runs until Run is called; Close must be called either way.
+func newMonitor(cfg *config.Config) (*monitor, error) {
+	m := &monitor{cfg: cfg, logDone: make(chan struct{})}
+
+	// Determine default filename if not provided but format is set
+	if cfg.Format != "" && cfg.Output == "" {
+		switch cfg.Format {
+		case "json":
+			cfg.Output = "signal-data.json"
+		case "sqlite":
+			cfg.Output = "signal-data.db"
+		case "tsdb":
+			cfg.Output = "signal-data" + tsdb.Ext
+		case "influx":
+			cfg.Output = "signal-data.lp"
+		default:
+			cfg.Output = "signal-data.csv"
+		}
+	}
+
+	rot, err := rotationPolicy(cfg.Rotation)
+	if err != nil {
+		return nil, err
+	}
+	rot.OnError = func(err error) {
+		if !cfg.LiveMode {
+			fmt.Fprintf(os.Stderr, "Log rotation error: %v\n", err)
+		}
+	}
+	logOpts, err := fanoutOptions(cfg.Logging)
+	if err != nil {
+		return nil, err
+	}
+	rd, err := redactor(cfg.Redact)
+	if err != nil {
+		return nil, err
+	}
+	m.logs = logger.NewFanout(logOpts, func(err error) {
+		if !cfg.LiveMode {
+			fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
+		}
+	})
+
+	for _, sc := range sinkConfigs(cfg) {
+		l, err := logger.Build(logger.SinkSpec{Type: sc.Type, Options: sc.Options, Rotation: rot})
+		if err != nil {
+			m.closeLogs()
+			return nil, fmt.Errorf("failed to initialize %s sink: %w", sc.Name, err)
+		}
+		l = logger.NewFilter(l, sc.Fields, sc.SampleEvery)
+		if !sc.DisableRedact {
+			l = logger.NewRedacting(l, rd)
+		}
+		m.logs.Add(sc.Name, l)
+	}
+
+	if !cfg.DisableAutoLog && cfg.Output != "stats.log" {
+		l, err := logger.NewRotatingJSONLogger("stats.log", rot)
+		if err == nil {
+			m.logs.Add("stats.log", logger.NewRedacting(l, rd))
+		}
+	}
+
+	if cfg.Rollup.Enabled {
+		if err := m.startRollup(rd); err != nil {
+			m.closeLogs()
+			return nil, fmt.Errorf("failed to open rollups: %w", err)
+		}
+	}
+
+	var pingers []*pinger.Pinger
+	for _, t := range cfg.Targets() {
+		p := pinger.NewPinger(t.Host, 1*time.Second)
+		p.Options = t.PingOptions
+		pingers = append(pingers, p)
+	}
+	m.pg = pinger.NewGroup(pingers...)
+	if cfg.RawPingLog != "" {
+		raw, err := logger.NewRawLogger(cfg.RawPingLog)
+		if err != nil {
+			m.closeLogs()
+			return nil, fmt.Errorf("failed to initialize raw ping log: %w", err)
+		}
+		m.raw = raw
+		if rd != nil {
+			m.pg.SetRaw(logger.RedactingRaw{RawLogger: raw, R: rd})
+		} else {
+			m.pg.SetRaw(raw)
+		}
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	sched := scheduler.New(cfg.Adaptive, time.Duration(cfg.RefreshInterval)*time.Second)
+	m.coll = collector.New(cfg.RouterURL, client, m.pg, sched)
+
+	// Every consumer subscribes before the collector starts so none misses
+	// the first sample. The exporter is shared by the web server, /metrics
+	// and OTLP, so it is built once here rather than by whichever starts first.
+	m.metrics = metrics.New(Version, m.pg)
+	go m.metrics.Consume(m.coll.Subscribe("metrics", 16))
+	logSub := m.coll.Subscribe("loggers", 64)
+	go func() {
+		defer close(m.logDone)
+		collector.LogTo(logSub, []logger.Logger{m.logs}, func(err error) {
+			if !cfg.LiveMode {
+				fmt.Fprintf(os.Stderr, "Logging error: %v\n", err)
+			}
+		})
+	}()
+
+	return m, nil
+}
+
+// historyLog is the log history is read back from: stats.log, or
+// cfg.Output if auto-log is disabled.
+func historyLog(cfg *config.Config) string {
+	if cfg.DisableAutoLog && cfg.Output != "" {
+		return cfg.Output
+	}
+	return "stats.log"
+}
+
+// startRollup adds a sink summarising samples into rollups next to the
+// history log, after catching up in the background with whatever was
+// logged since the last stored buckets.
+func (m *monitor) startRollup(rd *redact.Redactor) error {
+	src := historyLog(m.cfg)
+	r, err := rollup.Open(rollup.DirFor(src), rollup.Retention{
+		time.Minute: time.Duration(m.cfg.Rollup.MinuteDays) * 24 * time.Hour,
+		time.Hour:   time.Duration(m.cfg.Rollup.HourDays) * 24 * time.Hour,
+	})
+	if err != nil {
+		return err
+	}
+	done := r.CatchUp(func(since time.Time, fn func(*models.CombinedStats) error) error {
+		_, err := analysis.ScanLog(src, &analysis.TimeFilter{Start: since}, fn)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	})
+	m.logs.Add("rollup", logger.NewRedacting(r, rd))
+
+	go func() {
+		if err := <-done; err != nil && !m.cfg.LiveMode {
+			fmt.Fprintf(os.Stderr, "Rollup catch-up failed: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// startWeb serves the dashboard from an in-memory store fed by the collector.
+func (m *monitor) startWeb(quiet bool) {
+	// Recent history is seeded from the history log; after that the server
+	// is fed straight from the collector.
+	inputLog := historyLog(m.cfg)
+	if !quiet {
+		fmt.Printf("Starting background web server on port %d (history from %s)...\n", m.cfg.WebPort, inputLog)
+	}
+
+	store, err := web.NewLiveStore(liveStoreRetention, &web.FileStore{Path: inputLog})
+	if err != nil && !quiet {
+		fmt.Fprintf(os.Stderr, "Web history unavailable: %v\n", err)
+	}
+	webSub := m.coll.Subscribe("web", 16)
+	go func() {
+		for s := range webSub.C {
+			if s.Stats != nil {
+				store.Add(s.Stats)
+			}
+		}
+	}()
+
+	go func() {
+		if err := web.Run(m.cfg.WebPort, store, m.metrics, quiet); err != nil {
+			// If live mode, we can't really log this without breaking TUI.
+			if !m.cfg.LiveMode {
+				fmt.Fprintf(os.Stderr, "Web server error: %v\n", err)
+			}
+		}
+	}()
+}
+
+// startMetrics serves /metrics on its own port, for setups that scrape
+// without running the web dashboard.
+func (m *monitor) startMetrics(quiet bool) {
+	port := m.cfg.MetricsPort
+	if port <= 0 || (m.cfg.WebEnabled && port == m.cfg.WebPort) {
+		return // Disabled, or already served by the web server
+	}
+	if !quiet {
+		fmt.Printf("Serving Prometheus metrics on port %d...\n", port)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.metrics)
+	go func() {
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", port), mux); err != nil && !m.cfg.LiveMode {
+			fmt.Fprintf(os.Stderr, "Metrics server error: %v\n", err)
+		}
+	}()
+}
+
+// startOTLP pushes the exporter's metrics to an OpenTelemetry collector
+// until ctx is cancelled.
+func (m *monitor) startOTLP(ctx context.Context, quiet bool) error {
+	cfg := m.cfg.OTLP
+	if cfg.Endpoint == "" {
+		return nil
+	}
+	headers := cfg.Headers
+	if len(headers) == 0 {
+		headers = parseOTLPHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	}
+	p, err := metrics.NewOTLPPusher(m.metrics, metrics.OTLPConfig{
+		Endpoint: cfg.Endpoint,
+		Headers:  headers,
+		Interval: time.Duration(cfg.IntervalSeconds) * time.Second,
+		Site:     cfg.Site,
+	})
+	if err != nil {
+		return err
+	}
+	if !quiet {
+		fmt.Printf("Pushing OTLP metrics to %s...\n", cfg.Endpoint)
+	}
+
+	m.otlpDone = make(chan struct{})
+	go func() {
+		defer close(m.otlpDone)
+		p.Run(ctx, func(err error) {
+			if !m.cfg.LiveMode {
+				fmt.Fprintf(os.Stderr, "OTLP export error: %v\n", err)
+			}
+		})
+	}()
+	return nil
+}
+
+// startControl serves the JSON-RPC control socket used by `status` and `ctl`.
+// Failing to listen (e.g. another instance owns the socket) is not fatal.
+func (m *monitor) startControl(ctx context.Context, quiet bool) {
+	path := m.cfg.ControlSocket
+	if path == "none" {
+		return
+	}
+	if path == "" {
+		path = control.DefaultSocketPath()
+	}
+
+	srv := control.NewServer(m.coll, Version)
+	srv.SetLogs(m.logs)
+	if err := srv.Listen(path); err != nil {
+		if !quiet {
+			fmt.Fprintf(os.Stderr, "Control socket disabled: %v\n", err)
+		}
+		return
+	}
+	go func() {
+		if err := srv.Serve(ctx); err != nil && !quiet {
+			fmt.Fprintf(os.Stderr, "Control socket error: %v\n", err)
+		}
+		os.Remove(path)
+	}()
+}
+
+// Run pings and polls until ctx is cancelled.
+func (m *monitor) Run(ctx context.Context) {
+	go m.pg.Run(ctx)
+	m.coll.Run(ctx)
+}
+
+// Close stops publishing, waits for the log sinks to write every queued
+// sample and then flushes and closes them.
+func (m *monitor) Close() error {
+	m.coll.Bus().Close()
+	<-m.logDone
+	if m.otlpDone != nil {
+		<-m.otlpDone
+	}
+	return m.closeLogs()
+}
+
+func (m *monitor) closeLogs() error {
+	var errs []error
+	if m.logs != nil {
+		errs = append(errs, m.logs.Close())
+	}
+	if m.raw != nil {
+		errs = append(errs, m.raw.Close())
+	}
+	return errors.Join(errs...)
+}
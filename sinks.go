@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"tmobile-stats/internal/config"
+	"tmobile-stats/internal/logger"
+	"tmobile-stats/internal/mqtt"
+	"tmobile-stats/internal/tsdb"
+)
+
+// The store and the MQTT publisher live outside logger, so their sink
+// types are registered here.
+func init() {
+	logger.Register("tsdb", func(spec logger.SinkSpec) (logger.Logger, error) {
+		opts := logger.FileOptions{}
+		if err := logger.DecodeOptions(spec, &opts); err != nil {
+			return nil, err
+		}
+		if opts.Path == "" {
+			opts.Path = "signal-data" + tsdb.Ext
+		}
+		// Segments are partitioned by the rotation period and pruned by its max age
+		return tsdb.Open(opts.Path, tsdb.Options{Partition: spec.Rotation.Every, MaxAge: spec.Rotation.MaxAge})
+	})
+	logger.Register("mqtt", func(spec logger.SinkSpec) (logger.Logger, error) {
+		var opts config.MQTTConfig
+		if err := logger.DecodeOptions(spec, &opts); err != nil {
+			return nil, err
+		}
+		if opts.Password == "" {
+			opts.Password = os.Getenv("MQTT_PASSWORD")
+		}
+		return mqtt.NewPublisher(mqtt.Config{
+			Broker:           opts.Broker,
+			ClientID:         opts.ClientID,
+			Username:         opts.Username,
+			Password:         opts.Password,
+			TopicPrefix:      opts.TopicPrefix,
+			DiscoveryPrefix:  opts.DiscoveryPrefix,
+			DisableDiscovery: opts.DisableDiscovery,
+		})
+	})
+}
+
+// sinkConfigs returns every sink to open: the legacy format/output pair
+// first, then the sinks list, then the influx and mqtt sections. The
+// always-on stats.log is not included. Names are made unique.
+func sinkConfigs(cfg *config.Config) []config.SinkConfig {
+	var sinks []config.SinkConfig
+	if cfg.Format != "" {
+		path, _ := json.Marshal(logger.FileOptions{Path: cfg.Output})
+		sinks = append(sinks, config.SinkConfig{Type: cfg.Format, Options: path})
+	}
+	sinks = append(sinks, cfg.Sinks...)
+	if cfg.Influx.URL != "" {
+		opts, _ := json.Marshal(cfg.Influx)
+		sinks = append(sinks, config.SinkConfig{Type: "influxdb", Options: opts})
+	}
+	if cfg.MQTT.Broker != "" {
+		opts, _ := json.Marshal(cfg.MQTT)
+		sinks = append(sinks, config.SinkConfig{Type: "mqtt", Options: opts})
+	}
+
+	seen := map[string]int{}
+	for i := range sinks {
+		s := &sinks[i]
+		if s.Name == "" {
+			s.Name = s.Type
+		}
+		if seen[s.Name]++; seen[s.Name] > 1 {
+			s.Name = fmt.Sprintf("%s-%d", s.Name, seen[s.Name])
+		}
+	}
+	return sinks
+}
+
+// validateSinks checks the sinks list without opening anything.
+func validateSinks(sinks []config.SinkConfig) error {
+	for i, s := range sinks {
+		if s.Type == "" {
+			return fmt.Errorf("sinks[%d]: type is required", i)
+		}
+		if !logger.Registered(s.Type) {
+			return fmt.Errorf("sinks[%d]: unknown type %q. Use one of %v", i, s.Type, logger.Types())
+		}
+		if s.SampleEvery < 0 {
+			return fmt.Errorf("sinks[%d]: sample_every must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// validateStdout rejects an influx sink streaming to stdout ("-") while the
+// console output, which also goes there, is on.
+func validateStdout(cfg *config.Config) error {
+	if cfg.Silent && !cfg.LiveMode {
+		return nil
+	}
+	for _, s := range sinkConfigs(cfg) {
+		var opts logger.FileOptions
+		if s.Type == "influx" && json.Unmarshal(s.Options, &opts) == nil && opts.Path == "-" {
+			return fmt.Errorf("%s: writing to stdout needs -silent or the daemon, and can't be combined with -live", s.Name)
+		}
+	}
+	return nil
+}